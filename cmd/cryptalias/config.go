@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/kaigoh/cryptalias/internal/cryptalias"
+)
+
+const configUsage = `usage:
+  cryptalias config check [--json] <config.yml>
+  cryptalias config diff [--json] <old.yml> <new.yml>`
+
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(configUsage)
+	}
+	flags := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	jsonOut := flags.Bool("json", false, "output JSON")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "check" && flags.NArg() == 1:
+		return runConfigCheck(flags.Arg(0), *jsonOut)
+	case args[0] == "diff" && flags.NArg() == 2:
+		return runConfigDiff(flags.Arg(0), flags.Arg(1), *jsonOut)
+	}
+	return fmt.Errorf(configUsage)
+}
+
+func runConfigCheck(path string, jsonOut bool) error {
+	_, warnings, err := cryptalias.CheckConfig(path)
+	if jsonOut {
+		report := struct {
+			Path     string   `json:"path"`
+			Valid    bool     `json:"valid"`
+			Error    string   `json:"error,omitempty"`
+			Warnings []string `json:"warnings"`
+		}{Path: path, Valid: err == nil, Warnings: warnings}
+		if err != nil {
+			report.Error = err.Error()
+		}
+		if report.Warnings == nil {
+			report.Warnings = []string{}
+		}
+		if perr := printJSON(report); perr != nil {
+			return perr
+		}
+	} else {
+		for _, w := range warnings {
+			if perr := printf("warning: %s\n", w); perr != nil {
+				return perr
+			}
+		}
+		if err == nil {
+			return printf("%s: ok\n", path)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func runConfigDiff(oldPath, newPath string, jsonOut bool) error {
+	oldCfg, _, err := cryptalias.CheckConfig(oldPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", oldPath, err)
+	}
+	newCfg, _, err := cryptalias.CheckConfig(newPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", newPath, err)
+	}
+	changes := cryptalias.DiffConfigs(oldCfg, newCfg)
+	if jsonOut {
+		if changes == nil {
+			changes = []cryptalias.ConfigChange{}
+		}
+		return printJSON(changes)
+	}
+	if len(changes) == 0 {
+		return printf("no changes\n")
+	}
+	for _, c := range changes {
+		if err := printf("%s\n", c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
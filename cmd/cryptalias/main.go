@@ -19,6 +19,24 @@ func main() {
 		}
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "alias" {
+		if err := runAlias(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "domain" {
+		if err := runDomain(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfig(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	// We DON'T want to be running as root...
 	if os.Getuid() == 0 {
@@ -54,26 +72,63 @@ func runResolve(args []string) error {
 		return fmt.Errorf("alias must be in the format alias$domain (tip: wrap in single quotes to avoid shell expansion)")
 	}
 
-	address, err := cryptalias.ResolveAddress(context.Background(), ticker, alias)
+	dest, err := cryptalias.ResolveDestination(context.Background(), ticker, alias)
 	if err != nil {
 		return err
 	}
 
 	if *jsonOut {
 		output := struct {
-			Alias   string `json:"alias"`
-			Ticker  string `json:"ticker"`
-			Address string `json:"address"`
+			Alias          string  `json:"alias"`
+			Ticker         string  `json:"ticker"`
+			Address        string  `json:"address"`
+			Memo           string  `json:"memo,omitempty"`
+			DestinationTag *uint32 `json:"destination_tag,omitempty"`
+			PaymentID      string  `json:"payment_id,omitempty"`
 		}{
-			Alias:   alias,
-			Ticker:  strings.ToLower(ticker),
-			Address: address,
+			Alias:          alias,
+			Ticker:         strings.ToLower(ticker),
+			Address:        dest.Address,
+			Memo:           dest.Memo,
+			DestinationTag: dest.DestinationTag,
+			PaymentID:      dest.PaymentID,
 		}
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
 		return enc.Encode(output)
 	}
 
-	_, err = fmt.Fprintf(os.Stdout, "%s %s\n", strings.ToLower(ticker), address)
+	line := strings.ToLower(ticker) + " " + dest.Address
+	// Destination fields follow the address so scripts splitting on spaces
+	// still find the address in the second column.
+	if dest.Memo != "" {
+		line += " memo=" + dest.Memo
+	}
+	if dest.DestinationTag != nil {
+		line += fmt.Sprintf(" destination_tag=%d", *dest.DestinationTag)
+	}
+	if dest.PaymentID != "" {
+		line += " payment_id=" + dest.PaymentID
+	}
+	_, err = fmt.Fprintln(os.Stdout, line)
+	return err
+}
+
+func runAliasMigrate(args []string) error {
+	flags := flag.NewFlagSet("alias migrate", flag.ContinueOnError)
+	dbPath := flags.String("db", "", "SQLite database path (default <config>.aliases.db)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	configPath := "config.yml"
+	if flags.NArg() > 0 {
+		configPath = flags.Arg(0)
+	}
+
+	n, err := cryptalias.MigrateAliasesToSQLite(configPath, *dbPath)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "migrated %d aliases; %s now uses the sqlite alias store (backup in %s.bak)\n", n, configPath, configPath)
 	return err
 }
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/kaigoh/cryptalias/internal/cryptalias"
+)
+
+const aliasUsage = `usage:
+  cryptalias alias add [flags] <alias$domain> <ticker> [address]
+  cryptalias alias list [flags] <domain>
+  cryptalias alias show [flags] <alias$domain>
+  cryptalias alias remove [flags] [--ticker t] <alias$domain>
+  cryptalias alias tag add [flags] [--entry t] <alias+tag$domain> <ticker> [address]
+  cryptalias alias tag remove [flags] [--entry t] <alias+tag$domain>
+  cryptalias alias migrate [--db path] [config.yml]`
+
+const domainUsage = `usage:
+  cryptalias domain add [flags] <domain>
+  cryptalias domain list [flags]
+  cryptalias domain remove [flags] <domain>`
+
+// manageFlags are shared by the alias and domain subcommands. Without
+// --admin-url the config file is edited directly.
+type manageFlags struct {
+	*flag.FlagSet
+	config     string
+	adminURL   string
+	adminToken string
+	json       bool
+}
+
+func newManageFlags(name string) *manageFlags {
+	f := &manageFlags{FlagSet: flag.NewFlagSet(name, flag.ContinueOnError)}
+	f.StringVar(&f.config, "config", "config.yml", "config file to edit")
+	f.StringVar(&f.adminURL, "admin-url", os.Getenv("CRYPTALIAS_ADMIN_URL"), "edit through a running server's admin API, e.g. http://127.0.0.1:8081")
+	f.StringVar(&f.adminToken, "admin-token", os.Getenv("CRYPTALIAS_ADMIN_TOKEN"), "admin API bearer token")
+	f.BoolVar(&f.json, "json", false, "output JSON")
+	return f
+}
+
+func (f *manageFlags) open() (cryptalias.AliasManager, error) {
+	if f.adminURL == "" {
+		return cryptalias.OpenFileAliasManager(f.config)
+	}
+	if f.adminToken == "" {
+		return nil, fmt.Errorf("--admin-token (or CRYPTALIAS_ADMIN_TOKEN) is required with --admin-url")
+	}
+	return cryptalias.NewAdminAliasManager(f.adminURL, f.adminToken), nil
+}
+
+// walletFlags set the optional routing hints and destination fields of a
+// wallet.
+type walletFlags struct {
+	accountIndex int64
+	accountID    string
+	walletID     string
+	addressMode  string
+	memo         string
+}
+
+func addWalletFlags(f *manageFlags) *walletFlags {
+	w := &walletFlags{}
+	f.Int64Var(&w.accountIndex, "account-index", -1, "account index hint")
+	f.StringVar(&w.accountID, "account-id", "", "account id hint")
+	f.StringVar(&w.walletID, "wallet-id", "", "wallet id hint")
+	f.StringVar(&w.addressMode, "address-mode", "", "address mode override, e.g. integrated")
+	f.StringVar(&w.memo, "memo", "", "memo payers must include")
+	return w
+}
+
+// wallet builds the wallet from the <ticker> [address] arguments.
+func (w *walletFlags) wallet(args []string) cryptalias.WalletAddress {
+	out := cryptalias.WalletAddress{Ticker: strings.ToLower(args[0])}
+	if len(args) > 1 {
+		out.Address = args[1]
+	}
+	if w.accountIndex >= 0 {
+		index := uint64(w.accountIndex)
+		out.AccountIndex = &index
+	}
+	for _, hint := range []struct {
+		value string
+		field **string
+	}{{w.accountID, &out.AccountID}, {w.walletID, &out.WalletID}, {w.addressMode, &out.AddressMode}, {w.memo, &out.Memo}} {
+		if hint.value != "" {
+			v := hint.value
+			*hint.field = &v
+		}
+	}
+	return out
+}
+
+func runAlias(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(aliasUsage)
+	}
+	switch args[0] {
+	case "add":
+		return runAliasAdd(args[1:])
+	case "list":
+		return runAliasList(args[1:])
+	case "show":
+		return runAliasShow(args[1:])
+	case "remove":
+		return runAliasRemove(args[1:])
+	case "migrate":
+		return runAliasMigrate(args[1:])
+	case "tag":
+		if len(args) > 1 && args[1] == "add" {
+			return runTagAdd(args[2:])
+		}
+		if len(args) > 1 && args[1] == "remove" {
+			return runTagRemove(args[2:])
+		}
+	}
+	return fmt.Errorf(aliasUsage)
+}
+
+func runAliasAdd(args []string) error {
+	f := newManageFlags("alias add")
+	hints := addWalletFlags(f)
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() < 2 || f.NArg() > 3 {
+		return fmt.Errorf(aliasUsage)
+	}
+	name, tag, domain, err := cryptalias.ParseAliasName(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tag != "" {
+		return fmt.Errorf("use `cryptalias alias tag add` for tags")
+	}
+	alias := cryptalias.WalletAlias{Alias: name, Wallet: hints.wallet(f.Args()[1:])}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		if err := m.AddAlias(domain, alias); err != nil {
+			return err
+		}
+		if f.json {
+			return printJSON(alias)
+		}
+		return printf("added %s$%s (%s)\n", name, domain, alias.Wallet.Ticker)
+	})
+}
+
+func runAliasList(args []string) error {
+	f := newManageFlags("alias list")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 1 {
+		return fmt.Errorf(aliasUsage)
+	}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		list, err := m.ListAliases(strings.ToLower(f.Arg(0)))
+		if err != nil {
+			return err
+		}
+		return printAliases(f, list)
+	})
+}
+
+func runAliasShow(args []string) error {
+	f := newManageFlags("alias show")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 1 {
+		return fmt.Errorf(aliasUsage)
+	}
+	name, _, domain, err := cryptalias.ParseAliasName(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		list, err := m.ListAliases(domain)
+		if err != nil {
+			return err
+		}
+		var entries []cryptalias.WalletAlias
+		for _, a := range list {
+			if a.Alias == name {
+				entries = append(entries, a)
+			}
+		}
+		if len(entries) == 0 {
+			return cryptalias.ErrAliasNotFound
+		}
+		return printAliases(f, entries)
+	})
+}
+
+func runAliasRemove(args []string) error {
+	f := newManageFlags("alias remove")
+	ticker := f.String("ticker", "", "remove only the entry for this ticker")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 1 {
+		return fmt.Errorf(aliasUsage)
+	}
+	name, _, domain, err := cryptalias.ParseAliasName(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		if err := m.RemoveAlias(domain, name, strings.ToLower(*ticker)); err != nil {
+			return err
+		}
+		return printRemoved(f, name+"$"+domain)
+	})
+}
+
+func runTagAdd(args []string) error {
+	f := newManageFlags("alias tag add")
+	entry := f.String("entry", "", "ticker of the alias entry to tag (default: the tag's ticker)")
+	hints := addWalletFlags(f)
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() < 2 || f.NArg() > 3 {
+		return fmt.Errorf(aliasUsage)
+	}
+	name, tagName, domain, err := cryptalias.ParseAliasName(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tagName == "" {
+		return fmt.Errorf("tag is required: alias+tag$domain")
+	}
+	tag := cryptalias.WalletTag{Tag: tagName, Wallet: hints.wallet(f.Args()[1:])}
+	if *entry == "" {
+		*entry = tag.Wallet.Ticker
+	}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		if err := m.PutTag(domain, name, strings.ToLower(*entry), tag); err != nil {
+			return err
+		}
+		if f.json {
+			return printJSON(tag)
+		}
+		return printf("tagged %s+%s$%s (%s)\n", name, tagName, domain, tag.Wallet.Ticker)
+	})
+}
+
+func runTagRemove(args []string) error {
+	f := newManageFlags("alias tag remove")
+	entry := f.String("entry", "", "ticker of the alias entry (default: every entry with the tag)")
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+	if f.NArg() != 1 {
+		return fmt.Errorf(aliasUsage)
+	}
+	name, tagName, domain, err := cryptalias.ParseAliasName(f.Arg(0))
+	if err != nil {
+		return err
+	}
+	if tagName == "" {
+		return fmt.Errorf("tag is required: alias+tag$domain")
+	}
+	return withManager(f, func(m cryptalias.AliasManager) error {
+		if err := m.RemoveTag(domain, name, strings.ToLower(*entry), tagName); err != nil {
+			return err
+		}
+		return printRemoved(f, name+"+"+tagName+"$"+domain)
+	})
+}
+
+func runDomain(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(domainUsage)
+	}
+	f := newManageFlags("domain " + args[0])
+	if err := f.Parse(args[1:]); err != nil {
+		return err
+	}
+	switch {
+	case args[0] == "add" && f.NArg() == 1:
+		return withManager(f, func(m cryptalias.AliasManager) error {
+			d, err := m.AddDomain(f.Arg(0))
+			if err != nil {
+				return err
+			}
+			if f.json {
+				return printJSON(d)
+			}
+			return printf("added %s\nadd this DNS record: _cryptalias.%s IN TXT %q\n", d.Domain, d.Domain, d.DNSTXT)
+		})
+	case args[0] == "list" && f.NArg() == 0:
+		return withManager(f, func(m cryptalias.AliasManager) error {
+			list, err := m.ListDomains()
+			if err != nil {
+				return err
+			}
+			if f.json {
+				return printJSON(list)
+			}
+			tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			for _, d := range list {
+				fmt.Fprintf(tw, "%s\t%s\n", d.Domain, d.DNSTXT)
+			}
+			return tw.Flush()
+		})
+	case args[0] == "remove" && f.NArg() == 1:
+		return withManager(f, func(m cryptalias.AliasManager) error {
+			if err := m.RemoveDomain(f.Arg(0)); err != nil {
+				return err
+			}
+			return printRemoved(f, strings.ToLower(f.Arg(0)))
+		})
+	}
+	return fmt.Errorf(domainUsage)
+}
+
+func withManager(f *manageFlags, fn func(cryptalias.AliasManager) error) error {
+	m, err := f.open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return fn(m)
+}
+
+func printAliases(f *manageFlags, list []cryptalias.WalletAlias) error {
+	if f.json {
+		if list == nil {
+			list = []cryptalias.WalletAlias{}
+		}
+		return printJSON(list)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, a := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", a.Alias, a.Wallet.Ticker, walletLabel(a.Wallet))
+		for _, t := range a.Tags {
+			fmt.Fprintf(tw, "%s+%s\t%s\t%s\n", a.Alias, t.Tag, t.Wallet.Ticker, walletLabel(t.Wallet))
+		}
+	}
+	return tw.Flush()
+}
+
+func walletLabel(w cryptalias.WalletAddress) string {
+	if w.Address != "" {
+		return w.Address
+	}
+	return "(dynamic)"
+}
+
+func printRemoved(f *manageFlags, name string) error {
+	if f.json {
+		return printJSON(map[string]string{"removed": name})
+	}
+	return printf("removed %s\n", name)
+}
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printf(format string, args ...any) error {
+	_, err := fmt.Fprintf(os.Stdout, format, args...)
+	return err
+}
@@ -1,36 +1,180 @@
 package cryptalias
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
 )
 
 // AddressStore persists the per-client resolution cache so restarts do not
 // immediately rotate addresses for active clients.
 type AddressStore struct {
-	mu   sync.RWMutex
-	path string
-	data map[string]addressEntry
+	mu         sync.RWMutex
+	path       string
+	data       map[string]addressEntry
+	paymentIDs map[string]issuedRecord
+	addresses  map[string]issuedRecord
+	// cursors are the payment watcher's next min_height per wallet target.
+	cursors  map[string]uint64
+	received map[string]receivedPaymentState
+	// used holds addresses known to have received funds, with the time they
+	// were marked. Used addresses are never handed out again.
+	used map[string]int64
+	// salt keys ClientKeyHash; generated on first use and kept with the state.
+	salt []byte
+	// swept is when issued records were last pruned.
+	swept time.Time
 }
 
 type addressStoreFile struct {
-	Entries map[string]addressEntry `json:"entries"`
+	Entries    map[string]addressEntry         `json:"entries"`
+	PaymentIDs map[string]issuedRecord         `json:"payment_ids,omitempty"`
+	Addresses  map[string]issuedRecord         `json:"issued_addresses,omitempty"`
+	Cursors    map[string]uint64               `json:"payment_cursors,omitempty"`
+	Received   map[string]receivedPaymentState `json:"received_payments,omitempty"`
+	Used       map[string]int64                `json:"used_addresses,omitempty"`
+	Salt       []byte                          `json:"client_key_salt,omitempty"`
 }
 
-type addressEntry struct {
+// issuedRecord ties an issued address or payment ID back to the alias that
+// handed it out. Records outlive cache entries, since a payer may send funds
+// long after the address was resolved, and are pruned after
+// resolution.issued_retention_days.
+type issuedRecord struct {
+	Ticker    string `json:"ticker"`
 	Address   string `json:"address"`
-	ClientKey string `json:"client_key"`
+	Domain    string `json:"domain"`
+	Alias     string `json:"alias"`
+	Tag       string `json:"tag,omitempty"`
+	ClientKey string `json:"client_key,omitempty"`
+	// Backend names the token endpoint that issued the address.
+	Backend  string `json:"backend,omitempty"`
+	IssuedAt int64  `json:"issued_at"`
+
+	// AccountKey and the destination fields let an unused address be handed
+	// out again on the same alias route (resolution.reuse_unused).
+	AccountKey     string  `json:"account_key,omitempty"`
+	Memo           string  `json:"memo,omitempty"`
+	DestinationTag *uint32 `json:"destination_tag,omitempty"`
+	ValidUntil     int64   `json:"valid_until,omitempty"`
+	// Reference is the wallet service's opaque reference for the address,
+	// reported back with payments.
+	Reference string `json:"reference,omitempty"`
+	// Dedicated addresses were issued for a payer's amount or memo and are
+	// never handed to another client.
+	Dedicated bool `json:"dedicated,omitempty"`
+}
+
+// entry rebuilds the cache entry for a reused address.
+func (r issuedRecord) entry(clientKey string) addressEntry {
+	return addressEntry{
+		Address:        r.Address,
+		Memo:           r.Memo,
+		DestinationTag: r.DestinationTag,
+		ClientKey:      clientKey,
+		Backend:        r.Backend,
+		ValidUntil:     r.ValidUntil,
+		Reference:      r.Reference,
+	}
+}
+
+// receivedPaymentState remembers which incoming transfers were already
+// reported, so each is reported once when seen and once when confirmed.
+type receivedPaymentState struct {
+	Confirmed bool  `json:"confirmed,omitempty"`
+	SeenAt    int64 `json:"seen_at"`
+}
+
+// receivedPaymentRetention bounds how long confirmed payments are remembered.
+const receivedPaymentRetention = 30 * 24 * time.Hour
+
+type addressEntry struct {
+	Address        string  `json:"address"`
+	Memo           string  `json:"memo,omitempty"`
+	DestinationTag *uint32 `json:"destination_tag,omitempty"`
+	PaymentID      string  `json:"payment_id,omitempty"`
+	ClientKey      string  `json:"client_key"`
+	// Backend names the token endpoint that issued the address. A cache hit
+	// returns the entry whichever backend would be selected now.
+	Backend   string `json:"backend,omitempty"`
 	ExpiresAt int64  `json:"expires_at"`
+
+	// ValidUntil is the wallet's expiry hint in unix seconds; the entry is
+	// never served past it. Reference, Labels and Index are wallet metadata.
+	ValidUntil int64             `json:"valid_until,omitempty"`
+	Reference  string            `json:"reference,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Index      *addressIndex     `json:"index,omitempty"`
+}
+
+// addressIndex is the wallet-side position of an address, e.g. a Monero
+// account and subaddress index.
+type addressIndex struct {
+	Account uint64 `json:"account"`
+	Address uint64 `json:"address"`
+}
+
+func newAddressEntry(resp *cryptaliasv1.WalletAddressResponse, clientKey string, now time.Time) addressEntry {
+	entry := addressEntry{
+		Address:   resp.GetAddress(),
+		Memo:      resp.GetMemo(),
+		PaymentID: resp.GetPaymentId(),
+		ClientKey: clientKey,
+		Reference: resp.GetReference(),
+		Labels:    resp.GetLabels(),
+	}
+	if resp.DestinationTag != nil {
+		v := resp.GetDestinationTag()
+		entry.DestinationTag = &v
+	}
+	if secs := resp.GetExpiresInSeconds(); secs > 0 {
+		entry.ValidUntil = now.Add(time.Duration(secs) * time.Second).Unix()
+	}
+	if idx := resp.GetIndex(); idx != nil {
+		entry.Index = &addressIndex{Account: idx.GetAccount(), Address: idx.GetAddress()}
+	}
+	return entry
+}
+
+// walletAddress restores the cached destination, keeping destination fields
+// so a cache hit never drops a memo, tag or payment ID.
+func (e addressEntry) walletAddress(ticker string) WalletAddress {
+	w := WalletAddress{Ticker: ticker, Address: e.Address, DestinationTag: e.DestinationTag}
+	if e.Memo != "" {
+		v := e.Memo
+		w.Memo = &v
+	}
+	if e.PaymentID != "" {
+		v := e.PaymentID
+		w.PaymentID = &v
+	}
+	if e.ValidUntil > 0 {
+		w.ExpiresAt = time.Unix(e.ValidUntil, 0).UTC()
+	}
+	return w
 }
 
 func newAddressStore(configPath string) (*AddressStore, error) {
 	path := statePathFor(configPath)
 	store := &AddressStore{
-		path: path,
-		data: map[string]addressEntry{},
+		path:       path,
+		data:       map[string]addressEntry{},
+		paymentIDs: map[string]issuedRecord{},
+		addresses:  map[string]issuedRecord{},
+		cursors:    map[string]uint64{},
+		received:   map[string]receivedPaymentState{},
+		used:       map[string]int64{},
 	}
 	if err := store.load(); err != nil {
 		return nil, err
@@ -48,35 +192,243 @@ func aliasKey(ticker, domain, alias, tag, accountKey, clientKey string) string {
 	return ticker + "|" + domain + "|" + alias + "|" + tag + "|" + accountKey + "|" + clientKey
 }
 
-func (s *AddressStore) Get(key string, now time.Time) (string, bool) {
+func (s *AddressStore) Get(key string, now time.Time) (addressEntry, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	entry, ok := s.data[key]
 	if !ok {
-		return "", false
+		return addressEntry{}, false
 	}
-	if entry.ExpiresAt > 0 && now.Unix() >= entry.ExpiresAt {
+	if (entry.ExpiresAt > 0 && now.Unix() >= entry.ExpiresAt) || (entry.ValidUntil > 0 && now.Unix() >= entry.ValidUntil) {
 		// Lazy expiry: drop stale entries when encountered.
 		delete(s.data, key)
-		return "", false
+		return addressEntry{}, false
 	}
-	return entry.Address, true
+	return entry, true
 }
 
-func (s *AddressStore) Put(key, address, clientKey string, now time.Time, ttl time.Duration) error {
+func (s *AddressStore) putLocked(key string, entry addressEntry, now time.Time, ttl time.Duration) {
+	entry.ExpiresAt = now.Add(ttl).Unix()
+	if entry.ValidUntil > 0 && entry.ValidUntil < entry.ExpiresAt {
+		// The wallet's expiry hint caps the cache TTL.
+		entry.ExpiresAt = entry.ValidUntil
+	}
+	s.data[key] = entry
+}
+
+// Delete drops a cache entry, e.g. when its address was retired.
+func (s *AddressStore) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	expiresAt := now.Add(ttl).Unix()
-	s.data[key] = addressEntry{
-		Address:   address,
-		ClientKey: clientKey,
-		ExpiresAt: expiresAt,
+	if _, ok := s.data[key]; !ok {
+		return nil
 	}
+	delete(s.data, key)
 	return s.saveLocked()
 }
 
+// paymentIDKey is case-insensitive: wallets differ in the case of the hex
+// they report.
+func paymentIDKey(ticker, paymentID string) string {
+	return ticker + "|" + strings.ToLower(paymentID)
+}
+
+func issuedAddressKey(ticker, address string) string {
+	return ticker + "|" + address
+}
+
+// issuedSweepInterval bounds how often issued records are pruned.
+const issuedSweepInterval = time.Hour
+
+// RecordIssued caches a newly issued address for the client under key and
+// remembers which alias it, and its payment ID if any, was issued for. An
+// empty key records a dedicated address without caching it. Records older
+// than retention are pruned. The state is written once.
+func (s *AddressStore) RecordIssued(key string, in dynamicAliasInput, entry addressEntry, now time.Time, ttl, retention time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key != "" {
+		s.putLocked(key, entry, now, ttl)
+	}
+
+	rec := issuedRecord{
+		Ticker:         in.Ticker,
+		Address:        entry.Address,
+		Domain:         in.Domain,
+		Alias:          in.Alias,
+		Tag:            in.Tag,
+		ClientKey:      entry.ClientKey,
+		Backend:        entry.Backend,
+		IssuedAt:       now.Unix(),
+		AccountKey:     accountKey(in),
+		Memo:           entry.Memo,
+		DestinationTag: entry.DestinationTag,
+		ValidUntil:     entry.ValidUntil,
+		Reference:      entry.Reference,
+		Dedicated:      key == "",
+	}
+	if entry.PaymentID != "" {
+		s.paymentIDs[paymentIDKey(in.Ticker, entry.PaymentID)] = rec
+	} else {
+		// Integrated addresses share the wallet's primary address; only the
+		// payment ID identifies them.
+		s.addresses[issuedAddressKey(in.Ticker, entry.Address)] = rec
+	}
+	if retention > 0 && now.Sub(s.swept) >= issuedSweepInterval {
+		s.swept = now
+		cutoff := now.Add(-retention).Unix()
+		for _, records := range []map[string]issuedRecord{s.addresses, s.paymentIDs} {
+			for k, r := range records {
+				if r.IssuedAt < cutoff {
+					delete(records, k)
+				}
+			}
+		}
+	}
+	return s.saveLocked()
+}
+
+// LookupPaymentID returns the alias metadata recorded for a payment ID.
+func (s *AddressStore) LookupPaymentID(ticker, paymentID string) (issuedRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.paymentIDs[paymentIDKey(ticker, paymentID)]
+	return rec, ok
+}
+
+// LookupAddress returns the alias metadata recorded for an issued address.
+func (s *AddressStore) LookupAddress(ticker, address string) (issuedRecord, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.addresses[issuedAddressKey(ticker, address)]
+	return rec, ok
+}
+
+// MarkUsed records that an address received funds.
+func (s *AddressStore) MarkUsed(ticker, address string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := issuedAddressKey(ticker, address)
+	if _, ok := s.used[key]; ok {
+		return nil
+	}
+	s.used[key] = now.Unix()
+	return s.saveLocked()
+}
+
+// AddressUsed reports whether an address is known to have received funds.
+func (s *AddressStore) AddressUsed(ticker, address string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.used[issuedAddressKey(ticker, address)]
+	return ok
+}
+
+// UnusedIssued returns addresses issued on the alias route of in that have
+// not received funds and are not cached for any client at now, oldest first.
+func (s *AddressStore) UnusedIssued(in dynamicAliasInput, now time.Time) []issuedRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	leased := map[string]bool{}
+	for _, e := range s.data {
+		if e.ExpiresAt == 0 || now.Unix() < e.ExpiresAt {
+			leased[e.Address] = true
+		}
+	}
+	route := accountKey(in)
+	var out []issuedRecord
+	for key, rec := range s.addresses {
+		if rec.Ticker != in.Ticker || rec.Domain != in.Domain || rec.Alias != in.Alias || rec.Tag != in.Tag || rec.AccountKey != route || rec.Dedicated {
+			continue
+		}
+		if _, used := s.used[key]; used || leased[rec.Address] {
+			continue
+		}
+		if rec.ValidUntil > 0 && now.Unix() >= rec.ValidUntil {
+			continue
+		}
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].IssuedAt < out[j].IssuedAt })
+	return out
+}
+
+// PaymentCursor returns the next min_height to poll a wallet target from.
+func (s *AddressStore) PaymentCursor(target string) uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cursors[target]
+}
+
+func (s *AddressStore) SetPaymentCursor(target string, height uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cursors[target] == height {
+		return nil
+	}
+	s.cursors[target] = height
+	return s.saveLocked()
+}
+
+// ObservePayment records an incoming transfer and reports whether it is new,
+// or newly confirmed, and so should be reported.
+func (s *AddressStore) ObservePayment(key string, confirmed bool, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, seen := s.received[key]
+	if seen && (state.Confirmed || !confirmed) {
+		return false, nil
+	}
+	if !seen {
+		state.SeenAt = now.Unix()
+	}
+	state.Confirmed = confirmed
+	s.received[key] = state
+	cutoff := now.Add(-receivedPaymentRetention).Unix()
+	for k, v := range s.received {
+		if v.Confirmed && v.SeenAt < cutoff {
+			delete(s.received, k)
+		}
+	}
+	return true, s.saveLocked()
+}
+
+// ClientKeyHash returns a salted hash of a client key, so wallet services can
+// tell payers apart without learning their IP address. It is empty for an
+// empty key.
+func (s *AddressStore) ClientKeyHash(clientKey string) string {
+	if clientKey == "" {
+		return ""
+	}
+	s.mu.Lock()
+	if len(s.salt) == 0 {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			s.mu.Unlock()
+			slog.Warn("client key salt generation failed", "error", err)
+			return ""
+		}
+		s.salt = salt
+		if err := s.saveLocked(); err != nil {
+			slog.Warn("client key salt store failed", "error", err)
+		}
+	}
+	mac := hmac.New(sha256.New, s.salt)
+	s.mu.Unlock()
+	mac.Write([]byte(clientKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
 func (s *AddressStore) load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -108,12 +460,48 @@ func (s *AddressStore) load() error {
 	if file.Entries == nil {
 		file.Entries = map[string]addressEntry{}
 	}
+	if file.PaymentIDs == nil {
+		file.PaymentIDs = map[string]issuedRecord{}
+	}
+	for k, rec := range file.PaymentIDs {
+		// Older state files kept payment IDs in the case the wallet returned.
+		if lower := strings.ToLower(k); lower != k {
+			delete(file.PaymentIDs, k)
+			file.PaymentIDs[lower] = rec
+		}
+	}
+	if file.Addresses == nil {
+		file.Addresses = map[string]issuedRecord{}
+	}
+	if file.Cursors == nil {
+		file.Cursors = map[string]uint64{}
+	}
+	if file.Received == nil {
+		file.Received = map[string]receivedPaymentState{}
+	}
+	if file.Used == nil {
+		file.Used = map[string]int64{}
+	}
 	s.data = file.Entries
+	s.paymentIDs = file.PaymentIDs
+	s.addresses = file.Addresses
+	s.cursors = file.Cursors
+	s.received = file.Received
+	s.used = file.Used
+	s.salt = file.Salt
 	return nil
 }
 
 func (s *AddressStore) saveLocked() error {
-	file := addressStoreFile{Entries: s.data}
+	file := addressStoreFile{
+		Entries:    s.data,
+		PaymentIDs: s.paymentIDs,
+		Addresses:  s.addresses,
+		Cursors:    s.cursors,
+		Received:   s.received,
+		Used:       s.used,
+		Salt:       s.salt,
+	}
 	b, err := json.MarshalIndent(file, "", "  ")
 	if err != nil {
 		return err
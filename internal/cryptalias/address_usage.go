@@ -0,0 +1,114 @@
+package cryptalias
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxUsedAddressAttempts bounds how often a backend is asked again when it
+// hands out an address that already received funds, e.g. a pool that
+// recycles addresses.
+const maxUsedAddressAttempts = 3
+
+// retireUsed drops a cached address that received funds since it was issued,
+// so the client gets a fresh one. It reports whether the entry was retired.
+func (r *WalletResolver) retireUsed(cfg *Config, cacheKey, ticker string, entry addressEntry) bool {
+	if !cfg.Resolution.RetireUsedOrDefault() || !r.state.AddressUsed(ticker, entry.Address) {
+		return false
+	}
+	slog.Info("retiring used address", "ticker", ticker, "backend", entry.Backend)
+	if err := r.state.Delete(cacheKey); err != nil {
+		slog.Warn("retired address cache delete failed", "error", err)
+	}
+	return true
+}
+
+// issueUnused issues an address, asking again while the backend returns
+// addresses known to be used. Each attempt gets its own request ID, which
+// its retries and failover reuse so v2 services can deduplicate them.
+func (r *WalletResolver) issueUnused(ctx context.Context, cfg *Config, token TokenConfig, in dynamicAliasInput, clientKey string) (addressEntry, error) {
+	for attempt := 1; ; attempt++ {
+		var err error
+		if in.RequestID, err = NewNonce(); err != nil {
+			return addressEntry{}, err
+		}
+		entry, err := r.issue(ctx, token, in, clientKey)
+		if err != nil {
+			return addressEntry{}, err
+		}
+		if !cfg.Resolution.RetireUsedOrDefault() || !r.state.AddressUsed(in.Ticker, entry.Address) {
+			return entry, nil
+		}
+		slog.Warn("wallet backend returned a used address", "ticker", in.Ticker, "backend", entry.Backend, "attempt", attempt)
+		if attempt == maxUsedAddressAttempts {
+			return addressEntry{}, fmt.Errorf("wallet backend for %s kept returning used addresses", in.Ticker)
+		}
+	}
+}
+
+// reuseUnused returns an address previously issued on the same alias route
+// that never received funds and is not cached for another client. Candidates
+// are re-checked with the backend's IsAddressUsed RPC when it has one.
+func (r *WalletResolver) reuseUnused(ctx context.Context, token TokenConfig, in dynamicAliasInput, clientKey string, now time.Time) (addressEntry, bool) {
+	backends := map[string]TokenConfig{}
+	for _, b := range token.backends() {
+		backends[b.Name] = b
+	}
+	for _, rec := range r.state.UnusedIssued(in, now) {
+		backend, ok := backends[rec.Backend]
+		if !ok {
+			// Issued by a backend that is no longer configured.
+			continue
+		}
+		used, err := r.addressUsed(ctx, backend, in, rec.Address)
+		if err != nil {
+			slog.Warn("address usage check failed", "ticker", in.Ticker, "backend", backend.Name, "error", err)
+			continue
+		}
+		if used {
+			if err := r.state.MarkUsed(in.Ticker, rec.Address, now); err != nil {
+				slog.Warn("address usage record failed", "ticker", in.Ticker, "error", err)
+			}
+			continue
+		}
+		slog.Debug("dynamic resolve reusing unused address", "ticker", in.Ticker, "domain", in.Domain, "backend", backend.Name, "client", clientKey)
+		return rec.entry(clientKey), true
+	}
+	return addressEntry{}, false
+}
+
+// addressUsed asks the backend whether an address received funds. Backends
+// without the IsAddressUsed RPC report false; the payment watcher still
+// marks their addresses as used.
+func (r *WalletResolver) addressUsed(ctx context.Context, backend TokenConfig, in dynamicAliasInput, address string) (bool, error) {
+	req := &cryptaliasv1.AddressUsedRequest{Ticker: in.Ticker, Address: address, WalletId: in.WalletID}
+	var resp *cryptaliasv1.AddressUsedResponse
+	err := r.callBackend(ctx, backend, func(ctx context.Context) error {
+		var err error
+		switch backend.Endpoint.EndpointType {
+		case TokenEndpointTypeInternal:
+			var client cryptaliasv1.WalletServiceClient
+			if client, err = r.internal.client(backend, in.WalletID); err == nil {
+				resp, err = client.IsAddressUsed(ctx, req)
+			}
+		case TokenEndpointTypeExternal:
+			resp, err = r.grpc.IsAddressUsed(ctx, backend.Endpoint, req)
+		default:
+			resp = &cryptaliasv1.AddressUsedResponse{}
+		}
+		if status.Code(err) == codes.Unimplemented {
+			resp, err = &cryptaliasv1.AddressUsedResponse{}, nil
+		}
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetUsed(), nil
+}
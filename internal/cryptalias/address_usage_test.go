@@ -0,0 +1,135 @@
+package cryptalias
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sequenceBackend hands out the given address seeds in order, repeating the
+// last one, like a pool-style backend.
+func sequenceBackend(t *testing.T, seeds ...byte) (walletBackendFunc, *int) {
+	calls := 0
+	return func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		seed := seeds[min(calls, len(seeds)-1)]
+		calls++
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, seed)}, nil
+	}, &calls
+}
+
+func TestResolveRetiresUsedAddresses(t *testing.T) {
+	fn, calls := sequenceBackend(t, 1, 1, 1, 1, 2)
+	resolver := newBreakerTestResolver(t, fn)
+	cfg := breakerTestConfig(TokenEndpointConfig{})
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "shop", Domain: "example.com"}
+	ctx := withClientKey(context.Background(), "client-a")
+
+	first, err := resolver.Resolve(ctx, cfg, in)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if err := resolver.state.MarkUsed("xmr", first.Address, time.Now()); err != nil {
+		t.Fatalf("mark used: %v", err)
+	}
+
+	// The cached address is retired and the pool keeps returning it.
+	_, err = resolver.Resolve(ctx, cfg, in)
+	if err == nil || !strings.Contains(err.Error(), "used addresses") || *calls != 1+maxUsedAddressAttempts {
+		t.Fatalf("expected used pool addresses to be rejected, got %v after %d calls", err, *calls)
+	}
+	second, err := resolver.Resolve(ctx, cfg, in)
+	if err != nil || second.Address != testMoneroAddress(t, 2) {
+		t.Fatalf("expected a fresh address, got %q %v", second.Address, err)
+	}
+}
+
+func TestResolveUsesNewRequestIDPerUsedAddressAttempt(t *testing.T) {
+	// The backend deduplicates on request_id like a v2 service should: a
+	// repeated ID gets the address issued for it before.
+	issued := map[string]string{}
+	var ids []string
+	fn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		ids = append(ids, in.RequestID)
+		if len(ids) == 1 {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+		if _, ok := issued[in.RequestID]; !ok {
+			issued[in.RequestID] = testMoneroAddress(t, byte(len(issued)+1))
+		}
+		return &cryptaliasv1.WalletAddressResponse{Address: issued[in.RequestID]}, nil
+	}
+	resolver := newBreakerTestResolver(t, fn)
+	cfg := breakerTestConfig(TokenEndpointConfig{RetryBackoffMs: 1})
+	if err := resolver.state.MarkUsed("xmr", testMoneroAddress(t, 1), time.Now()); err != nil {
+		t.Fatalf("mark used: %v", err)
+	}
+
+	got, err := resolver.Resolve(context.Background(), cfg, dynamicAliasInput{Ticker: "xmr", Alias: "shop", Domain: "example.com"})
+	if err != nil || got.Address != testMoneroAddress(t, 2) {
+		t.Fatalf("expected a fresh address, got %q %v", got.Address, err)
+	}
+	if len(ids) != 3 || ids[0] != ids[1] || ids[1] == ids[2] {
+		t.Fatalf("expected the retry to keep its request ID and the next attempt to get a new one, got %v", ids)
+	}
+}
+
+func TestResolveReusesUnusedAddresses(t *testing.T) {
+	rpc := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	fn, calls := sequenceBackend(t, 1, 2, 3)
+	resolver := newBreakerTestResolver(t, fn)
+	cfg := &Config{
+		Resolution: ResolutionConfig{ReuseUnused: true},
+		Tokens: []TokenConfig{{
+			Name:     "Monero",
+			Tickers:  []string{"xmr"},
+			Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: srv.URL},
+		}},
+	}
+	cfg.Normalize("")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "shop", Domain: "example.com"}
+	resolve := func(client string) string {
+		t.Helper()
+		got, err := resolver.Resolve(withClientKey(context.Background(), client), cfg, in)
+		if err != nil {
+			t.Fatalf("resolve for %s: %v", client, err)
+		}
+		return got.Address
+	}
+
+	a := resolve("client-a")
+	if b := resolve("client-b"); b == a {
+		t.Fatalf("an address cached for another client must not be reused")
+	}
+
+	// Once client-a's entry is gone its unused address goes to the next client.
+	if err := resolver.state.Delete(aliasKey("xmr", "example.com", "shop", "", accountKey(in), "client-a")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if c := resolve("client-c"); c != a || *calls != 2 {
+		t.Fatalf("expected client-c to reuse %q, got %q after %d calls", a, c, *calls)
+	}
+	if rpc.count("get_address_index") != 1 {
+		t.Fatalf("expected the wallet to be asked before reuse, got %v", rpc.calls)
+	}
+
+	// The wallet reports the next candidate as used, so a new address is issued.
+	if err := resolver.state.Delete(aliasKey("xmr", "example.com", "shop", "", accountKey(in), "client-c")); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	rpc.transfers = []map[string]any{{"txid": "aa", "amount": 1}}
+	if d := resolve("client-d"); d == a || *calls != 3 {
+		t.Fatalf("expected a used address to be skipped, got %q after %d calls", d, *calls)
+	}
+	if !resolver.state.AddressUsed("xmr", a) {
+		t.Fatalf("expected the wallet's answer to retire the address")
+	}
+}
@@ -0,0 +1,513 @@
+package cryptalias
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// addressValidator checks that an address is well-formed for a chain and
+// belongs to the configured network. Validators never contact the network.
+type addressValidator interface {
+	ValidateAddress(address, network string) error
+	// Networks lists the network names accepted in tokens[].network.
+	Networks() []string
+}
+
+// addressValidators is keyed by both chain name and ticker so tokens can opt
+// into a validator explicitly (chain: evm) or implicitly by ticker.
+var addressValidators = map[string]addressValidator{}
+
+func registerAddressValidator(v addressValidator, keys ...string) {
+	for _, k := range keys {
+		addressValidators[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+}
+
+func init() {
+	registerAddressValidator(moneroValidator{}, "monero", "xmr")
+	registerAddressValidator(bitcoinValidator, "bitcoin", "btc")
+	registerAddressValidator(litecoinValidator, "litecoin", "ltc")
+	registerAddressValidator(dogecoinValidator, "dogecoin", "doge")
+	registerAddressValidator(evmValidator{}, "evm", "eth", "etc")
+}
+
+// addressValidatorFor prefers the token's explicit chain, then falls back to
+// the ticker. Tickers without a registered validator are not checked.
+func addressValidatorFor(token TokenConfig, ticker string) (addressValidator, bool) {
+	if chain := strings.ToLower(strings.TrimSpace(token.Chain)); chain != "" {
+		v, ok := addressValidators[chain]
+		return v, ok
+	}
+	v, ok := addressValidators[strings.ToLower(strings.TrimSpace(ticker))]
+	return v, ok
+}
+
+// validateTokenAddress runs the registered validator for the token, if any.
+func validateTokenAddress(token TokenConfig, ticker, address string) error {
+	v, ok := addressValidatorFor(token, ticker)
+	if !ok {
+		return nil
+	}
+	return v.ValidateAddress(address, token.NetworkOrDefault())
+}
+
+// integratedAddressValidator is implemented by validators for chains whose
+// addresses can embed a payment ID.
+type integratedAddressValidator interface {
+	IsIntegratedAddress(address string) bool
+}
+
+// isIntegratedAddress reports whether the token's validator recognizes
+// address as one that already carries a payment ID.
+func isIntegratedAddress(token TokenConfig, ticker, address string) bool {
+	v, ok := addressValidatorFor(token, ticker)
+	if !ok {
+		return false
+	}
+	iv, ok := v.(integratedAddressValidator)
+	return ok && iv.IsIntegratedAddress(address)
+}
+
+func networkSupported(v addressValidator, network string) bool {
+	for _, n := range v.Networks() {
+		if n == network {
+			return true
+		}
+	}
+	return false
+}
+
+var errWrongNetwork = errors.New("address is for a different network")
+
+// Monero
+
+type moneroValidator struct{}
+
+type moneroPrefixes struct {
+	standard   uint64
+	integrated uint64
+	subaddress uint64
+}
+
+var moneroNetworks = map[string]moneroPrefixes{
+	"mainnet":  {standard: 18, integrated: 19, subaddress: 42},
+	"testnet":  {standard: 53, integrated: 54, subaddress: 63},
+	"stagenet": {standard: 24, integrated: 25, subaddress: 36},
+}
+
+func (moneroValidator) Networks() []string {
+	return []string{"mainnet", "testnet", "stagenet"}
+}
+
+func (moneroValidator) ValidateAddress(address, network string) error {
+	prefix, integrated, err := decodeMoneroAddress(address)
+	if err != nil {
+		return err
+	}
+	for name, p := range moneroNetworks {
+		matched := (integrated && prefix == p.integrated) || (!integrated && (prefix == p.standard || prefix == p.subaddress))
+		if !matched {
+			continue
+		}
+		if name != network {
+			return fmt.Errorf("%w: monero %s address, expected %s", errWrongNetwork, name, network)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown monero network prefix %d", prefix)
+}
+
+func (moneroValidator) IsIntegratedAddress(address string) bool {
+	prefix, integrated, err := decodeMoneroAddress(address)
+	if err != nil || !integrated {
+		return false
+	}
+	for _, p := range moneroNetworks {
+		if prefix == p.integrated {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMoneroAddress checks an address's length and checksum and returns its
+// network prefix and whether it embeds a payment ID.
+func decodeMoneroAddress(address string) (uint64, bool, error) {
+	raw, err := moneroBase58Decode(address)
+	if err != nil {
+		return 0, false, err
+	}
+	prefix, n := readUvarint(raw)
+	if n <= 0 {
+		return 0, false, errors.New("invalid monero network prefix")
+	}
+	body := raw[n:]
+	// Standard and subaddresses carry two 32-byte keys; integrated addresses
+	// append an 8-byte payment ID. Every form ends with a 4-byte checksum.
+	integrated := false
+	switch len(body) {
+	case 64 + 4:
+	case 64 + 8 + 4:
+		integrated = true
+	default:
+		return 0, false, fmt.Errorf("invalid monero address length %d", len(raw))
+	}
+	sum := keccak256(raw[:len(raw)-4])
+	if !bytes.Equal(sum[:4], raw[len(raw)-4:]) {
+		return 0, false, errors.New("invalid monero address checksum")
+	}
+	return prefix, integrated, nil
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// moneroEncodedBlockSizes maps decoded block length (index) to encoded length.
+var moneroEncodedBlockSizes = []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+// moneroBase58Decode decodes Monero's block-based base58 variant, where each
+// 8-byte block is encoded independently into 11 characters.
+func moneroBase58Decode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty address")
+	}
+	const fullEncoded = 11
+	var out []byte
+	for start := 0; start < len(s); start += fullEncoded {
+		end := start + fullEncoded
+		if end > len(s) {
+			end = len(s)
+		}
+		block := s[start:end]
+		size := -1
+		for i, n := range moneroEncodedBlockSizes {
+			if n == len(block) {
+				size = i
+				break
+			}
+		}
+		if size <= 0 {
+			return nil, errors.New("invalid monero base58 length")
+		}
+		num := new(big.Int)
+		for _, c := range []byte(block) {
+			idx := strings.IndexByte(base58Alphabet, c)
+			if idx < 0 {
+				return nil, fmt.Errorf("invalid base58 character %q", c)
+			}
+			num.Mul(num, big.NewInt(58))
+			num.Add(num, big.NewInt(int64(idx)))
+		}
+		decoded := num.Bytes()
+		if len(decoded) > size {
+			return nil, errors.New("invalid monero base58 block")
+		}
+		padded := make([]byte, size)
+		copy(padded[size-len(decoded):], decoded)
+		out = append(out, padded...)
+	}
+	return out, nil
+}
+
+func readUvarint(b []byte) (uint64, int) {
+	var v uint64
+	for i, c := range b {
+		if i >= 10 {
+			return 0, -1
+		}
+		v |= uint64(c&0x7f) << (7 * uint(i))
+		if c < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, -1
+}
+
+func keccak256(data []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// Bitcoin and Bitcoin-derived UTXO chains
+
+type utxoNetwork struct {
+	pubKeyHash byte
+	scriptHash byte
+	// hrp is the bech32 human-readable part; empty when segwit is unsupported.
+	hrp string
+}
+
+type utxoValidator struct {
+	name     string
+	networks map[string]utxoNetwork
+}
+
+var bitcoinValidator = utxoValidator{
+	name: "bitcoin",
+	networks: map[string]utxoNetwork{
+		"mainnet": {pubKeyHash: 0x00, scriptHash: 0x05, hrp: "bc"},
+		"testnet": {pubKeyHash: 0x6f, scriptHash: 0xc4, hrp: "tb"},
+		"signet":  {pubKeyHash: 0x6f, scriptHash: 0xc4, hrp: "tb"},
+		"regtest": {pubKeyHash: 0x6f, scriptHash: 0xc4, hrp: "bcrt"},
+	},
+}
+
+var litecoinValidator = utxoValidator{
+	name: "litecoin",
+	networks: map[string]utxoNetwork{
+		"mainnet": {pubKeyHash: 0x30, scriptHash: 0x32, hrp: "ltc"},
+		"testnet": {pubKeyHash: 0x6f, scriptHash: 0x3a, hrp: "tltc"},
+	},
+}
+
+var dogecoinValidator = utxoValidator{
+	name: "dogecoin",
+	networks: map[string]utxoNetwork{
+		"mainnet": {pubKeyHash: 0x1e, scriptHash: 0x16},
+		"testnet": {pubKeyHash: 0x71, scriptHash: 0xc4},
+	},
+}
+
+func (v utxoValidator) Networks() []string {
+	out := make([]string, 0, len(v.networks))
+	for name := range v.networks {
+		out = append(out, name)
+	}
+	return out
+}
+
+func (v utxoValidator) ValidateAddress(address, network string) error {
+	expected, ok := v.networks[network]
+	if !ok {
+		return fmt.Errorf("unsupported %s network %q", v.name, network)
+	}
+	if hrp, ok := bech32HRP(address); ok {
+		for name, n := range v.networks {
+			if n.hrp == "" || n.hrp != hrp {
+				continue
+			}
+			if err := validateSegwitAddress(address, hrp); err != nil {
+				return err
+			}
+			if n.hrp != expected.hrp {
+				return fmt.Errorf("%w: %s %s address, expected %s", errWrongNetwork, v.name, name, network)
+			}
+			return nil
+		}
+	}
+
+	payload, err := base58CheckDecode(address)
+	if err != nil {
+		return err
+	}
+	if len(payload) != 21 {
+		return fmt.Errorf("invalid %s address length", v.name)
+	}
+	version := payload[0]
+	if version == expected.pubKeyHash || version == expected.scriptHash {
+		return nil
+	}
+	for name, n := range v.networks {
+		if version == n.pubKeyHash || version == n.scriptHash {
+			return fmt.Errorf("%w: %s %s address, expected %s", errWrongNetwork, v.name, name, network)
+		}
+	}
+	return fmt.Errorf("unknown %s address version 0x%02x", v.name, version)
+}
+
+func base58CheckDecode(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.New("empty address")
+	}
+	num := new(big.Int)
+	for _, c := range []byte(s) {
+		idx := strings.IndexByte(base58Alphabet, c)
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		num.Mul(num, big.NewInt(58))
+		num.Add(num, big.NewInt(int64(idx)))
+	}
+	decoded := num.Bytes()
+	// Leading '1' characters encode leading zero bytes.
+	zeros := 0
+	for zeros < len(s) && s[zeros] == base58Alphabet[0] {
+		zeros++
+	}
+	raw := append(make([]byte, zeros), decoded...)
+	if len(raw) < 5 {
+		return nil, errors.New("base58check payload too short")
+	}
+	payload, checksum := raw[:len(raw)-4], raw[len(raw)-4:]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("invalid base58check checksum")
+	}
+	return payload, nil
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const (
+	bech32Const  = 1
+	bech32mConst = 0x2bc830a3
+)
+
+// bech32HRP returns the human-readable part when the address looks like
+// bech32 so we can pick the matching network before full validation.
+func bech32HRP(address string) (string, bool) {
+	pos := strings.LastIndexByte(address, '1')
+	if pos < 1 {
+		return "", false
+	}
+	return strings.ToLower(address[:pos]), true
+}
+
+func validateSegwitAddress(address, hrp string) error {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return errors.New("bech32 address must not mix case")
+	}
+	address = strings.ToLower(address)
+	if len(address) > 90 {
+		return errors.New("bech32 address too long")
+	}
+	pos := strings.LastIndexByte(address, '1')
+	if pos+7 > len(address) {
+		return errors.New("bech32 data part too short")
+	}
+	data := make([]byte, 0, len(address)-pos-1)
+	for _, c := range []byte(address[pos+1:]) {
+		idx := strings.IndexByte(bech32Charset, c)
+		if idx < 0 {
+			return fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(idx))
+	}
+	checksum := bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	values := data[:len(data)-6]
+	if len(values) == 0 {
+		return errors.New("missing witness version")
+	}
+	version := values[0]
+	switch {
+	case version == 0 && checksum != bech32Const:
+		return errors.New("invalid bech32 checksum")
+	case version > 0 && checksum != bech32mConst:
+		return errors.New("invalid bech32m checksum")
+	case version > 16:
+		return fmt.Errorf("invalid witness version %d", version)
+	}
+	program, err := convertBits(values[1:], 5, 8, false)
+	if err != nil {
+		return err
+	}
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("invalid witness program length %d", len(program))
+	}
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("invalid v0 witness program length %d", len(program))
+	}
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for _, c := range []byte(hrp) {
+		out = append(out, c>>5)
+	}
+	out = append(out, 0)
+	for _, c := range []byte(hrp) {
+		out = append(out, c&31)
+	}
+	return out
+}
+
+func convertBits(data []byte, from, to uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<to - 1
+	var out []byte
+	for _, v := range data {
+		if uint32(v)>>from != 0 {
+			return nil, errors.New("invalid data range")
+		}
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxv))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+	return out, nil
+}
+
+// EVM
+
+type evmValidator struct{}
+
+// EVM addresses do not encode the chain, so any network name is accepted.
+func (evmValidator) Networks() []string {
+	return nil
+}
+
+func (evmValidator) ValidateAddress(address, _ string) error {
+	if !strings.HasPrefix(address, "0x") {
+		return errors.New("evm address must start with 0x")
+	}
+	body := address[2:]
+	if len(body) != 40 {
+		return errors.New("evm address must be 20 bytes")
+	}
+	if _, err := hex.DecodeString(body); err != nil {
+		return errors.New("evm address must be hex")
+	}
+	// All-lower and all-upper addresses carry no EIP-55 checksum.
+	if strings.ToLower(body) == body || strings.ToUpper(body) == body {
+		return nil
+	}
+	hash := keccak256([]byte(strings.ToLower(body)))
+	for i, c := range []byte(body) {
+		if c >= '0' && c <= '9' {
+			continue
+		}
+		nibble := hash[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		upper := c >= 'A' && c <= 'F'
+		if (nibble&0x0f >= 8) != upper {
+			return errors.New("invalid eip-55 checksum")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cryptalias
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+const testDonationXMR = "8BUwkJ4LWiJS7bHAsKxBbaR1dkxzcvMJoNqGeCcLEt42betKeFnnEEA7xEJLBNNA1ngBS4V4pTVt6g8S4XZyePsc1UH5msc"
+
+// testMoneroAddress builds a checksummed mainnet standard address from a seed
+// so tests can use distinct, valid addresses without real wallets.
+func testMoneroAddress(t *testing.T, seed byte) string {
+	t.Helper()
+	raw := []byte{18}
+	for i := 0; i < 64; i++ {
+		raw = append(raw, seed+byte(i))
+	}
+	raw = append(raw, keccak256(raw)[:4]...)
+	return moneroBase58Encode(raw)
+}
+
+func testMoneroIntegratedAddress(t *testing.T, seed byte) string {
+	t.Helper()
+	raw := []byte{19}
+	for i := 0; i < 72; i++ {
+		raw = append(raw, seed+byte(i))
+	}
+	raw = append(raw, keccak256(raw)[:4]...)
+	return moneroBase58Encode(raw)
+}
+
+func moneroBase58Encode(data []byte) string {
+	var sb strings.Builder
+	for start := 0; start < len(data); start += 8 {
+		end := start + 8
+		if end > len(data) {
+			end = len(data)
+		}
+		block := data[start:end]
+		size := moneroEncodedBlockSizes[len(block)]
+		num := new(big.Int).SetBytes(block)
+		enc := make([]byte, size)
+		for i := size - 1; i >= 0; i-- {
+			mod := new(big.Int)
+			num.DivMod(num, big.NewInt(58), mod)
+			enc[i] = base58Alphabet[mod.Int64()]
+		}
+		sb.Write(enc)
+	}
+	return sb.String()
+}
+
+func TestAddressValidators(t *testing.T) {
+	cases := []struct {
+		name    string
+		ticker  string
+		network string
+		address string
+		ok      bool
+	}{
+		{name: "xmr subaddress", ticker: "xmr", address: testDonationXMR, ok: true},
+		{name: "xmr generated", ticker: "xmr", address: testMoneroAddress(t, 7), ok: true},
+		{name: "xmr wrong network", ticker: "xmr", network: "stagenet", address: testDonationXMR},
+		{name: "xmr bad checksum", ticker: "xmr", address: testDonationXMR[:len(testDonationXMR)-1] + "d"},
+		{name: "btc p2pkh", ticker: "btc", address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", ok: true},
+		{name: "btc p2sh", ticker: "btc", address: "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", ok: true},
+		{name: "btc bech32", ticker: "btc", address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", ok: true},
+		{name: "btc bech32m", ticker: "btc", address: "bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", ok: true},
+		{name: "btc testnet bech32", ticker: "btc", network: "testnet", address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", ok: true},
+		{name: "btc testnet on mainnet", ticker: "btc", address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{name: "btc bad checksum", ticker: "btc", address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3"},
+		{name: "btc bech32 bad checksum", ticker: "btc", address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5"},
+		{name: "eth checksummed", ticker: "eth", address: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ok: true},
+		{name: "eth lowercase", ticker: "eth", address: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", ok: true},
+		{name: "eth bad checksum", ticker: "eth", address: "0x5AAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"},
+		{name: "unknown ticker passes", ticker: "zzz", address: "anything", ok: true},
+	}
+	for _, tc := range cases {
+		token := TokenConfig{Network: tc.network}
+		err := validateTokenAddress(token, tc.ticker, tc.address)
+		if tc.ok && err != nil {
+			t.Fatalf("%s: expected valid, got %v", tc.name, err)
+		}
+		if !tc.ok && err == nil {
+			t.Fatalf("%s: expected validation error", tc.name)
+		}
+	}
+}
+
+func TestAddressValidatorWrongNetworkError(t *testing.T) {
+	err := validateTokenAddress(TokenConfig{Network: "testnet"}, "xmr", testDonationXMR)
+	if !errors.Is(err, errWrongNetwork) {
+		t.Fatalf("expected wrong network error, got %v", err)
+	}
+}
+
+func TestAddressValidatorChainOverridesTicker(t *testing.T) {
+	token := TokenConfig{Chain: "evm"}
+	if err := validateTokenAddress(token, "usdc", "0xnot-an-address"); err == nil {
+		t.Fatalf("expected evm validator to reject malformed address for usdc")
+	}
+}
+
+func TestValidateRejectsInvalidStaticAddress(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Domains[0].Aliases[0].Wallet.Address = "addr-root"
+
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected invalid static address to fail validation")
+	}
+}
@@ -0,0 +1,729 @@
+package cryptalias
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const minAdminTokenLength = 16
+
+// AdminConfig enables the admin REST API on a listener of its own.
+type AdminConfig struct {
+	// Listen is the admin address, e.g. 127.0.0.1:8081. Empty disables the
+	// API. It is read at startup only.
+	Listen string `yaml:"listen,omitempty"`
+	// Token is the bearer token every admin request must present.
+	Token string `yaml:"token,omitempty"`
+}
+
+func (a AdminConfig) validate() error {
+	if a.Listen == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(a.Listen); err != nil {
+		return fmt.Errorf("admin.listen must be host:port: %v", err)
+	}
+	if len(a.Token) < minAdminTokenLength {
+		return fmt.Errorf("admin.token must be at least %d characters when admin.listen is set", minAdminTokenLength)
+	}
+	return nil
+}
+
+// adminAPI edits domains, aliases, tags and tokens. Config changes go through
+// ConfigStore.Update; aliases through the active AliasStore.
+type adminAPI struct {
+	store *ConfigStore
+	// mu serializes alias edits so If-Match checks hold until the write.
+	mu sync.Mutex
+}
+
+// AdminHandler serves the admin REST API under /admin/v1. Responses use the
+// config.yml field names with secrets redacted; writes honour If-Match and
+// If-None-Match and support ?dry_run=1.
+func AdminHandler(store *ConfigStore) http.Handler {
+	a := &adminAPI{store: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/v1/domains", a.listDomains)
+	mux.HandleFunc("GET /admin/v1/domains/{domain}", a.getDomain)
+	mux.HandleFunc("PUT /admin/v1/domains/{domain}", a.putDomain)
+	mux.HandleFunc("DELETE /admin/v1/domains/{domain}", a.deleteDomain)
+
+	mux.HandleFunc("GET /admin/v1/domains/{domain}/aliases", a.listAliases)
+	mux.HandleFunc("GET /admin/v1/domains/{domain}/aliases/{alias}", a.getAlias)
+	mux.HandleFunc("DELETE /admin/v1/domains/{domain}/aliases/{alias}", a.deleteAlias)
+	mux.HandleFunc("GET /admin/v1/domains/{domain}/aliases/{alias}/{ticker}", a.getAliasEntry)
+	mux.HandleFunc("PUT /admin/v1/domains/{domain}/aliases/{alias}/{ticker}", a.putAliasEntry)
+	mux.HandleFunc("DELETE /admin/v1/domains/{domain}/aliases/{alias}/{ticker}", a.deleteAliasEntry)
+
+	mux.HandleFunc("GET /admin/v1/domains/{domain}/aliases/{alias}/{ticker}/tags", a.listTags)
+	mux.HandleFunc("GET /admin/v1/domains/{domain}/aliases/{alias}/{ticker}/tags/{tag}", a.getTag)
+	mux.HandleFunc("PUT /admin/v1/domains/{domain}/aliases/{alias}/{ticker}/tags/{tag}", a.putTag)
+	mux.HandleFunc("DELETE /admin/v1/domains/{domain}/aliases/{alias}/{ticker}/tags/{tag}", a.deleteTag)
+
+	mux.HandleFunc("GET /admin/v1/tokens", a.listTokens)
+	mux.HandleFunc("GET /admin/v1/tokens/{name}", a.getToken)
+	mux.HandleFunc("PUT /admin/v1/tokens/{name}", a.putToken)
+	mux.HandleFunc("DELETE /admin/v1/tokens/{name}", a.deleteToken)
+	return a.authenticate(mux)
+}
+
+func (a *adminAPI) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := a.store.Get().Admin.Token
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			slog.Warn("admin request unauthorized", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cryptalias-admin"`)
+			writeAdminError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Domains. Aliases are managed under the domain and are not part of it.
+
+func adminDomain(d AliasDomainConfig) AliasDomainConfig {
+	d.Aliases = nil
+	return d
+}
+
+func (a *adminAPI) listDomains(w http.ResponseWriter, r *http.Request) {
+	cfg := a.store.Get()
+	out := make([]any, 0, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		out = append(out, adminDomain(d))
+	}
+	writeAdminList(w, "domains", out)
+}
+
+func (a *adminAPI) getDomain(w http.ResponseWriter, r *http.Request) {
+	d, err := a.store.Get().GetDomain(strings.ToLower(r.PathValue("domain")))
+	if err != nil {
+		writeAdminError(w, http.StatusNotFound, "unknown domain")
+		return
+	}
+	a.writeAdminResource(w, http.StatusOK, adminDomain(*d))
+}
+
+func (a *adminAPI) putDomain(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.PathValue("domain"))
+	var cur any
+	if d, err := a.store.Get().GetDomain(name); err == nil {
+		cur = adminDomain(*d)
+	}
+	var next AliasDomainConfig
+	if err := decodeAdminBody(r, cur, &next, "aliases"); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := pathName(&next.Domain, name, "domain"); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+
+	created := false
+	cfg, ok := a.applyConfig(w, r, func(c *Config) error {
+		d, err := configDomain(c, name)
+		if err != nil {
+			if err := a.checkPreconditions(r, nil); err != nil {
+				return err
+			}
+			created = true
+			c.Domains = append(c.Domains, next)
+			return nil
+		}
+		if err := a.checkPreconditions(r, adminDomain(*d)); err != nil {
+			return err
+		}
+		created = false
+		aliases := d.Aliases
+		*d = next
+		d.Aliases = aliases
+		return nil
+	})
+	if !ok {
+		return
+	}
+	d, _ := cfg.GetDomain(name)
+	a.writeAdminResource(w, createdStatus(created), adminDomain(*d))
+}
+
+func (a *adminAPI) deleteDomain(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.PathValue("domain"))
+	if _, ok := a.applyConfig(w, r, func(c *Config) error {
+		d, err := configDomain(c, name)
+		if err != nil {
+			return errAdminNotFound
+		}
+		if err := a.checkPreconditions(r, adminDomain(*d)); err != nil {
+			return err
+		}
+		for i := range c.Domains {
+			if c.Domains[i].Domain == name {
+				c.Domains = append(c.Domains[:i], c.Domains[i+1:]...)
+				break
+			}
+		}
+		return nil
+	}); !ok {
+		return
+	}
+	if !isDryRun(r) {
+		dropDomainAliases(a.store, name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Aliases. An alias has one entry per root wallet ticker.
+
+func (a *adminAPI) listAliases(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	list, err := a.store.Aliases().ListAliases(domain)
+	if err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+	out := make([]any, 0, len(list))
+	for _, alias := range list {
+		out = append(out, alias)
+	}
+	writeAdminList(w, "aliases", out)
+}
+
+func (a *adminAPI) getAlias(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	entries, err := a.aliasEntries(domain, r.PathValue("alias"))
+	if err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+	_, etag, err := a.adminResource(entries)
+	if err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	out := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, entry)
+	}
+	writeAdminList(w, "aliases", out)
+}
+
+func (a *adminAPI) deleteAlias(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	name := strings.ToLower(r.PathValue("alias"))
+	entries, err := a.aliasEntries(domain, name)
+	if err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+	// The ETag covers every ticker's entry, as returned by getAlias.
+	if err := a.checkPreconditions(r, entries); err != nil {
+		a.fail(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if !isDryRun(r) {
+		if err := a.store.Aliases().DeleteAlias(domain, name, ""); err != nil {
+			a.fail(w, err, http.StatusInternalServerError)
+			return
+		}
+		logAdminChange(r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// aliasEntries returns the entries of an alias, one per root ticker, or
+// ErrAliasNotFound.
+func (a *adminAPI) aliasEntries(domain, alias string) ([]WalletAlias, error) {
+	list, err := a.store.Aliases().ListAliases(domain)
+	if err != nil {
+		return nil, err
+	}
+	alias = strings.ToLower(alias)
+	var out []WalletAlias
+	for _, entry := range list {
+		if entry.Alias == alias {
+			out = append(out, entry)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrAliasNotFound
+	}
+	return out, nil
+}
+
+func (a *adminAPI) getAliasEntry(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	entry, found, err := a.aliasEntry(domain, r.PathValue("alias"), r.PathValue("ticker"))
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	a.writeAdminResource(w, http.StatusOK, entry)
+}
+
+func (a *adminAPI) putAliasEntry(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	name, ticker := strings.ToLower(r.PathValue("alias")), strings.ToLower(r.PathValue("ticker"))
+	cur, found, err := a.aliasEntry(domain, name, ticker)
+	if err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return
+	}
+	var current any
+	if found {
+		current = cur
+	}
+	if err := a.checkPreconditions(r, current); err != nil {
+		a.fail(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	var next WalletAlias
+	if err := decodeAdminBody(r, current, &next); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := pathName(&next.Alias, name, "alias"); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := pathName(&next.Wallet.Ticker, ticker, "wallet.ticker"); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	a.saveAliasEntry(w, r, domain, next, !found)
+}
+
+func (a *adminAPI) deleteAliasEntry(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	name, ticker := strings.ToLower(r.PathValue("alias")), strings.ToLower(r.PathValue("ticker"))
+	cur, found, err := a.aliasEntry(domain, name, ticker)
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	if err := a.checkPreconditions(r, cur); err != nil {
+		a.fail(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if !isDryRun(r) {
+		if err := a.store.Aliases().DeleteAlias(domain, name, ticker); err != nil {
+			a.fail(w, err, http.StatusInternalServerError)
+			return
+		}
+		logAdminChange(r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tags belong to an alias entry and are saved with it.
+
+func (a *adminAPI) listTags(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	entry, found, err := a.aliasEntry(domain, r.PathValue("alias"), r.PathValue("ticker"))
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	out := make([]any, 0, len(entry.Tags))
+	for _, t := range entry.Tags {
+		out = append(out, t)
+	}
+	writeAdminList(w, "tags", out)
+}
+
+func (a *adminAPI) getTag(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	entry, found, err := a.aliasEntry(domain, r.PathValue("alias"), r.PathValue("ticker"))
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	i := findTag(entry, r.PathValue("tag"))
+	if i < 0 {
+		writeAdminError(w, http.StatusNotFound, "unknown tag")
+		return
+	}
+	a.writeAdminResource(w, http.StatusOK, entry.Tags[i])
+}
+
+func (a *adminAPI) putTag(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, found, err := a.aliasEntry(domain, r.PathValue("alias"), r.PathValue("ticker"))
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	name := strings.ToLower(r.PathValue("tag"))
+	i := findTag(entry, name)
+	var current any
+	if i >= 0 {
+		current = entry.Tags[i]
+	}
+	if err := a.checkPreconditions(r, current); err != nil {
+		a.fail(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	var next WalletTag
+	if err := decodeAdminBody(r, current, &next); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := pathName(&next.Tag, name, "tag"); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	entry.Tags = append([]WalletTag(nil), entry.Tags...)
+	if i >= 0 {
+		entry.Tags[i] = next
+	} else {
+		entry.Tags = append(entry.Tags, next)
+	}
+	if !a.validateAliasEntry(w, domain, &entry) {
+		return
+	}
+	if !isDryRun(r) {
+		if err := a.store.Aliases().PutAlias(domain, entry); err != nil {
+			a.fail(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+		logAdminChange(r)
+	}
+	a.writeAdminResource(w, createdStatus(i < 0), entry.Tags[findTag(entry, name)])
+}
+
+func (a *adminAPI) deleteTag(w http.ResponseWriter, r *http.Request) {
+	domain, ok := a.domain(w, r)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	entry, found, err := a.aliasEntry(domain, r.PathValue("alias"), r.PathValue("ticker"))
+	if err != nil || !found {
+		a.fail(w, notFoundOr(err), http.StatusInternalServerError)
+		return
+	}
+	i := findTag(entry, r.PathValue("tag"))
+	if i < 0 {
+		writeAdminError(w, http.StatusNotFound, "unknown tag")
+		return
+	}
+	if err := a.checkPreconditions(r, entry.Tags[i]); err != nil {
+		a.fail(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	entry.Tags = append(append([]WalletTag(nil), entry.Tags[:i]...), entry.Tags[i+1:]...)
+	if !isDryRun(r) {
+		if err := a.store.Aliases().PutAlias(domain, entry); err != nil {
+			a.fail(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+		logAdminChange(r)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveAliasEntry validates and, unless dry-running, stores an alias entry.
+func (a *adminAPI) saveAliasEntry(w http.ResponseWriter, r *http.Request, domain string, entry WalletAlias, created bool) {
+	if !a.validateAliasEntry(w, domain, &entry) {
+		return
+	}
+	if !isDryRun(r) {
+		if err := a.store.Aliases().PutAlias(domain, entry); err != nil {
+			a.fail(w, err, http.StatusUnprocessableEntity)
+			return
+		}
+		logAdminChange(r)
+	}
+	a.writeAdminResource(w, createdStatus(created), entry)
+}
+
+func (a *adminAPI) validateAliasEntry(w http.ResponseWriter, domain string, entry *WalletAlias) bool {
+	normalizeWalletAlias(entry)
+	if err := a.store.Get().validateAlias("alias", *entry); err != nil {
+		a.fail(w, err, http.StatusUnprocessableEntity)
+		return false
+	}
+	return true
+}
+
+// aliasEntry returns the entry of alias for ticker, or its only entry when
+// ticker is empty.
+func (a *adminAPI) aliasEntry(domain, alias, ticker string) (WalletAlias, bool, error) {
+	alias, ticker = strings.ToLower(alias), strings.ToLower(ticker)
+	list, err := a.store.Aliases().ListAliases(domain)
+	if err != nil {
+		return WalletAlias{}, false, err
+	}
+	for _, entry := range list {
+		if entry.Alias == alias && (ticker == "" || entry.Wallet.Ticker == ticker) {
+			return entry, true, nil
+		}
+	}
+	return WalletAlias{}, false, nil
+}
+
+func findTag(entry WalletAlias, tag string) int {
+	tag = strings.ToLower(tag)
+	for i, t := range entry.Tags {
+		if t.Tag == tag {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tokens are addressed by name.
+
+func (a *adminAPI) listTokens(w http.ResponseWriter, r *http.Request) {
+	cfg := a.store.Get()
+	out := make([]any, 0, len(cfg.Tokens))
+	for _, t := range cfg.Tokens {
+		out = append(out, t)
+	}
+	writeAdminList(w, "tokens", out)
+}
+
+func (a *adminAPI) getToken(w http.ResponseWriter, r *http.Request) {
+	cfg := a.store.Get()
+	i := findTokenByName(cfg, r.PathValue("name"))
+	if i < 0 {
+		writeAdminError(w, http.StatusNotFound, "unknown token")
+		return
+	}
+	a.writeAdminResource(w, http.StatusOK, cfg.Tokens[i])
+}
+
+func (a *adminAPI) putToken(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	cfg := a.store.Get()
+	var cur any
+	if i := findTokenByName(cfg, name); i >= 0 {
+		cur = cfg.Tokens[i]
+	}
+	var next TokenConfig
+	if err := decodeAdminBody(r, cur, &next); err != nil {
+		a.fail(w, err, http.StatusBadRequest)
+		return
+	}
+	if next.Name == "" {
+		next.Name = name
+	} else if next.Name != name {
+		a.fail(w, badAdminRequest("name %q does not match the path", next.Name), http.StatusBadRequest)
+		return
+	}
+
+	created := false
+	cfg, ok := a.applyConfig(w, r, func(c *Config) error {
+		i := findTokenByName(c, name)
+		if i < 0 {
+			if err := a.checkPreconditions(r, nil); err != nil {
+				return err
+			}
+			created = true
+			c.Tokens = append(c.Tokens, next)
+			return nil
+		}
+		if err := a.checkPreconditions(r, c.Tokens[i]); err != nil {
+			return err
+		}
+		created = false
+		c.Tokens[i] = next
+		return nil
+	})
+	if !ok {
+		return
+	}
+	a.writeAdminResource(w, createdStatus(created), cfg.Tokens[findTokenByName(cfg, name)])
+}
+
+func (a *adminAPI) deleteToken(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if _, ok := a.applyConfig(w, r, func(c *Config) error {
+		i := findTokenByName(c, name)
+		if i < 0 {
+			return errAdminNotFound
+		}
+		if err := a.checkPreconditions(r, c.Tokens[i]); err != nil {
+			return err
+		}
+		c.Tokens = append(c.Tokens[:i], c.Tokens[i+1:]...)
+		return nil
+	}); !ok {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func findTokenByName(cfg *Config, name string) int {
+	for i, t := range cfg.Tokens {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyConfig validates mutate against a copy of the config and, unless
+// dry-running, applies it with ConfigStore.Update. It returns the resulting
+// config, or writes the error response and returns false.
+func (a *adminAPI) applyConfig(w http.ResponseWriter, r *http.Request, mutate func(*Config) error) (*Config, bool) {
+	next, err := a.store.Check(mutate)
+	if err != nil {
+		a.fail(w, err, http.StatusUnprocessableEntity)
+		return nil, false
+	}
+	if isDryRun(r) {
+		return next, true
+	}
+	if err := a.store.Update(mutate); err != nil {
+		a.fail(w, err, http.StatusInternalServerError)
+		return nil, false
+	}
+	logAdminChange(r)
+	return a.store.Get(), true
+}
+
+func (a *adminAPI) domain(w http.ResponseWriter, r *http.Request) (string, bool) {
+	d, err := a.store.Get().GetDomain(strings.ToLower(r.PathValue("domain")))
+	if err != nil {
+		writeAdminError(w, http.StatusNotFound, "unknown domain")
+		return "", false
+	}
+	return d.Domain, true
+}
+
+// fail writes err with the status its kind maps to, or status otherwise.
+func (a *adminAPI) fail(w http.ResponseWriter, err error, status int) {
+	var bad *adminBadRequest
+	switch {
+	case errors.Is(err, errAdminPrecondition):
+		status = http.StatusPreconditionFailed
+	case errors.Is(err, errAdminNotFound), errors.Is(err, ErrAliasNotFound):
+		status = http.StatusNotFound
+	case errors.As(err, &bad):
+		status = http.StatusBadRequest
+	}
+	if status >= http.StatusInternalServerError {
+		slog.Error("admin request failed", "error", err)
+	}
+	writeAdminError(w, status, err.Error())
+}
+
+func notFoundOr(err error) error {
+	if err == nil {
+		return errAdminNotFound
+	}
+	return err
+}
+
+// pathName fills an empty name field from the path and rejects a mismatch.
+func pathName(field *string, name, key string) error {
+	got := strings.ToLower(strings.TrimSpace(*field))
+	if got == "" {
+		*field = name
+		return nil
+	}
+	if got != name {
+		return badAdminRequest("%s %q does not match the path", key, *field)
+	}
+	return nil
+}
+
+func isDryRun(r *http.Request) bool {
+	switch strings.ToLower(r.URL.Query().Get("dry_run")) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+func createdStatus(created bool) int {
+	if created {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+func logAdminChange(r *http.Request) {
+	slog.Info("admin change", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+}
+
+func (a *adminAPI) writeAdminResource(w http.ResponseWriter, status int, v any) {
+	tree, etag, err := a.adminResource(v)
+	if err != nil {
+		writeAdminError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("ETag", etag)
+	writeAdminJSON(w, status, tree)
+}
+
+func writeAdminList(w http.ResponseWriter, key string, items []any) {
+	out := make([]any, 0, len(items))
+	for _, item := range items {
+		tree, err := adminTree(item)
+		if err != nil {
+			writeAdminError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		redactTree(tree)
+		out = append(out, tree)
+	}
+	writeAdminJSON(w, http.StatusOK, map[string]any{key: out})
+}
+
+func writeAdminError(w http.ResponseWriter, status int, msg string) {
+	writeAdminJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
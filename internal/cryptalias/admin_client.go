@@ -0,0 +1,172 @@
+package cryptalias
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// adminClient is an AliasManager backed by a running server's admin API.
+type adminClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// NewAdminAliasManager manages domains and aliases through the admin API at
+// baseURL, e.g. http://127.0.0.1:8081.
+func NewAdminAliasManager(baseURL, token string) AliasManager {
+	return &adminClient{
+		baseURL: strings.TrimRight(baseURL, "/") + "/admin/v1",
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *adminClient) Close() error {
+	return nil
+}
+
+// adminStatusError is a non-2xx admin API answer.
+type adminStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *adminStatusError) Error() string {
+	return fmt.Sprintf("admin API: %s (%d)", e.msg, e.status)
+}
+
+// do sends body as JSON and decodes a JSON answer into out.
+func (c *adminClient) do(method string, segments []string, body, out any, headers ...string) error {
+	path := c.baseURL
+	for _, s := range segments {
+		path += "/" + url.PathEscape(s)
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAdminBodyBytes))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		return &adminStatusError{status: resp.StatusCode, msg: e.Error}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (c *adminClient) ListDomains() ([]DomainSummary, error) {
+	var resp struct {
+		Domains []DomainSummary `json:"domains"`
+	}
+	if err := c.do(http.MethodGet, []string{"domains"}, nil, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Domains {
+		resp.Domains[i].DNSTXT = "pubkey=" + resp.Domains[i].PublicKey
+	}
+	return resp.Domains, nil
+}
+
+func (c *adminClient) AddDomain(domain string) (DomainSummary, error) {
+	var out DomainSummary
+	err := c.do(http.MethodPut, []string{"domains", domain}, map[string]string{}, &out, "If-None-Match", "*")
+	if e, ok := err.(*adminStatusError); ok && e.status == http.StatusPreconditionFailed {
+		return out, fmt.Errorf("%w: %s", ErrDomainExists, domain)
+	}
+	out.DNSTXT = "pubkey=" + out.PublicKey
+	return out, err
+}
+
+func (c *adminClient) RemoveDomain(domain string) error {
+	return c.do(http.MethodDelete, []string{"domains", domain}, nil, nil)
+}
+
+func (c *adminClient) ListAliases(domain string) ([]WalletAlias, error) {
+	var resp struct {
+		Aliases []WalletAlias `json:"aliases"`
+	}
+	if err := c.do(http.MethodGet, []string{"domains", domain, "aliases"}, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Aliases, nil
+}
+
+func (c *adminClient) AddAlias(domain string, alias WalletAlias) error {
+	normalizeWalletAlias(&alias)
+	err := c.do(http.MethodPut, []string{"domains", domain, "aliases", alias.Alias, alias.Wallet.Ticker}, alias, nil, "If-None-Match", "*")
+	if e, ok := err.(*adminStatusError); ok && e.status == http.StatusPreconditionFailed {
+		return fmt.Errorf("%w: %s$%s (%s)", ErrAliasExists, alias.Alias, domain, alias.Wallet.Ticker)
+	}
+	return err
+}
+
+func (c *adminClient) RemoveAlias(domain, alias, ticker string) error {
+	segments := []string{"domains", domain, "aliases", alias}
+	if ticker != "" {
+		segments = append(segments, ticker)
+	}
+	return c.do(http.MethodDelete, segments, nil, nil)
+}
+
+func (c *adminClient) PutTag(domain, alias, ticker string, tag WalletTag) error {
+	return c.do(http.MethodPut, []string{"domains", domain, "aliases", alias, ticker, "tags", tag.Tag}, tag, nil)
+}
+
+func (c *adminClient) RemoveTag(domain, alias, ticker, tag string) error {
+	if ticker != "" {
+		return c.do(http.MethodDelete, []string{"domains", domain, "aliases", alias, ticker, "tags", tag}, nil, nil)
+	}
+	list, err := c.ListAliases(domain)
+	if err != nil {
+		return err
+	}
+	removed := false
+	for _, entry := range list {
+		if entry.Alias != strings.ToLower(alias) || findTag(entry, tag) < 0 {
+			continue
+		}
+		if err := c.do(http.MethodDelete, []string{"domains", domain, "aliases", entry.Alias, entry.Wallet.Ticker, "tags", tag}, nil, nil); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if !removed {
+		return ErrTagNotFound
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package cryptalias
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	redactedSecret     = "REDACTED"
+	maxAdminBodyBytes  = 1 << 20
+	adminETagHashBytes = 12
+)
+
+// secretConfigKeys are config keys whose values the admin API never returns.
+// Every value of a secretConfigMaps map (HTTP headers, exec env) is redacted
+// as well, and addressConfigKey values lose their userinfo like in config
+// diffs.
+var (
+	secretConfigKeys = map[string]bool{"private_key": true, "token": true, "password": true, "wallet_password": true, "hmac_secret": true}
+	secretConfigMaps = map[string]bool{"headers": true, "env": true}
+)
+
+const addressConfigKey = "address"
+
+// redactedAddressValue returns the redacted form of an endpoint address and
+// whether it differs. Wallet addresses share the key but never contain '@'
+// or '?', so they are left alone.
+func redactedAddressValue(v any) (string, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.ContainsAny(s, "@?") {
+		return "", false
+	}
+	redacted := redactAddress(s)
+	return redacted, redacted != s
+}
+
+var (
+	errAdminPrecondition = errors.New("precondition failed: the resource has changed")
+	errAdminNotFound     = errors.New("not found")
+)
+
+// adminBadRequest reports a malformed request body or path.
+type adminBadRequest struct {
+	msg string
+}
+
+func (e *adminBadRequest) Error() string {
+	return e.msg
+}
+
+func badAdminRequest(format string, args ...any) error {
+	return &adminBadRequest{msg: fmt.Sprintf(format, args...)}
+}
+
+// adminTree converts a config value to its YAML shape with string keys, so
+// the admin API uses the same field names as config.yml.
+func adminTree(v any) (any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return stringKeys(raw), nil
+}
+
+func stringKeys(v any) any {
+	switch t := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(t))
+		for k, child := range t {
+			out[fmt.Sprint(k)] = stringKeys(child)
+		}
+		return out
+	case []any:
+		for i := range t {
+			t[i] = stringKeys(t[i])
+		}
+	}
+	return v
+}
+
+// adminResource returns the redacted tree of a resource and its strong ETag.
+// The ETag covers the redacted tree and the store's secret revision, so a
+// changed secret invalidates it without the ETag depending on the secret.
+func (a *adminAPI) adminResource(v any) (any, string, error) {
+	tree, err := adminTree(v)
+	if err != nil {
+		return nil, "", err
+	}
+	redactTree(tree)
+	data, err := json.Marshal(tree)
+	if err != nil {
+		return nil, "", err
+	}
+	data = binary.BigEndian.AppendUint64(data, a.store.SecretRevision())
+	sum := sha256.Sum256(data)
+	return tree, fmt.Sprintf("%q", fmt.Sprintf("%x", sum[:adminETagHashBytes])), nil
+}
+
+// secretTree keeps only the secret values of an admin tree, in place, so
+// they can be compared between configs. It is nil when there are none.
+func secretTree(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		out := map[string]any{}
+		for k, child := range t {
+			if _, redacted := redactedAddressValue(child); secretConfigKeys[k] || secretConfigMaps[k] || (k == addressConfigKey && redacted) {
+				out[k] = child
+			} else if st := secretTree(child); st != nil {
+				out[k] = st
+			}
+		}
+		if len(out) > 0 {
+			return out
+		}
+	case []any:
+		out := make([]any, len(t))
+		found := false
+		for i, child := range t {
+			out[i] = secretTree(child)
+			found = found || out[i] != nil
+		}
+		if found {
+			return out
+		}
+	}
+	return nil
+}
+
+// configSecretsDigest hashes every secret of a config, so the store can tell
+// when one changed.
+func configSecretsDigest(cfg *Config) [sha256.Size]byte {
+	tree, err := adminTree(cfg)
+	if err != nil {
+		return [sha256.Size]byte{}
+	}
+	data, _ := json.Marshal(secretTree(tree))
+	return sha256.Sum256(data)
+}
+
+func redactTree(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			switch {
+			case secretConfigKeys[k]:
+				if s, ok := child.(string); !ok || s != "" {
+					t[k] = redactedSecret
+				}
+			case secretConfigMaps[k]:
+				if m, ok := child.(map[string]any); ok {
+					for mk := range m {
+						m[mk] = redactedSecret
+					}
+				}
+			case k == addressConfigKey:
+				if redacted, ok := redactedAddressValue(child); ok {
+					t[k] = redacted
+				}
+			default:
+				redactTree(child)
+			}
+		}
+	case []any:
+		for _, child := range t {
+			redactTree(child)
+		}
+	}
+}
+
+// listIdentityKeys are the keys that identify an entry of a config list,
+// tried in order.
+var listIdentityKeys = []string{"id", "domain", "name"}
+
+// listIdentity returns the identifying key and value of a list entry, or ""
+// when it has none.
+func listIdentity(v any) (string, string) {
+	m, _ := v.(map[string]any)
+	for _, k := range listIdentityKeys {
+		if id, ok := m[k].(string); ok && id != "" {
+			return k, id
+		}
+	}
+	return "", ""
+}
+
+// currentListEntry finds the entry of cur that in[i] updates: the one with
+// the same identifier or, for entries without one (unnamed endpoints), the
+// unnamed entry at the same position. It is nil for a new entry, whose
+// secrets cannot be REDACTED.
+func currentListEntry(in, cur []any, i int) any {
+	key, id := listIdentity(in[i])
+	if key == "" {
+		if i < len(cur) {
+			if k, _ := listIdentity(cur[i]); k == "" {
+				return cur[i]
+			}
+		}
+		return nil
+	}
+	for _, c := range cur {
+		if k, cid := listIdentity(c); k == key && cid == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// restoreSecrets puts back the current value of each secret sent back as
+// REDACTED, and of each address sent back in its redacted form, so a
+// GET-modify-PUT round trip keeps secrets unchanged. List
+// entries are matched by identifier, so reordering or removing entries never
+// moves a secret to another entry.
+func restoreSecrets(in, cur any, path string) error {
+	switch t := in.(type) {
+	case map[string]any:
+		c, _ := cur.(map[string]any)
+		for k, v := range t {
+			switch {
+			case secretConfigKeys[k] && v == redactedSecret:
+				if c[k] == nil {
+					return badAdminRequest("%s%s is %s but has no current value", path, k, redactedSecret)
+				}
+				t[k] = c[k]
+			case secretConfigMaps[k]:
+				m, _ := v.(map[string]any)
+				cm, _ := c[k].(map[string]any)
+				for mk, mv := range m {
+					if mv != redactedSecret {
+						continue
+					}
+					if cm[mk] == nil {
+						return badAdminRequest("%s%s.%s is %s but has no current value", path, k, mk, redactedSecret)
+					}
+					m[mk] = cm[mk]
+				}
+			case k == addressConfigKey:
+				if redacted, ok := redactedAddressValue(c[k]); ok && v == redacted {
+					t[k] = c[k]
+				}
+			default:
+				if err := restoreSecrets(v, c[k], path+k+"."); err != nil {
+					return err
+				}
+			}
+		}
+	case []any:
+		c, _ := cur.([]any)
+		for i := range t {
+			if err := restoreSecrets(t[i], currentListEntry(t, c, i), fmt.Sprintf("%s%d.", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonNumbers turns json.Number values into ints where possible so YAML
+// decodes them into integer fields.
+func jsonNumbers(v any) any {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]any:
+		for k, child := range t {
+			t[k] = jsonNumbers(child)
+		}
+	case []any:
+		for i := range t {
+			t[i] = jsonNumbers(t[i])
+		}
+	}
+	return v
+}
+
+// decodeAdminBody reads a JSON object from the request into dst, the same
+// way config.yml would be read. cur is the current resource, if any, whose
+// secrets replace REDACTED placeholders. forbidden keys are rejected.
+func decodeAdminBody(r *http.Request, cur any, dst any, forbidden ...string) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxAdminBodyBytes))
+	dec.UseNumber()
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return badAdminRequest("invalid JSON body: %v", err)
+	}
+	obj, ok := jsonNumbers(body).(map[string]any)
+	if !ok {
+		return badAdminRequest("body must be a JSON object")
+	}
+	for _, k := range forbidden {
+		if _, ok := obj[k]; ok {
+			return badAdminRequest("%s cannot be set here", k)
+		}
+	}
+	if cur != nil {
+		curTree, err := adminTree(cur)
+		if err != nil {
+			return err
+		}
+		if err := restoreSecrets(obj, curTree, ""); err != nil {
+			return err
+		}
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	if err := yaml.UnmarshalStrict(data, dst); err != nil {
+		return badAdminRequest("invalid body: %v", err)
+	}
+	return nil
+}
+
+// checkPreconditions applies If-Match and If-None-Match to the current
+// version of a resource; cur is nil when it does not exist.
+func (a *adminAPI) checkPreconditions(r *http.Request, cur any) error {
+	match, noneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if match == "" && noneMatch == "" {
+		return nil
+	}
+	etag := ""
+	if cur != nil {
+		var err error
+		if _, etag, err = a.adminResource(cur); err != nil {
+			return err
+		}
+	}
+	if match != "" && (cur == nil || !etagListMatches(match, etag)) {
+		return errAdminPrecondition
+	}
+	if noneMatch != "" && cur != nil && etagListMatches(noneMatch, etag) {
+		return errAdminPrecondition
+	}
+	return nil
+}
+
+func etagListMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -20,6 +20,7 @@ var (
 
 type walletResolver interface {
 	Resolve(ctx context.Context, cfg *Config, in dynamicAliasInput) (WalletAddress, error)
+	LookupAlias(ctx context.Context, cfg *Config, domainCfg AliasDomainConfig, aliasName, tag, ticker string) (WalletAddress, error)
 }
 
 type Alias struct {
@@ -40,13 +41,17 @@ func ParseAliasDomain(input string) (string, error) {
 	return domain, nil
 }
 
-// ParseAlias resolves only static mappings from config.
-func ParseAlias(input string, ticker string, config *Config) (Alias, error) {
+// ParseAlias resolves only static mappings, from the same alias store as
+// ResolveAlias.
+func ParseAlias(input string, ticker string, config *Config, aliases AliasStore) (Alias, error) {
 	alias, domainCfg, tickerClean, err := parseAliasIdentifier(input, ticker, config)
 	if err != nil {
 		return Alias{}, err
 	}
-	walletCfg, ok := findAliasWallet(domainCfg, alias.Alias, alias.Tag, tickerClean)
+	walletCfg, ok, err := aliases.FindWallet(domainCfg.Domain, alias.Alias, alias.Tag, tickerClean)
+	if err != nil {
+		return Alias{}, err
+	}
 	if ok && strings.TrimSpace(walletCfg.Address) != "" {
 		alias.Wallet = walletCfg
 		return alias, nil
@@ -57,12 +62,17 @@ func ParseAlias(input string, ticker string, config *Config) (Alias, error) {
 // ResolveAlias prefers static mappings, then falls back to dynamic resolution.
 // When a static alias exists but has no address, its optional routing hints
 // (account_index/account_id/wallet_id) are forwarded to the wallet service.
-func ResolveAlias(ctx context.Context, input string, ticker string, cfg *Config, resolver walletResolver) (Alias, error) {
+// A domain with an alias provider only resolves the aliases it declares
+// besides those in the alias store.
+func ResolveAlias(ctx context.Context, input string, ticker string, cfg *Config, aliases AliasStore, resolver walletResolver) (Alias, error) {
 	alias, domainCfg, tickerClean, err := parseAliasIdentifier(input, ticker, cfg)
 	if err != nil {
 		return Alias{}, err
 	}
-	walletCfg, ok := findAliasWallet(domainCfg, alias.Alias, alias.Tag, tickerClean)
+	walletCfg, ok, err := aliases.FindWallet(domainCfg.Domain, alias.Alias, alias.Tag, tickerClean)
+	if err != nil {
+		return Alias{}, err
+	}
 	if ok && strings.TrimSpace(walletCfg.Address) != "" {
 		alias.Wallet = walletCfg
 		return alias, nil
@@ -70,28 +80,65 @@ func ResolveAlias(ctx context.Context, input string, ticker string, cfg *Config,
 	if resolver == nil {
 		return Alias{}, ErrAliasNotFound
 	}
+	if !ok && domainCfg.AliasProvider != nil {
+		if walletCfg, err = resolver.LookupAlias(ctx, cfg, domainCfg, alias.Alias, alias.Tag, tickerClean); err != nil {
+			return Alias{}, err
+		}
+		if walletCfg.Address != "" {
+			alias.Wallet = walletCfg
+			return alias, nil
+		}
+		ok = true
+	}
 
 	in := dynamicAliasInput{
 		Ticker: tickerClean,
 		Alias:  alias.Alias,
 		Tag:    alias.Tag,
 		Domain: alias.Domain,
+		Amount: requestAmountFromContext(ctx),
+		Memo:   requestMemoFromContext(ctx),
 	}
 	if ok {
 		in.AccountIndex = walletCfg.AccountIndex
 		in.AccountID = walletCfg.AccountID
 		in.WalletID = walletCfg.WalletID
+		in.AddressMode = walletCfg.AddressMode
 	}
 
 	wallet, err := resolver.Resolve(ctx, cfg, in)
 	if err != nil {
 		return Alias{}, err
 	}
+	if ok {
+		// Destination fields configured on the alias (e.g. an exchange deposit
+		// memo) apply unless the wallet service supplied its own.
+		integrated := false
+		if token, err := findTokenConfig(cfg, tickerClean); err == nil {
+			integrated = isIntegratedAddress(token, tickerClean, wallet.Address)
+		}
+		inheritDestinationFields(&wallet, walletCfg, integrated)
+	}
 
 	alias.Wallet = wallet
 	return alias, nil
 }
 
+// inheritDestinationFields fills unset destination fields of dst from src. An
+// integrated address already carries its payment ID, and wallets reject a
+// second one, so payment_id is not inherited for it.
+func inheritDestinationFields(dst *WalletAddress, src WalletAddress, integrated bool) {
+	if dst.Memo == nil {
+		dst.Memo = src.Memo
+	}
+	if dst.DestinationTag == nil {
+		dst.DestinationTag = src.DestinationTag
+	}
+	if dst.PaymentID == nil && !integrated {
+		dst.PaymentID = src.PaymentID
+	}
+}
+
 func parseAliasIdentifier(input string, ticker string, cfg *Config) (Alias, AliasDomainConfig, string, error) {
 	inputClean := strings.TrimSpace(input)
 	if inputClean == "" {
@@ -164,25 +211,6 @@ func parseAliasParts(input string) (string, string, string, string, error) {
 	return prefixTicker, alias, tag, domain, nil
 }
 
-func findAliasWallet(domainCfg AliasDomainConfig, aliasName, tag, tickerClean string) (WalletAddress, bool) {
-	for _, a := range domainCfg.Aliases {
-		if a.Alias != aliasName {
-			continue
-		}
-		// Check tags first.
-		for _, t := range a.Tags {
-			if t.Tag == tag && t.Wallet.Ticker == tickerClean {
-				return t.Wallet, true
-			}
-		}
-		// Fall back to the root alias if tickers match.
-		if a.Wallet.Ticker == tickerClean {
-			return a.Wallet, true
-		}
-	}
-	return WalletAddress{}, false
-}
-
 func validateAliasOrTag(s, field string) error {
 	if s == "" {
 		return fmt.Errorf("%s is empty", field)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"testing"
+
+	"google.golang.org/protobuf/proto"
 )
 
 type fakeResolver struct {
@@ -22,6 +24,10 @@ func (f *fakeResolver) Resolve(_ context.Context, _ *Config, in dynamicAliasInpu
 	return WalletAddress{Ticker: in.Ticker, Address: f.addr}, nil
 }
 
+func (f *fakeResolver) LookupAlias(context.Context, *Config, AliasDomainConfig, string, string, string) (WalletAddress, error) {
+	return WalletAddress{}, ErrAliasNotFound
+}
+
 func TestResolveAliasFallsBackToDynamic(t *testing.T) {
 	pub, priv := testKeypair(t)
 	cfg := &Config{
@@ -45,7 +51,8 @@ func TestResolveAliasFallsBackToDynamic(t *testing.T) {
 	cfg.Normalize("")
 
 	resolver := &fakeResolver{addr: "addr-dynamic"}
-	alias, err := ResolveAlias(context.Background(), "demo+tip$127.0.0.1", "XMR", cfg, resolver)
+	ctx := withRequestAmount(context.Background(), "0.5")
+	alias, err := ResolveAlias(ctx, "demo+tip$127.0.0.1", "XMR", cfg, NewConfigStore("", cfg), resolver)
 	if err != nil {
 		t.Fatalf("resolve alias: %v", err)
 	}
@@ -58,7 +65,7 @@ func TestResolveAliasFallsBackToDynamic(t *testing.T) {
 	if !resolver.called {
 		t.Fatalf("expected dynamic resolver to be called")
 	}
-	if resolver.last.Ticker != "xmr" || resolver.last.Alias != "demo" || resolver.last.Tag != "tip" || resolver.last.Domain != "127.0.0.1" {
+	if resolver.last.Ticker != "xmr" || resolver.last.Alias != "demo" || resolver.last.Tag != "tip" || resolver.last.Domain != "127.0.0.1" || resolver.last.Amount != "0.5" {
 		t.Fatalf("unexpected dynamic input: %+v", resolver.last)
 	}
 }
@@ -67,14 +74,40 @@ func TestResolveAliasStaticPrecedence(t *testing.T) {
 	resolver := &fakeResolver{err: errors.New("should not be called")}
 	cfg := testConfig(t)
 
-	alias, err := ResolveAlias(context.Background(), "demo$127.0.0.1", "xmr", cfg, resolver)
+	alias, err := ResolveAlias(context.Background(), "demo$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver)
 	if err != nil {
 		t.Fatalf("resolve alias: %v", err)
 	}
-	if alias.Wallet.Address != "addr-root" {
+	if alias.Wallet.Address != testMoneroAddress(t, 1) {
 		t.Fatalf("expected static root address, got %q", alias.Wallet.Address)
 	}
 	if resolver.called {
 		t.Fatalf("expected dynamic resolver not to be called")
 	}
 }
+
+func TestResolveAliasSkipsPaymentIDForIntegratedAddress(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Domains[0].Aliases = []WalletAlias{{
+		Alias:  "shop",
+		Wallet: WalletAddress{Ticker: "xmr", PaymentID: proto.String("0011223344556677")},
+	}}
+	cfg.Normalize("")
+
+	for _, tc := range []struct {
+		addr      string
+		paymentID bool
+	}{
+		{testMoneroAddress(t, 1), true},
+		{testMoneroIntegratedAddress(t, 1), false},
+	} {
+		resolver := &fakeResolver{addr: tc.addr}
+		alias, err := ResolveAlias(context.Background(), "shop$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver)
+		if err != nil {
+			t.Fatalf("resolve alias: %v", err)
+		}
+		if got := alias.Wallet.PaymentID != nil; got != tc.paymentID {
+			t.Fatalf("address %s: expected payment ID %t, got %v", tc.addr, tc.paymentID, alias.Wallet.PaymentID)
+		}
+	}
+}
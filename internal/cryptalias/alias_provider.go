@@ -0,0 +1,260 @@
+package cryptalias
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultAliasProviderCacheSeconds   = 30
+	defaultAliasProviderTimeoutSeconds = 5
+	// maxAliasProviderCacheEntries bounds the lookup cache; expired entries
+	// are pruned when it is full.
+	maxAliasProviderCacheEntries = 10000
+)
+
+// ErrAliasProviderUnavailable is returned when a domain's alias provider
+// cannot be reached and no cached answer is available.
+var ErrAliasProviderUnavailable = errors.New("alias provider unavailable")
+
+// AliasProviderConfig points a domain at an AliasProvider gRPC service that
+// declares the aliases not listed in config.
+type AliasProviderConfig struct {
+	// Address is the gRPC target (host:port).
+	Address string `yaml:"address"`
+	// Token/Username/Password are sent as auth metadata, like external
+	// wallet endpoints.
+	Token    string `yaml:"token,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// TLS secures the connection.
+	TLS EndpointTLSConfig `yaml:"tls,omitempty"`
+	// AllowInsecureCredentials permits credentials without TLS to a
+	// non-loopback address.
+	AllowInsecureCredentials bool `yaml:"allow_insecure_credentials,omitempty"`
+	// CacheSeconds is how long answers, including "not found", are reused
+	// (default 30).
+	CacheSeconds int `yaml:"cache_seconds,omitempty"`
+	// TimeoutSeconds bounds each lookup (default 5).
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+func (p *AliasProviderConfig) normalize() {
+	p.Address = strings.TrimSpace(p.Address)
+	if p.CacheSeconds == 0 {
+		p.CacheSeconds = defaultAliasProviderCacheSeconds
+	}
+	if p.TimeoutSeconds == 0 {
+		p.TimeoutSeconds = defaultAliasProviderTimeoutSeconds
+	}
+}
+
+func (p AliasProviderConfig) validate(path string) error {
+	if p.Address == "" {
+		return fmt.Errorf("%s.address is required", path)
+	}
+	if p.CacheSeconds < 0 {
+		return fmt.Errorf("%s.cache_seconds must be >= 0", path)
+	}
+	if p.TimeoutSeconds < 0 {
+		return fmt.Errorf("%s.timeout_seconds must be >= 0", path)
+	}
+	if err := p.TLS.validate(); err != nil {
+		return fmt.Errorf("%s.tls.%v", path, err)
+	}
+	if err := checkCredentialTransport(p.endpoint()); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+func (p *AliasProviderConfig) Clone() *AliasProviderConfig {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.TLS.PinSHA256 = append([]string(nil), p.TLS.PinSHA256...)
+	return &out
+}
+
+// endpoint lets the provider share the external gRPC connection handling.
+func (p AliasProviderConfig) endpoint() TokenEndpointConfig {
+	return TokenEndpointConfig{
+		EndpointType:             TokenEndpointTypeExternal,
+		EndpointAddress:          p.Address,
+		Token:                    p.Token,
+		Username:                 p.Username,
+		Password:                 p.Password,
+		TLS:                      p.TLS,
+		AllowInsecureCredentials: p.AllowInsecureCredentials,
+	}
+}
+
+// aliasProviderClient looks aliases up with AliasProvider services and caches
+// the answers briefly.
+type aliasProviderClient struct {
+	grpc *grpcWalletClient
+
+	mu    sync.Mutex
+	cache map[string]providedAliasEntry
+}
+
+type providedAliasEntry struct {
+	wallet  WalletAddress
+	found   bool
+	expires time.Time
+}
+
+func newAliasProviderClient(grpc *grpcWalletClient) *aliasProviderClient {
+	return &aliasProviderClient{grpc: grpc, cache: map[string]providedAliasEntry{}}
+}
+
+// Lookup returns the wallet the domain's provider declares for an alias, or
+// ErrAliasNotFound when the alias does not exist or is disabled.
+func (c *aliasProviderClient) Lookup(ctx context.Context, cfg *Config, domainCfg AliasDomainConfig, aliasName, tag, ticker string) (WalletAddress, error) {
+	provider := domainCfg.AliasProvider
+	key := strings.Join([]string{domainCfg.Domain, aliasName, tag, ticker}, "|")
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.cache[key]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		if !cached.found {
+			return WalletAddress{}, ErrAliasNotFound
+		}
+		return cached.wallet, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(provider.TimeoutSeconds)*time.Second)
+	defer cancel()
+	resp, err := c.lookup(ctx, *provider, &cryptaliasv1.LookupAliasRequest{Domain: domainCfg.Domain, Alias: aliasName, Tag: tag, Ticker: ticker})
+	if status.Code(err) == codes.NotFound {
+		resp, err = &cryptaliasv1.LookupAliasResponse{}, nil
+	}
+	if err != nil {
+		slog.Error("alias provider lookup failed", "domain", domainCfg.Domain, "alias", aliasName, "error", err)
+		return WalletAddress{}, fmt.Errorf("%w: %v", ErrAliasProviderUnavailable, err)
+	}
+
+	entry := providedAliasEntry{found: resp.GetFound() && resp.GetAlias().GetEnabled()}
+	if entry.found {
+		entry.wallet = providedWallet(resp.GetAlias(), ticker)
+		if err := validateProvidedWallet(cfg, entry.wallet); err != nil {
+			slog.Error("alias provider returned an invalid alias", "domain", domainCfg.Domain, "alias", aliasName, "error", err)
+			return WalletAddress{}, fmt.Errorf("alias provider returned invalid alias: %w", err)
+		}
+	}
+	ttl := time.Duration(provider.CacheSeconds) * time.Second
+	if secs := resp.GetCacheSeconds(); secs > 0 {
+		ttl = time.Duration(secs) * time.Second
+	}
+	entry.expires = now.Add(ttl)
+	c.store(key, entry, now)
+	slog.Debug("alias provider lookup", "domain", domainCfg.Domain, "alias", aliasName, "tag", tag, "ticker", ticker, "found", entry.found)
+
+	if !entry.found {
+		return WalletAddress{}, ErrAliasNotFound
+	}
+	return entry.wallet, nil
+}
+
+func (c *aliasProviderClient) lookup(ctx context.Context, provider AliasProviderConfig, req *cryptaliasv1.LookupAliasRequest) (*cryptaliasv1.LookupAliasResponse, error) {
+	endpoint := provider.endpoint()
+	wc, release, err := c.grpc.connFor(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return wc.aliases.LookupAlias(withEndpointAuth(ctx, endpoint), req)
+}
+
+// List pages through the aliases a domain's provider declares.
+func (c *aliasProviderClient) List(ctx context.Context, domainCfg AliasDomainConfig) ([]*cryptaliasv1.ProvidedAlias, error) {
+	provider := domainCfg.AliasProvider
+	if provider == nil {
+		return nil, nil
+	}
+	endpoint := provider.endpoint()
+	wc, release, err := c.grpc.connFor(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx = withEndpointAuth(ctx, endpoint)
+	var (
+		out   []*cryptaliasv1.ProvidedAlias
+		token string
+	)
+	for {
+		resp, err := wc.aliases.ListAliases(ctx, &cryptaliasv1.ListAliasesRequest{Domain: domainCfg.Domain, PageToken: token})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, resp.GetAliases()...)
+		if token = resp.GetNextPageToken(); token == "" {
+			return out, nil
+		}
+	}
+}
+
+func (c *aliasProviderClient) store(key string, entry providedAliasEntry, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= maxAliasProviderCacheEntries {
+		for k, e := range c.cache {
+			if !now.Before(e.expires) {
+				delete(c.cache, k)
+			}
+		}
+	}
+	if len(c.cache) < maxAliasProviderCacheEntries {
+		c.cache[key] = entry
+	}
+}
+
+// providedWallet converts a provider answer into the wallet config form used
+// for aliases in config.
+func providedWallet(a *cryptaliasv1.ProvidedAlias, ticker string) WalletAddress {
+	w := WalletAddress{
+		Ticker:         ticker,
+		Address:        a.GetAddress(),
+		AccountIndex:   a.AccountIndex,
+		AccountID:      a.AccountId,
+		WalletID:       a.WalletId,
+		AddressMode:    a.AddressMode,
+		Memo:           a.Memo,
+		DestinationTag: a.DestinationTag,
+		PaymentID:      a.PaymentId,
+	}
+	if w.AddressMode != nil {
+		w.AddressMode = proto.String(strings.ToLower(strings.TrimSpace(*w.AddressMode)))
+	}
+	normalizeWalletAddress(&w)
+	return w
+}
+
+// validateProvidedWallet applies the static alias checks to a provider answer.
+func validateProvidedWallet(cfg *Config, w WalletAddress) error {
+	if err := validateDestinationFields(w); err != nil {
+		return err
+	}
+	if w.Address == "" {
+		return nil
+	}
+	token, err := findTokenConfig(cfg, w.Ticker)
+	if err != nil {
+		token = TokenConfig{}
+	}
+	return validateTokenAddress(token, w.Ticker, w.Address)
+}
@@ -0,0 +1,83 @@
+package cryptalias
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// directoryProvider serves a fixed set of aliases and counts lookups.
+type directoryProvider struct {
+	cryptaliasv1.UnimplementedAliasProviderServer
+	mu      sync.Mutex
+	aliases map[string]*cryptaliasv1.ProvidedAlias
+	lookups int
+}
+
+func (p *directoryProvider) LookupAlias(_ context.Context, req *cryptaliasv1.LookupAliasRequest) (*cryptaliasv1.LookupAliasResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups++
+	a, ok := p.aliases[req.GetAlias()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no such user")
+	}
+	return &cryptaliasv1.LookupAliasResponse{Found: true, Alias: a}, nil
+}
+
+func TestResolveAliasConsultsAliasProvider(t *testing.T) {
+	provider := &directoryProvider{aliases: map[string]*cryptaliasv1.ProvidedAlias{
+		"alice": {Alias: "alice", Ticker: "xmr", Enabled: true, WalletId: proto.String("alice")},
+		"bob":   {Alias: "bob", Ticker: "xmr", Enabled: true, Address: testMoneroAddress(t, 3)},
+		"carol": {Alias: "carol", Ticker: "xmr", Address: testMoneroAddress(t, 4)},
+	}}
+	addr := serveWalletService(t, func(s *grpc.Server) { cryptaliasv1.RegisterAliasProviderServer(s, provider) })
+
+	var last dynamicAliasInput
+	resolver := newBreakerTestResolver(t, func(_ context.Context, _ TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		last = in
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, 5)}, nil
+	})
+	cfg := testConfig(t)
+	cfg.Tokens = breakerTestConfig(TokenEndpointConfig{}).Tokens
+	cfg.Domains[0].AliasProvider = &AliasProviderConfig{Address: addr}
+	cfg.Normalize("")
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	// Aliases in config still win without asking the provider.
+	if _, err := ResolveAlias(context.Background(), "demo$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver); err != nil || provider.lookups != 0 {
+		t.Fatalf("expected the config alias without a lookup, got %v after %d lookups", err, provider.lookups)
+	}
+
+	alice, err := ResolveAlias(context.Background(), "alice$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver)
+	if err != nil || alice.Wallet.Address != testMoneroAddress(t, 5) || last.WalletID == nil || *last.WalletID != "alice" {
+		t.Fatalf("expected a dynamic address routed by the provider's hints, got %+v %v (input %+v)", alice.Wallet, err, last)
+	}
+	bob, err := ResolveAlias(context.Background(), "bob$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver)
+	if err != nil || bob.Wallet.Address != testMoneroAddress(t, 3) {
+		t.Fatalf("expected the provider's static address, got %+v %v", bob.Wallet, err)
+	}
+	for _, name := range []string{"carol", "dave"} {
+		if _, err := ResolveAlias(context.Background(), name+"$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver); !errors.Is(err, ErrAliasNotFound) {
+			t.Fatalf("expected %s to be unknown, got %v", name, err)
+		}
+	}
+
+	// Answers, including "not found", are cached.
+	lookups := provider.lookups
+	for _, name := range []string{"alice", "dave"} {
+		_, _ = ResolveAlias(context.Background(), name+"$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg), resolver)
+	}
+	if provider.lookups != lookups {
+		t.Fatalf("expected cached answers, got %d more lookups", provider.lookups-lookups)
+	}
+}
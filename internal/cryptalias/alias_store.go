@@ -0,0 +1,259 @@
+package cryptalias
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	AliasStoreTypeYAML   = "yaml"
+	AliasStoreTypeSQLite = "sqlite"
+)
+
+// AliasStoreConfig selects the alias storage backend.
+type AliasStoreConfig struct {
+	// Type is yaml (domains[].aliases in this file, default) or sqlite.
+	Type string `yaml:"type,omitempty"`
+	// Path is the SQLite database file, relative to the config file's
+	// directory; defaults to <config>.aliases.db.
+	Path string `yaml:"path,omitempty"`
+}
+
+func (a AliasStoreConfig) PathOrDefault(configPath string) string {
+	if a.Path == "" {
+		return configPath + ".aliases.db"
+	}
+	if filepath.IsAbs(a.Path) {
+		return a.Path
+	}
+	return filepath.Join(filepath.Dir(configPath), a.Path)
+}
+
+// errRestartRequired rejects config changes that only take effect at startup.
+var errRestartRequired = errors.New("alias_store changes require a restart")
+
+// sameAliasStore reports whether two configs of the file at configPath use
+// the same alias store.
+func sameAliasStore(a, b *Config, configPath string) bool {
+	x, y := a.AliasStore, b.AliasStore
+	if x.Type == "" {
+		x.Type = AliasStoreTypeYAML
+	}
+	if y.Type == "" {
+		y.Type = AliasStoreTypeYAML
+	}
+	if x.Type != y.Type {
+		return false
+	}
+	return x.Type != AliasStoreTypeSQLite || x.PathOrDefault(configPath) == y.PathOrDefault(configPath)
+}
+
+// AliasStore holds the aliases of the configured domains. An alias may have
+// one entry per ticker of its root wallet; tags belong to an entry.
+type AliasStore interface {
+	// FindWallet returns the wallet for alias+tag on ticker. A tag without
+	// its own wallet for the ticker falls back to the root alias.
+	FindWallet(domain, alias, tag, ticker string) (WalletAddress, bool, error)
+	// FindLightning returns the entry of an alias that has a lightning block.
+	FindLightning(domain, alias string) (WalletAlias, bool, error)
+	ListAliases(domain string) ([]WalletAlias, error)
+	// PutAlias creates or replaces the entry with the alias name and root
+	// wallet ticker.
+	PutAlias(domain string, alias WalletAlias) error
+	// DeleteAlias removes an alias's entry for ticker, or all of its entries
+	// when ticker is empty.
+	DeleteAlias(domain, alias, ticker string) error
+}
+
+type aliasWalletKey struct {
+	domain, alias, tag, ticker string
+}
+
+type aliasNameKey struct {
+	domain, alias string
+}
+
+// aliasIndex answers alias lookups for a config snapshot without scanning
+// domains[].aliases.
+type aliasIndex struct {
+	wallets   map[aliasWalletKey]WalletAddress
+	lightning map[aliasNameKey]WalletAlias
+	domains   map[string][]WalletAlias
+}
+
+func newAliasIndex(cfg *Config) *aliasIndex {
+	ix := &aliasIndex{
+		wallets:   map[aliasWalletKey]WalletAddress{},
+		lightning: map[aliasNameKey]WalletAlias{},
+		domains:   map[string][]WalletAlias{},
+	}
+	if cfg == nil {
+		return ix
+	}
+	// The first definition wins, as with the linear scan it replaces.
+	add := func(k aliasWalletKey, w WalletAddress) {
+		if _, ok := ix.wallets[k]; !ok {
+			ix.wallets[k] = w
+		}
+	}
+	for _, d := range cfg.Domains {
+		ix.domains[d.Domain] = d.Aliases
+		for _, a := range d.Aliases {
+			add(aliasWalletKey{d.Domain, a.Alias, "", a.Wallet.Ticker}, a.Wallet)
+			for _, t := range a.Tags {
+				add(aliasWalletKey{d.Domain, a.Alias, t.Tag, t.Wallet.Ticker}, t.Wallet)
+			}
+			name := aliasNameKey{d.Domain, a.Alias}
+			if _, ok := ix.lightning[name]; !ok && a.Lightning != nil {
+				ix.lightning[name] = a
+			}
+		}
+	}
+	return ix
+}
+
+func (ix *aliasIndex) findWallet(domain, alias, tag, ticker string) (WalletAddress, bool) {
+	if tag != "" {
+		if w, ok := ix.wallets[aliasWalletKey{domain, alias, tag, ticker}]; ok {
+			return w, true
+		}
+	}
+	w, ok := ix.wallets[aliasWalletKey{domain, alias, "", ticker}]
+	return w, ok
+}
+
+func (ix *aliasIndex) findLightning(domain, alias string) (WalletAlias, bool) {
+	a, ok := ix.lightning[aliasNameKey{domain, alias}]
+	return a, ok
+}
+
+// ConfigStore implements AliasStore with domains[].aliases; writes rewrite
+// the config file.
+
+func (s *ConfigStore) FindWallet(domain, alias, tag, ticker string) (WalletAddress, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	w, ok := s.index.findWallet(domain, alias, tag, ticker)
+	return w, ok, nil
+}
+
+func (s *ConfigStore) FindLightning(domain, alias string) (WalletAlias, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	a, ok := s.index.findLightning(domain, alias)
+	return a, ok, nil
+}
+
+func (s *ConfigStore) ListAliases(domain string) ([]WalletAlias, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := append([]WalletAlias(nil), s.index.domains[domain]...)
+	// Copy tags so callers can edit an entry without touching the config.
+	for i := range out {
+		out[i].Tags = append([]WalletTag(nil), out[i].Tags...)
+	}
+	return out, nil
+}
+
+func (s *ConfigStore) PutAlias(domain string, alias WalletAlias) error {
+	normalizeWalletAlias(&alias)
+	return s.Update(func(cfg *Config) error {
+		d, err := configDomain(cfg, domain)
+		if err != nil {
+			return err
+		}
+		for i, a := range d.Aliases {
+			if a.Alias == alias.Alias && a.Wallet.Ticker == alias.Wallet.Ticker {
+				d.Aliases[i] = alias
+				return nil
+			}
+		}
+		d.Aliases = append(d.Aliases, alias)
+		return nil
+	})
+}
+
+func (s *ConfigStore) DeleteAlias(domain, alias, ticker string) error {
+	alias, ticker = strings.ToLower(alias), strings.ToLower(ticker)
+	return s.Update(func(cfg *Config) error {
+		d, err := configDomain(cfg, domain)
+		if err != nil {
+			return err
+		}
+		kept := d.Aliases[:0]
+		for _, a := range d.Aliases {
+			if a.Alias == alias && (ticker == "" || a.Wallet.Ticker == ticker) {
+				continue
+			}
+			kept = append(kept, a)
+		}
+		if len(kept) == len(d.Aliases) {
+			return ErrAliasNotFound
+		}
+		d.Aliases = kept
+		return nil
+	})
+}
+
+// openAliasStore attaches the SQLite alias store to store when the config
+// selects it. The returned store is nil for YAML aliases.
+func openAliasStore(store *ConfigStore) (*SQLiteAliasStore, error) {
+	alias := store.Get().AliasStore
+	if alias.Type != AliasStoreTypeSQLite {
+		return nil, nil
+	}
+	db, err := OpenSQLiteAliasStore(alias.PathOrDefault(store.path), store)
+	if err != nil {
+		return nil, err
+	}
+	store.UseAliasStore(db)
+	return db, nil
+}
+
+// dropDomainAliases removes a deleted domain's aliases from a separate alias
+// store; YAML aliases go with the domain.
+func dropDomainAliases(store *ConfigStore, domain string) {
+	aliases := store.Aliases()
+	if aliases == AliasStore(store) {
+		return
+	}
+	list, err := aliases.ListAliases(domain)
+	if err != nil {
+		slog.Error("alias cleanup failed", "domain", domain, "error", err)
+		return
+	}
+	for _, a := range list {
+		if err := aliases.DeleteAlias(domain, a.Alias, a.Wallet.Ticker); err != nil && !errors.Is(err, ErrAliasNotFound) {
+			slog.Error("alias cleanup failed", "domain", domain, "alias", a.Alias, "error", err)
+		}
+	}
+}
+
+// configDomain returns the domain entry of cfg for in-place edits.
+func configDomain(cfg *Config, domain string) (*AliasDomainConfig, error) {
+	domain = strings.ToLower(domain)
+	for i := range cfg.Domains {
+		if cfg.Domains[i].Domain == domain {
+			return &cfg.Domains[i], nil
+		}
+	}
+	return nil, fmt.Errorf("domain %q is not configured", domain)
+}
+
+// ImportAliases copies every alias in domains[].aliases into dst and returns
+// how many entries were written.
+func ImportAliases(cfg *Config, dst AliasStore) (int, error) {
+	n := 0
+	for _, d := range cfg.Domains {
+		for _, a := range d.Aliases {
+			if err := dst.PutAlias(d.Domain, a); err != nil {
+				return n, fmt.Errorf("import %s$%s: %w", a.Alias, d.Domain, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
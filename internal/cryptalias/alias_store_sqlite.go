@@ -0,0 +1,267 @@
+package cryptalias
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+const sqliteAliasSchema = `
+CREATE TABLE IF NOT EXISTS aliases (
+	domain TEXT NOT NULL,
+	alias  TEXT NOT NULL,
+	ticker TEXT NOT NULL,
+	entry  TEXT NOT NULL,
+	PRIMARY KEY (domain, alias, ticker)
+);
+CREATE TABLE IF NOT EXISTS alias_wallets (
+	domain TEXT NOT NULL,
+	alias  TEXT NOT NULL,
+	tag    TEXT NOT NULL,
+	ticker TEXT NOT NULL,
+	owner  TEXT NOT NULL,
+	wallet TEXT NOT NULL,
+	PRIMARY KEY (domain, alias, tag, ticker)
+);
+CREATE INDEX IF NOT EXISTS alias_wallets_owner ON alias_wallets (domain, alias, owner);
+`
+
+// SQLiteAliasStore keeps aliases in an embedded SQLite database. Each entry
+// is stored whole in aliases, and its root and tag wallets are indexed in
+// alias_wallets for lookups.
+type SQLiteAliasStore struct {
+	db     *sql.DB
+	config *ConfigStore
+}
+
+// OpenSQLiteAliasStore opens (creating if needed) the database at path.
+// Aliases are validated against the tokens and domains of config.
+func OpenSQLiteAliasStore(path string, config *ConfigStore) (*SQLiteAliasStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(sqliteAliasSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("alias store %s: %w", path, err)
+	}
+	_ = os.Chmod(path, 0o600)
+	slog.Info("sqlite alias store opened", "path", path)
+	return &SQLiteAliasStore{db: db, config: config}, nil
+}
+
+func (s *SQLiteAliasStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteAliasStore) FindWallet(domain, alias, tag, ticker string) (WalletAddress, bool, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT wallet FROM alias_wallets
+		WHERE domain = ? AND alias = ? AND ticker = ? AND tag IN (?, '')
+		ORDER BY tag = '' LIMIT 1`, domain, alias, ticker, tag).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WalletAddress{}, false, nil
+	}
+	if err != nil {
+		return WalletAddress{}, false, err
+	}
+	var w WalletAddress
+	if err := json.Unmarshal([]byte(raw), &w); err != nil {
+		return WalletAddress{}, false, err
+	}
+	return w, true, nil
+}
+
+func (s *SQLiteAliasStore) FindLightning(domain, alias string) (WalletAlias, bool, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT entry FROM aliases
+		WHERE domain = ? AND alias = ? AND json_extract(entry, '$.lightning') IS NOT NULL
+		ORDER BY ticker LIMIT 1`, domain, alias).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WalletAlias{}, false, nil
+	}
+	if err != nil {
+		return WalletAlias{}, false, err
+	}
+	var a WalletAlias
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		return WalletAlias{}, false, err
+	}
+	return a, true, nil
+}
+
+func (s *SQLiteAliasStore) ListAliases(domain string) ([]WalletAlias, error) {
+	rows, err := s.db.Query(`SELECT entry FROM aliases WHERE domain = ? ORDER BY alias, ticker`, domain)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []WalletAlias
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return nil, err
+		}
+		var a WalletAlias
+		if err := json.Unmarshal([]byte(raw), &a); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteAliasStore) PutAlias(domain string, alias WalletAlias) error {
+	normalizeWalletAlias(&alias)
+	cfg := s.config.Get()
+	d, err := configDomain(cfg, domain)
+	if err != nil {
+		return err
+	}
+	domain = d.Domain
+	if err := cfg.validateAlias("alias", alias); err != nil {
+		return err
+	}
+	entry, err := json.Marshal(alias)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	owner := alias.Wallet.Ticker
+	if _, err := tx.Exec(`DELETE FROM alias_wallets WHERE domain = ? AND alias = ? AND owner = ?`, domain, alias.Alias, owner); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO aliases (domain, alias, ticker, entry) VALUES (?, ?, ?, ?)
+		ON CONFLICT (domain, alias, ticker) DO UPDATE SET entry = excluded.entry`, domain, alias.Alias, owner, string(entry)); err != nil {
+		return err
+	}
+	put := func(tag string, w WalletAddress) error {
+		raw, err := json.Marshal(w)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO alias_wallets (domain, alias, tag, ticker, owner, wallet) VALUES (?, ?, ?, ?, ?, ?)`,
+			domain, alias.Alias, tag, w.Ticker, owner, string(raw))
+		if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			name := alias.Alias
+			if tag != "" {
+				name += "+" + tag
+			}
+			return fmt.Errorf("%s$%s already has a %s wallet", name, domain, w.Ticker)
+		}
+		return err
+	}
+	if err := put("", alias.Wallet); err != nil {
+		return err
+	}
+	for _, t := range alias.Tags {
+		if err := put(t.Tag, t.Wallet); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteAliasStore) DeleteAlias(domain, alias, ticker string) error {
+	domain, alias, ticker = strings.ToLower(domain), strings.ToLower(alias), strings.ToLower(ticker)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	// An empty ticker matches every entry of the alias.
+	res, err := tx.Exec(`DELETE FROM aliases WHERE domain = ? AND alias = ? AND (? = '' OR ticker = ?)`, domain, alias, ticker, ticker)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrAliasNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM alias_wallets WHERE domain = ? AND alias = ? AND (? = '' OR owner = ?)`, domain, alias, ticker, ticker); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MigrateAliasesToSQLite moves domains[].aliases of the config at configPath
+// into the SQLite database at dbPath (default <config>.aliases.db) and
+// switches the config to alias_store.type sqlite. The original config is
+// kept as <config>.bak. It returns the number of entries imported.
+func MigrateAliasesToSQLite(configPath, dbPath string) (int, error) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return 0, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return 0, err
+	}
+	if cfg.AliasStore.Type == AliasStoreTypeSQLite {
+		return 0, fmt.Errorf("%s already uses the sqlite alias store", configPath)
+	}
+	if dbPath == "" {
+		dbPath = cfg.AliasStore.PathOrDefault(configPath)
+	}
+
+	db, err := OpenSQLiteAliasStore(dbPath, NewConfigStore(configPath, cfg))
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	n, err := ImportAliases(cfg, db)
+	if err != nil {
+		return n, err
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return n, err
+	}
+	if err := os.WriteFile(configPath+".bak", original, 0o600); err != nil {
+		return n, err
+	}
+	next := cfg.Clone()
+	for i := range next.Domains {
+		next.Domains[i].Aliases = nil
+	}
+	next.AliasStore = AliasStoreConfig{Type: AliasStoreTypeSQLite, Path: aliasStorePath(configPath, dbPath)}
+	if err := SaveConfig(configPath, next); err != nil {
+		return n, err
+	}
+	slog.Info("aliases migrated to sqlite", "config", configPath, "db", dbPath, "aliases", n)
+	return n, nil
+}
+
+// aliasStorePath returns the alias_store.path to save for a database opened
+// at dbPath: empty for the default, otherwise relative to the config file's
+// directory when possible.
+func aliasStorePath(configPath, dbPath string) string {
+	if filepath.Clean(dbPath) == filepath.Clean(configPath+".aliases.db") {
+		return ""
+	}
+	abs, err := filepath.Abs(dbPath)
+	if err != nil {
+		return dbPath
+	}
+	dir, err := filepath.Abs(filepath.Dir(configPath))
+	if err != nil {
+		return abs
+	}
+	if rel, err := filepath.Rel(dir, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return abs
+}
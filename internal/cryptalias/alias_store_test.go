@@ -0,0 +1,128 @@
+package cryptalias
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSQLiteAliasStore(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Normalize("")
+	dir := t.TempDir()
+	db, err := OpenSQLiteAliasStore(filepath.Join(dir, "aliases.db"), NewConfigStore(filepath.Join(dir, "config.yml"), cfg))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	if n, err := ImportAliases(cfg, db); err != nil || n != 1 {
+		t.Fatalf("import: %d %v", n, err)
+	}
+	alias, err := ResolveAlias(context.Background(), "demo+tip$127.0.0.1", "xmr", cfg, db, nil)
+	if err != nil || alias.Wallet.Address != testMoneroAddress(t, 2) {
+		t.Fatalf("expected the tag wallet, got %+v %v", alias.Wallet, err)
+	}
+	// Unknown tags fall back to the root wallet.
+	if w, ok, err := db.FindWallet("127.0.0.1", "demo", "other", "xmr"); err != nil || !ok || w.Address != testMoneroAddress(t, 1) {
+		t.Fatalf("expected the root wallet, got %+v %v %v", w, ok, err)
+	}
+
+	err = db.PutAlias("127.0.0.1", WalletAlias{Alias: "Shop", Wallet: WalletAddress{Ticker: "XMR", Address: "not-an-address"}})
+	if err == nil {
+		t.Fatalf("expected an invalid address to be rejected")
+	}
+	if err := db.PutAlias("example.com", WalletAlias{Alias: "shop", Wallet: WalletAddress{Ticker: "xmr"}}); err == nil {
+		t.Fatalf("expected an unknown domain to be rejected")
+	}
+	// Replacing an entry drops the tags it no longer has.
+	if err := db.PutAlias("127.0.0.1", WalletAlias{Alias: "demo", Wallet: WalletAddress{Ticker: "xmr", Address: testMoneroAddress(t, 3)}}); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if w, _, _ := db.FindWallet("127.0.0.1", "demo", "tip", "xmr"); w.Address != testMoneroAddress(t, 3) {
+		t.Fatalf("expected the replaced root wallet, got %+v", w)
+	}
+	if list, err := db.ListAliases("127.0.0.1"); err != nil || len(list) != 1 || len(list[0].Tags) != 0 {
+		t.Fatalf("unexpected aliases %+v %v", list, err)
+	}
+	// ParseAlias reads the same store, not the aliases left in config.
+	if alias, err := ParseAlias("demo+tip$127.0.0.1", "xmr", cfg, db); err != nil || alias.Wallet.Address != testMoneroAddress(t, 3) {
+		t.Fatalf("expected the stored wallet, got %+v %v", alias.Wallet, err)
+	}
+
+	if err := db.DeleteAlias("127.0.0.1", "demo", ""); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok, _ := db.FindWallet("127.0.0.1", "demo", "", "xmr"); ok {
+		t.Fatalf("expected the alias to be gone")
+	}
+	if err := db.DeleteAlias("127.0.0.1", "demo", ""); !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("expected ErrAliasNotFound, got %v", err)
+	}
+}
+
+func TestMigrateAliasesToSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := SaveConfig(path, testConfig(t)); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	running, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	store := NewConfigStore(path, running)
+
+	n, err := MigrateAliasesToSQLite(path, "")
+	if err != nil || n != 1 {
+		t.Fatalf("migrate: %d %v", n, err)
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Fatalf("expected a backup: %v", err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if cfg.AliasStore.Type != AliasStoreTypeSQLite || cfg.AliasStore.Path != "" || len(cfg.Domains[0].Aliases) != 0 {
+		t.Fatalf("expected the config to switch to the default sqlite store, got %+v", cfg.AliasStore)
+	}
+	// A running server keeps its YAML aliases until it is restarted.
+	if err := store.Set(cfg.Clone()); !errors.Is(err, errRestartRequired) {
+		t.Fatalf("expected the reload to require a restart, got %v", err)
+	}
+	if _, ok, _ := store.Aliases().FindWallet("127.0.0.1", "demo", "", "xmr"); !ok {
+		t.Fatalf("expected the running store to keep serving its aliases")
+	}
+
+	db, err := OpenSQLiteAliasStore(cfg.AliasStore.PathOrDefault(path), NewConfigStore(path, cfg))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+	if w, ok, err := db.FindWallet("127.0.0.1", "demo", "tip", "xmr"); err != nil || !ok || w.Address != testMoneroAddress(t, 2) {
+		t.Fatalf("expected migrated tag wallet, got %+v %v %v", w, ok, err)
+	}
+	if _, err := MigrateAliasesToSQLite(path, ""); err == nil {
+		t.Fatalf("expected a second migration to be refused")
+	}
+}
+
+func TestAliasStorePathIsRelativeToConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	if got := aliasStorePath(path, filepath.Join(dir, "data", "aliases.db")); got != filepath.Join("data", "aliases.db") {
+		t.Fatalf("expected a path relative to the config, got %q", got)
+	}
+	if got := aliasStorePath(path, path+".aliases.db"); got != "" {
+		t.Fatalf("expected the default path to be left empty, got %q", got)
+	}
+	store := AliasStoreConfig{Type: AliasStoreTypeSQLite, Path: filepath.Join("data", "aliases.db")}
+	if got := store.PathOrDefault(path); got != filepath.Join(dir, "data", "aliases.db") {
+		t.Fatalf("expected the path to resolve next to the config, got %q", got)
+	}
+}
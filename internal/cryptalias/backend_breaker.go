@@ -0,0 +1,234 @@
+package cryptalias
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultBackendTimeoutSeconds   = 15
+	defaultBackendRetries          = 1
+	defaultBackendRetryBackoffMs   = 200
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCooldownSeconds  = 30
+)
+
+type BackendState string
+
+const (
+	BackendStateClosed   BackendState = "closed"
+	BackendStateOpen     BackendState = "open"
+	BackendStateHalfOpen BackendState = "half_open"
+)
+
+// BreakerConfig tunes the per-token circuit breaker.
+type BreakerConfig struct {
+	Disabled bool `yaml:"disabled,omitempty"`
+	// FailureThreshold is the number of consecutive failed calls that opens
+	// the breaker.
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+	// CooldownSeconds is how long an open breaker fails fast before a probe.
+	CooldownSeconds int `yaml:"cooldown_seconds,omitempty"`
+}
+
+func (b BreakerConfig) thresholdOrDefault() int {
+	if b.FailureThreshold <= 0 {
+		return defaultBreakerFailureThreshold
+	}
+	return b.FailureThreshold
+}
+
+func (b BreakerConfig) cooldownOrDefault() time.Duration {
+	if b.CooldownSeconds <= 0 {
+		return defaultBreakerCooldownSeconds * time.Second
+	}
+	return time.Duration(b.CooldownSeconds) * time.Second
+}
+
+// BackendUnavailableError is returned without calling the backend while its
+// breaker is open. Handlers map it to 503 with Retry-After.
+type BackendUnavailableError struct {
+	Token      string
+	RetryAfter time.Duration
+}
+
+func (e *BackendUnavailableError) Error() string {
+	return fmt.Sprintf("wallet backend for %s is unavailable, retry in %s", e.Token, e.RetryAfter.Round(time.Second))
+}
+
+// retryAfterSeconds formats a Retry-After header value, rounding up.
+func retryAfterSeconds(d time.Duration) string {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return strconv.Itoa(secs)
+}
+
+// errRetryableBackend marks transport failures where the request did not
+// reach the wallet, so retrying cannot issue a duplicate address.
+var errRetryableBackend = errors.New("wallet backend temporarily unavailable")
+
+// errBackendTimeout wraps calls that hit the token's timeout_seconds.
+var errBackendTimeout = errors.New("wallet backend timed out")
+
+func retryableBackendError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, errRetryableBackend) {
+		return true
+	}
+	if status.Code(err) == codes.Unavailable {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// breakerFailure reports whether err says the backend itself is failing.
+// Errors about the request, such as an unknown wallet_id or a rejected
+// address, must not let clients open the breaker for everyone.
+func breakerFailure(err error) bool {
+	if errors.Is(err, errBackendTimeout) || retryableBackendError(err) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// BackendStatus is the breaker state reported on the status endpoints.
+type BackendStatus struct {
+	Token               string       `json:"token"`
+	State               BackendState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+	RetryAt             *time.Time   `json:"retry_at,omitempty"`
+}
+
+// BackendBreakers tracks one circuit breaker per token.
+type BackendBreakers struct {
+	mu       sync.Mutex
+	breakers map[string]*backendBreaker
+}
+
+type backendBreaker struct {
+	failures int
+	openedAt time.Time
+	retryAt  time.Time
+	// probing is set while a half-open trial call is in flight.
+	probing bool
+}
+
+func NewBackendBreakers() *BackendBreakers {
+	return &BackendBreakers{breakers: map[string]*backendBreaker{}}
+}
+
+// allow reports whether a call may proceed. Once the cooldown has passed a
+// single probe call is let through; its result closes or re-opens the breaker.
+func (b *BackendBreakers) allow(token TokenConfig, now time.Time) error {
+	if b == nil || token.Endpoint.Breaker.Disabled {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.breakers[token.Name]
+	if !ok || br.openedAt.IsZero() {
+		return nil
+	}
+	if now.Before(br.retryAt) || br.probing {
+		retryAfter := br.retryAt.Sub(now)
+		if retryAfter < time.Second {
+			retryAfter = time.Second
+		}
+		return &BackendUnavailableError{Token: token.Name, RetryAfter: retryAfter}
+	}
+	br.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a call. Errors that are not
+// backend failures count as successes.
+func (b *BackendBreakers) record(token TokenConfig, err error, now time.Time) {
+	if b == nil || token.Endpoint.Breaker.Disabled {
+		return
+	}
+	if !breakerFailure(err) {
+		err = nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	br, ok := b.breakers[token.Name]
+	if !ok {
+		br = &backendBreaker{}
+		b.breakers[token.Name] = br
+	}
+	br.probing = false
+	if err == nil {
+		*br = backendBreaker{}
+		return
+	}
+	br.failures++
+	if !br.openedAt.IsZero() || br.failures >= token.Endpoint.Breaker.thresholdOrDefault() {
+		if br.openedAt.IsZero() {
+			br.openedAt = now
+		}
+		br.retryAt = now.Add(token.Endpoint.Breaker.cooldownOrDefault())
+	}
+}
+
+// abort ends a half-open probe without judging the backend, e.g. when the
+// client disconnected mid-call.
+func (b *BackendBreakers) abort(token TokenConfig) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if br, ok := b.breakers[token.Name]; ok {
+		br.probing = false
+	}
+}
+
+// List returns breaker state for every token that has been called.
+func (b *BackendBreakers) List() []BackendStatus {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	out := make([]BackendStatus, 0, len(b.breakers))
+	for name, br := range b.breakers {
+		s := BackendStatus{
+			Token:               name,
+			State:               BackendStateClosed,
+			ConsecutiveFailures: br.failures,
+		}
+		if !br.openedAt.IsZero() {
+			openedAt, retryAt := br.openedAt.UTC(), br.retryAt.UTC()
+			s.OpenedAt, s.RetryAt = &openedAt, &retryAt
+			s.State = BackendStateOpen
+			if br.probing || !now.Before(br.retryAt) {
+				s.State = BackendStateHalfOpen
+			}
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Token < out[j].Token })
+	return out
+}
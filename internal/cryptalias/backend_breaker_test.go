@@ -0,0 +1,164 @@
+package cryptalias
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func breakerTestConfig(endpoint TokenEndpointConfig) *Config {
+	endpoint.EndpointType = TokenEndpointTypeInternal
+	endpoint.EndpointAddress = "internal"
+	cfg := &Config{Tokens: []TokenConfig{{Name: "Monero", Tickers: []string{"xmr"}, Endpoint: endpoint}}}
+	cfg.Normalize("")
+	return cfg
+}
+
+func newBreakerTestResolver(t *testing.T, internalFn walletBackendFunc) *WalletResolver {
+	t.Helper()
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	return newWalletResolverWithDeps(state, internalFn, nil)
+}
+
+func TestWalletResolverRetriesUnavailableBackend(t *testing.T) {
+	calls := 0
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		if calls == 1 {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, 1)}, nil
+	}
+	resolver := newBreakerTestResolver(t, internalFn)
+	cfg := breakerTestConfig(TokenEndpointConfig{RetryBackoffMs: 1})
+
+	if _, err := resolver.Resolve(context.Background(), cfg, dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}); err != nil {
+		t.Fatalf("expected retry to succeed, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestWalletResolverTimeoutOpensBreaker(t *testing.T) {
+	calls := 0
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	resolver := newBreakerTestResolver(t, internalFn)
+	cfg := breakerTestConfig(TokenEndpointConfig{
+		TimeoutSeconds: 1,
+		Breaker:        BreakerConfig{FailureThreshold: 1, CooldownSeconds: 60},
+	})
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	start := time.Now()
+	_, err := resolver.Resolve(context.Background(), cfg, in)
+	if !errors.Is(err, errBackendTimeout) {
+		t.Fatalf("expected backend timeout, got %v", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("timeout_seconds was not enforced")
+	}
+
+	_, err = resolver.Resolve(context.Background(), cfg, in)
+	var unavailable *BackendUnavailableError
+	if !errors.As(err, &unavailable) {
+		t.Fatalf("expected breaker to fail fast, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the open breaker to skip the backend, got %d calls", calls)
+	}
+	statuses := resolver.BackendStatuses()
+	if len(statuses) != 1 || statuses[0].State != BackendStateOpen {
+		t.Fatalf("expected open breaker in status, got %+v", statuses)
+	}
+}
+
+func TestWalletResolverRequestErrorsKeepBreakerClosed(t *testing.T) {
+	calls := 0
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		return nil, status.Error(codes.InvalidArgument, "unknown wallet_id")
+	}
+	resolver := newBreakerTestResolver(t, internalFn)
+	cfg := breakerTestConfig(TokenEndpointConfig{Breaker: BreakerConfig{FailureThreshold: 2}})
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	for i := 0; i < 5; i++ {
+		var unavailable *BackendUnavailableError
+		if _, err := resolver.Resolve(context.Background(), cfg, in); err == nil || errors.As(err, &unavailable) {
+			t.Fatalf("request %d: expected the backend's own error, got %v", i, err)
+		}
+	}
+	if calls != 5 {
+		t.Fatalf("expected every request to reach the backend, got %d calls", calls)
+	}
+	if got := resolver.BackendStatuses(); len(got) != 1 || got[0].State != BackendStateClosed || got[0].ConsecutiveFailures != 0 {
+		t.Fatalf("expected a closed breaker, got %+v", got)
+	}
+}
+
+func TestBackendBreakersHalfOpenProbe(t *testing.T) {
+	b := NewBackendBreakers()
+	token := TokenConfig{Name: "Monero", Endpoint: TokenEndpointConfig{Breaker: BreakerConfig{FailureThreshold: 2, CooldownSeconds: 10}}}
+	now := time.Now()
+	fail := status.Error(codes.Unavailable, "boom")
+
+	b.record(token, fail, now)
+	if err := b.allow(token, now); err != nil {
+		t.Fatalf("breaker opened below threshold: %v", err)
+	}
+	b.record(token, fail, now)
+	if err := b.allow(token, now.Add(5*time.Second)); err == nil {
+		t.Fatalf("expected open breaker during cooldown")
+	}
+
+	later := now.Add(11 * time.Second)
+	if err := b.allow(token, later); err != nil {
+		t.Fatalf("expected a probe after cooldown: %v", err)
+	}
+	if err := b.allow(token, later); err == nil {
+		t.Fatalf("expected only one probe in flight")
+	}
+	b.record(token, nil, later)
+	if err := b.allow(token, later); err != nil {
+		t.Fatalf("expected successful probe to close the breaker: %v", err)
+	}
+}
+
+func TestAliasResolverHandlerReturnsRetryAfterWhenBreakerOpen(t *testing.T) {
+	store, resolver := newTestStore(t)
+	token := store.Get().Tokens[0]
+	for i := 0; i < defaultBreakerFailureThreshold; i++ {
+		resolver.breakers.record(token, errBackendTimeout, time.Now())
+	}
+
+	// Only dynamic aliases reach the backend.
+	req := httptest.NewRequest(http.MethodGet, "/_cryptalias/resolve/xmr/shop$127.0.0.1", nil)
+	req.SetPathValue("ticker", "xmr")
+	req.SetPathValue("alias", "shop$127.0.0.1")
+	rr := httptest.NewRecorder()
+
+	AliasResolverHandler(store, resolver, nil, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected Retry-After header")
+	}
+}
@@ -0,0 +1,187 @@
+package cryptalias
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+)
+
+const IntegrationBitcoind = "bitcoind"
+
+func init() {
+	registerInternalIntegration(IntegrationBitcoind, func() internalIntegration {
+		return newWalletTargetIntegration("bitcoind", bitcoindWalletEndpoint, func(ep TokenEndpointConfig) cryptaliasv1.WalletServiceServer {
+			return newBitcoindWalletService(ep)
+		})
+	})
+}
+
+// bitcoindAddressTypes are the getnewaddress address types Cryptalias accepts.
+var bitcoindAddressTypes = map[string]bool{
+	"legacy":      true,
+	"p2sh-segwit": true,
+	"bech32":      true,
+	"bech32m":     true,
+}
+
+// bitcoindWalletEndpoint selects the bitcoind wallet for a request. Named
+// wallets work as for Monero; otherwise wallet_id is the bitcoind wallet name.
+func bitcoindWalletEndpoint(endpoint TokenEndpointConfig, walletID *string) (TokenEndpointConfig, error) {
+	ep, err := namedWalletEndpoint(endpoint, walletID)
+	if err != nil {
+		return TokenEndpointConfig{}, err
+	}
+	if len(endpoint.Wallets) == 0 && walletID != nil && *walletID != "" {
+		ep.WalletFile = *walletID
+	}
+	return ep, nil
+}
+
+// bitcoindWalletService issues addresses through the Bitcoin Core JSON-RPC
+// getnewaddress call, which BTC, LTC, DOGE, BCH and most forks share.
+type bitcoindWalletService struct {
+	cryptaliasv1.UnimplementedWalletServiceServer
+	endpoint atomic.Value // TokenEndpointConfig
+	http     *http.Client
+}
+
+func newBitcoindWalletService(endpoint TokenEndpointConfig) *bitcoindWalletService {
+	s := &bitcoindWalletService{http: &http.Client{Timeout: 10 * time.Second}}
+	s.SetEndpoint(endpoint)
+	return s
+}
+
+func (s *bitcoindWalletService) SetEndpoint(endpoint TokenEndpointConfig) {
+	s.endpoint.Store(endpoint)
+}
+
+func (s *bitcoindWalletService) GetAddress(ctx context.Context, req *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+
+	// Older forks (DOGE, BCH) reject the address_type argument, so only send
+	// it when configured.
+	params := []any{walletAddressLabel(req)}
+	if ep.AddressType != "" {
+		params = append(params, ep.AddressType)
+	}
+
+	var addr string
+	if err := s.call(ctx, ep, "getnewaddress", params, &addr); err != nil {
+		return nil, err
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("bitcoind returned empty address")
+	}
+	return &cryptaliasv1.WalletAddressResponse{Address: addr}, nil
+}
+
+// Health calls getwalletinfo, which fails when the node is down or the
+// wallet is not loaded.
+func (s *bitcoindWalletService) Health(ctx context.Context, _ *cryptaliasv1.HealthRequest) (*cryptaliasv1.HealthResponse, error) {
+	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+	var info struct {
+		WalletName string `json:"walletname"`
+	}
+	if err := s.call(ctx, ep, "getwalletinfo", []any{}, &info); err != nil {
+		return &cryptaliasv1.HealthResponse{Ok: false, Message: err.Error()}, nil
+	}
+	return &cryptaliasv1.HealthResponse{Ok: true, Message: fmt.Sprintf("wallet %q loaded", info.WalletName)}, nil
+}
+
+type bitcoindRPCRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      string `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type bitcoindRPCResponse struct {
+	Result json.RawMessage   `json:"result"`
+	Error  *bitcoindRPCError `json:"error"`
+}
+
+type bitcoindRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *bitcoindRPCError) Error() string {
+	return fmt.Sprintf("bitcoind rpc error %d: %s", e.Code, e.Message)
+}
+
+func (s *bitcoindWalletService) call(ctx context.Context, ep TokenEndpointConfig, method string, params []any, out any) error {
+	body, err := json.Marshal(bitcoindRPCRequest{JSONRPC: "1.0", ID: "cryptalias", Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	target := strings.TrimRight(ep.EndpointAddress, "/")
+	if ep.WalletFile != "" {
+		// Multi-wallet nodes route wallet calls by path.
+		target += "/wallet/" + url.PathEscape(ep.WalletFile)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	user, password, err := bitcoindCredentials(ep)
+	if err != nil {
+		return err
+	}
+	if user != "" || password != "" {
+		httpReq.SetBasicAuth(user, password)
+	}
+
+	resp, err := s.http.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("bitcoind rejected credentials (http %d)", resp.StatusCode)
+	}
+
+	// bitcoind reports RPC errors with a non-200 status and a JSON body.
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	var rpcResp bitcoindRPCResponse
+	if err := json.Unmarshal(b, &rpcResp); err != nil {
+		return fmt.Errorf("bitcoind http %d: invalid response", resp.StatusCode)
+	}
+	if rpcResp.Error != nil {
+		return rpcResp.Error
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bitcoind http %d", resp.StatusCode)
+	}
+	return json.Unmarshal(rpcResp.Result, out)
+}
+
+// bitcoindCredentials returns rpcuser credentials, or reads the cookie file.
+// The cookie is re-read per call because bitcoind rotates it on restart.
+func bitcoindCredentials(ep TokenEndpointConfig) (string, string, error) {
+	if ep.CookieFile == "" {
+		return ep.Username, ep.Password, nil
+	}
+	b, err := os.ReadFile(ep.CookieFile)
+	if err != nil {
+		return "", "", fmt.Errorf("read bitcoind cookie: %w", err)
+	}
+	user, password, ok := strings.Cut(strings.TrimSpace(string(b)), ":")
+	if !ok {
+		return "", "", fmt.Errorf("bitcoind cookie file %s is malformed", ep.CookieFile)
+	}
+	return user, password, nil
+}
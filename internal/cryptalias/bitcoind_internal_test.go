@@ -0,0 +1,88 @@
+package cryptalias
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBitcoindIntegrationGetNewAddress(t *testing.T) {
+	var gotPath, gotUser, gotPass string
+	var gotReq bitcoindRPCRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, gotPass, _ = r.BasicAuth()
+		_ = json.NewDecoder(r.Body).Decode(&gotReq)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+			"error":  nil,
+			"id":     gotReq.ID,
+		})
+	}))
+	defer srv.Close()
+
+	cookie := filepath.Join(t.TempDir(), ".cookie")
+	if err := os.WriteFile(cookie, []byte("__cookie__:secret\n"), 0o600); err != nil {
+		t.Fatalf("write cookie: %v", err)
+	}
+	endpoint := TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeInternal,
+		EndpointAddress: srv.URL,
+		Integration:     IntegrationBitcoind,
+		CookieFile:      cookie,
+		AddressType:     "bech32",
+	}
+
+	integration := internalIntegrations[IntegrationBitcoind]()
+	client, err := integration.Client(endpoint, proto.String("business"))
+	if err != nil {
+		t.Fatalf("bitcoind client: %v", err)
+	}
+	resp, err := client.GetAddress(context.Background(), &cryptaliasv1.WalletAddressRequest{
+		Ticker: "btc",
+		Alias:  "shop",
+		Tag:    "tips",
+		Domain: "example.com",
+	})
+	if err != nil {
+		t.Fatalf("get address: %v", err)
+	}
+	if resp.GetAddress() != "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4" {
+		t.Fatalf("unexpected address %q", resp.GetAddress())
+	}
+	if gotPath != "/wallet/business" {
+		t.Fatalf("expected wallet_id to select the wallet path, got %q", gotPath)
+	}
+	if gotUser != "__cookie__" || gotPass != "secret" {
+		t.Fatalf("expected cookie auth, got %q:%q", gotUser, gotPass)
+	}
+	if gotReq.Method != "getnewaddress" || len(gotReq.Params) != 2 || gotReq.Params[0] != "example.com:shop+tips" || gotReq.Params[1] != "bech32" {
+		t.Fatalf("unexpected rpc request: %+v", gotReq)
+	}
+}
+
+func TestBitcoindIntegrationReportsRPCError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"result": nil,
+			"error":  map[string]any{"code": -18, "message": "Requested wallet does not exist or is not loaded"},
+			"id":     "cryptalias",
+		})
+	}))
+	defer srv.Close()
+
+	svc := newBitcoindWalletService(TokenEndpointConfig{EndpointAddress: srv.URL, Username: "user", Password: "pass"})
+	_, err := svc.GetAddress(context.Background(), &cryptaliasv1.WalletAddressRequest{Ticker: "btc", Alias: "shop", Domain: "example.com"})
+	rpcErr, ok := err.(*bitcoindRPCError)
+	if !ok || rpcErr.Code != -18 {
+		t.Fatalf("expected bitcoind rpc error -18, got %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package cryptalias
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// bolt11Invoice holds the parts of a BOLT11 payment request that LNURL-pay
+// requires Cryptalias to check before handing it to a payer.
+type bolt11Invoice struct {
+	// AmountMsat is 0 when the invoice has no amount.
+	AmountMsat uint64
+	// DescriptionHash is the h field, nil when the invoice has none.
+	DescriptionHash []byte
+}
+
+const (
+	// bolt11SignatureLen is the 65-byte signature in 5-bit groups.
+	bolt11SignatureLen = 104
+	bolt11TimestampLen = 7
+	bolt11TagDescHash  = 23 // 'h'
+)
+
+// decodeBOLT11 checks the bech32 envelope of a payment request and extracts
+// its amount and description hash. The node signature is not verified.
+func decodeBOLT11(pr string) (bolt11Invoice, error) {
+	var inv bolt11Invoice
+	if strings.ToLower(pr) != pr && strings.ToUpper(pr) != pr {
+		return inv, errors.New("payment request must not mix case")
+	}
+	pr = strings.ToLower(pr)
+	pos := strings.LastIndexByte(pr, '1')
+	if pos < 3 || !strings.HasPrefix(pr, "ln") {
+		return inv, errors.New("not a lightning payment request")
+	}
+	hrp := pr[:pos]
+	data := make([]byte, 0, len(pr)-pos-1)
+	for _, c := range []byte(pr[pos+1:]) {
+		idx := strings.IndexByte(bech32Charset, c)
+		if idx < 0 {
+			return inv, fmt.Errorf("invalid bech32 character %q", c)
+		}
+		data = append(data, byte(idx))
+	}
+	if len(data) < bolt11TimestampLen+bolt11SignatureLen+6 {
+		return inv, errors.New("payment request too short")
+	}
+	if bech32Polymod(append(bech32HRPExpand(hrp), data...)) != bech32Const {
+		return inv, errors.New("invalid bech32 checksum")
+	}
+
+	amount, err := bolt11Amount(strings.TrimLeft(hrp[2:], "abcdefghijklmnopqrstuvwxyz"), hrp)
+	if err != nil {
+		return inv, err
+	}
+	inv.AmountMsat = amount
+
+	fields := data[bolt11TimestampLen : len(data)-6-bolt11SignatureLen]
+	for len(fields) > 0 {
+		if len(fields) < 3 {
+			return inv, errors.New("truncated tagged field")
+		}
+		tag, n := fields[0], int(fields[1])<<5|int(fields[2])
+		if len(fields) < 3+n {
+			return inv, errors.New("truncated tagged field")
+		}
+		value := fields[3 : 3+n]
+		fields = fields[3+n:]
+		// Readers skip h fields of the wrong length.
+		if tag != bolt11TagDescHash || n != 52 {
+			continue
+		}
+		if inv.DescriptionHash != nil {
+			return inv, errors.New("duplicate description hash")
+		}
+		hash, err := convertBits(value, 5, 8, false)
+		if err != nil {
+			return inv, fmt.Errorf("invalid description hash: %w", err)
+		}
+		inv.DescriptionHash = hash
+	}
+	return inv, nil
+}
+
+// bolt11Amount converts the amount suffix of the human-readable part, in
+// bitcoin with an optional m, u, n or p multiplier, to millisatoshis.
+func bolt11Amount(amount, hrp string) (uint64, error) {
+	if amount == "" {
+		return 0, nil
+	}
+	last := amount[len(amount)-1]
+	digits := amount
+	if strings.IndexByte("munp", last) >= 0 {
+		digits = amount[:len(amount)-1]
+	}
+	if digits == "" || digits[0] == '0' {
+		return 0, fmt.Errorf("invalid amount in %q", hrp)
+	}
+	n, err := strconv.ParseUint(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount in %q", hrp)
+	}
+	// Millisatoshis per unit; pico-bitcoin amounts are tenths of one.
+	var mult uint64
+	switch last {
+	case 'p':
+		if n%10 != 0 {
+			return 0, fmt.Errorf("sub-millisatoshi amount in %q", hrp)
+		}
+		return n / 10, nil
+	case 'n':
+		mult = 100
+	case 'u':
+		mult = 100_000
+	case 'm':
+		mult = 100_000_000
+	default:
+		mult = 100_000_000_000
+	}
+	if n > math.MaxUint64/mult {
+		return 0, fmt.Errorf("amount out of range in %q", hrp)
+	}
+	return n * mult, nil
+}
+
+// checkInvoice verifies that a wallet service's invoice is for the requested
+// amount and commits to the LNURL-pay metadata (LUD-06, LUD-16/18).
+func checkInvoice(pr string, want lightningInvoiceInput) error {
+	inv, err := decodeBOLT11(pr)
+	if err != nil {
+		return fmt.Errorf("wallet service returned an invalid payment request: %w", err)
+	}
+	if inv.AmountMsat != want.AmountMsat {
+		return fmt.Errorf("wallet service returned an invoice for %d msat, requested %d", inv.AmountMsat, want.AmountMsat)
+	}
+	if !bytes.Equal(inv.DescriptionHash, want.DescriptionHash) {
+		return errors.New("wallet service returned an invoice whose description hash does not match the metadata")
+	}
+	return nil
+}
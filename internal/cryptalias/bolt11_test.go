@@ -0,0 +1,102 @@
+package cryptalias
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+)
+
+// testBOLT11 encodes an invoice with the given amount suffix and description
+// hash and a blank signature, which decodeBOLT11 does not verify.
+func testBOLT11(t *testing.T, amount string, descHash []byte) string {
+	t.Helper()
+	hrp := "lnbc" + amount
+	data := make([]byte, bolt11TimestampLen)
+	if descHash != nil {
+		hash, err := convertBits(descHash, 8, 5, true)
+		if err != nil {
+			t.Fatalf("convert hash: %v", err)
+		}
+		data = append(data, bolt11TagDescHash, byte(len(hash)>>5), byte(len(hash)&31))
+		data = append(data, hash...)
+	}
+	data = append(data, make([]byte, bolt11SignatureLen)...)
+	poly := bech32Polymod(append(bech32HRPExpand(hrp), append(data, 0, 0, 0, 0, 0, 0)...)) ^ bech32Const
+	for i := 0; i < 6; i++ {
+		data = append(data, byte(poly>>uint(5*(5-i)))&31)
+	}
+	var b strings.Builder
+	b.WriteString(hrp + "1")
+	for _, v := range data {
+		b.WriteByte(bech32Charset[v])
+	}
+	return b.String()
+}
+
+func TestDecodeBOLT11(t *testing.T) {
+	hash := sha256.Sum256([]byte("metadata"))
+	cases := []struct {
+		amount string
+		msat   uint64
+	}{
+		{"", 0},
+		{"2500u", 250_000_000},
+		{"10n", 1_000},
+		{"20m", 2_000_000_000},
+		{"1", 100_000_000_000},
+		{"10p", 1},
+	}
+	for _, tc := range cases {
+		inv, err := decodeBOLT11(testBOLT11(t, tc.amount, hash[:]))
+		if err != nil {
+			t.Fatalf("decode %q: %v", tc.amount, err)
+		}
+		if inv.AmountMsat != tc.msat || string(inv.DescriptionHash) != string(hash[:]) {
+			t.Fatalf("amount %q: unexpected invoice %+v", tc.amount, inv)
+		}
+	}
+
+	// Test vector from the BOLT11 specification.
+	spec := "lnbc20m1pvjluezsp5zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zyg3zygspp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqhp58yjmdan79s6qqdhdzgynm4zwqd5d7xmw5fk98klysy043l2ahrqs9qrsgq7ea976txfraylvgzuxs8kgcw23ezlrszfnh8r6qtfpr6cxga50aj6txm9rxrydzd06dfeawfk6swupvz4erwnyutnjq7x39ymw6j38gp7ynn44"
+	specHash := sha256.Sum256([]byte("One piece of chocolate cake, one icecream cone, one pickle, one slice of swiss cheese, one slice of salami, one lollypop, one piece of cherry pie, one sausage, one cupcake, and one slice of watermelon"))
+	if inv, err := decodeBOLT11(spec); err != nil || inv.AmountMsat != 2_000_000_000 || string(inv.DescriptionHash) != string(specHash[:]) {
+		t.Fatalf("unexpected spec invoice %+v %v", inv, err)
+	}
+
+	valid := testBOLT11(t, "10n", hash[:])
+	for _, pr := range []string{
+		"lnbc10n1fake",
+		valid[:len(valid)-1] + "q",
+		testBOLT11(t, "1p", hash[:]),
+		testBOLT11(t, "010n", hash[:]),
+		strings.ToUpper(valid[:10]) + valid[10:],
+	} {
+		if _, err := decodeBOLT11(pr); err == nil {
+			t.Fatalf("expected %q to be rejected", pr)
+		}
+	}
+	if inv, err := decodeBOLT11(strings.ToUpper(valid)); err != nil || inv.AmountMsat != 1_000 {
+		t.Fatalf("expected an upper-case invoice to decode, got %+v %v", inv, err)
+	}
+}
+
+func TestCheckInvoice(t *testing.T) {
+	hash := sha256.Sum256([]byte("metadata"))
+	want := lightningInvoiceInput{AmountMsat: 1_000, DescriptionHash: hash[:]}
+	if err := checkInvoice(testBOLT11(t, "10n", hash[:]), want); err != nil {
+		t.Fatalf("expected matching invoice to pass: %v", err)
+	}
+
+	other := sha256.Sum256([]byte("other"))
+	for name, pr := range map[string]string{
+		"wrong amount":   testBOLT11(t, "20n", hash[:]),
+		"no amount":      testBOLT11(t, "", hash[:]),
+		"wrong hash":     testBOLT11(t, "10n", other[:]),
+		"no hash":        testBOLT11(t, "10n", nil),
+		"not an invoice": "lnbc10n1fake",
+	} {
+		if err := checkInvoice(pr, want); err == nil {
+			t.Fatalf("%s: expected the invoice to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package cryptalias
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+)
+
+const (
+	IntegrationBTCPay = "btcpay"
+
+	defaultBTCPayPaymentMethod = "BTC-CHAIN"
+)
+
+func init() {
+	registerInternalIntegration(IntegrationBTCPay, func() internalIntegration {
+		return newWalletTargetIntegration("btcpay", namedWalletEndpoint, func(ep TokenEndpointConfig) cryptaliasv1.WalletServiceServer {
+			return newBTCPayWalletService(ep)
+		})
+	})
+}
+
+// btcpayWalletService hands out BTCPay-managed on-chain addresses through the
+// Greenfield API. Requests with an amount get a Greenfield invoice instead.
+type btcpayWalletService struct {
+	cryptaliasv1.UnimplementedWalletServiceServer
+	endpoint atomic.Value // TokenEndpointConfig
+	http     *http.Client
+}
+
+func newBTCPayWalletService(endpoint TokenEndpointConfig) *btcpayWalletService {
+	s := &btcpayWalletService{http: &http.Client{Timeout: 10 * time.Second}}
+	s.SetEndpoint(endpoint)
+	return s
+}
+
+func (s *btcpayWalletService) SetEndpoint(endpoint TokenEndpointConfig) {
+	s.endpoint.Store(endpoint)
+}
+
+func (s *btcpayWalletService) GetAddress(ctx context.Context, req *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+
+	// account_id selects the BTCPay store; the endpoint store is the default.
+	storeID := ep.StoreID
+	if req.AccountId != nil && req.GetAccountId() != "" {
+		storeID = req.GetAccountId()
+	}
+	if storeID == "" {
+		return nil, fmt.Errorf("btcpay store id is not configured (set store_id or account_id)")
+	}
+	paymentMethod := ep.PaymentMethod
+	if paymentMethod == "" {
+		paymentMethod = defaultBTCPayPaymentMethod
+	}
+
+	if req.Amount != nil && req.GetAmount() != "" {
+		return s.invoiceAddress(ctx, ep, storeID, paymentMethod, req)
+	}
+
+	var resp struct {
+		Address string `json:"address"`
+	}
+	path := "/api/v1/stores/" + url.PathEscape(storeID) + "/payment-methods/" + url.PathEscape(paymentMethod) + "/wallet/address?forceGenerate=true"
+	if err := s.do(ctx, ep, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Address == "" {
+		return nil, fmt.Errorf("btcpay returned empty address")
+	}
+	return &cryptaliasv1.WalletAddressResponse{Address: resp.Address}, nil
+}
+
+// invoiceAddress creates an invoice for the requested amount and returns the
+// invoice's destination so BTCPay tracks the payment against it.
+func (s *btcpayWalletService) invoiceAddress(ctx context.Context, ep TokenEndpointConfig, storeID, paymentMethod string, req *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	currency, _, _ := strings.Cut(paymentMethod, "-")
+	invoiceReq := map[string]any{
+		"amount":   req.GetAmount(),
+		"currency": currency,
+		"metadata": map[string]any{
+			"orderId":          walletAddressLabel(req),
+			"cryptaliasDomain": req.GetDomain(),
+			"cryptaliasAlias":  req.GetAlias(),
+			"cryptaliasTag":    req.GetTag(),
+		},
+		"checkout": map[string]any{
+			"paymentMethods": []string{paymentMethod},
+		},
+	}
+	var invoice struct {
+		ID string `json:"id"`
+	}
+	storePath := "/api/v1/stores/" + url.PathEscape(storeID)
+	if err := s.do(ctx, ep, http.MethodPost, storePath+"/invoices", invoiceReq, &invoice); err != nil {
+		return nil, err
+	}
+	if invoice.ID == "" {
+		return nil, fmt.Errorf("btcpay returned invoice without id")
+	}
+
+	var methods []struct {
+		// Greenfield 2.x uses paymentMethodId, 1.x used paymentMethod.
+		PaymentMethodID string `json:"paymentMethodId"`
+		PaymentMethod   string `json:"paymentMethod"`
+		Destination     string `json:"destination"`
+	}
+	if err := s.do(ctx, ep, http.MethodGet, storePath+"/invoices/"+url.PathEscape(invoice.ID)+"/payment-methods", nil, &methods); err != nil {
+		return nil, err
+	}
+	for _, m := range methods {
+		id := m.PaymentMethodID
+		if id == "" {
+			id = m.PaymentMethod
+		}
+		if strings.EqualFold(id, paymentMethod) && m.Destination != "" {
+			return &cryptaliasv1.WalletAddressResponse{Address: m.Destination}, nil
+		}
+	}
+	return nil, fmt.Errorf("btcpay invoice %s has no %s destination", invoice.ID, paymentMethod)
+}
+
+// Health uses the Greenfield health endpoint, which reports whether BTCPay's
+// nodes are synchronized.
+func (s *btcpayWalletService) Health(ctx context.Context, _ *cryptaliasv1.HealthRequest) (*cryptaliasv1.HealthResponse, error) {
+	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+	var health struct {
+		Synchronized bool `json:"synchronized"`
+	}
+	if err := s.do(ctx, ep, http.MethodGet, "/api/v1/health", nil, &health); err != nil {
+		return &cryptaliasv1.HealthResponse{Ok: false, Message: err.Error()}, nil
+	}
+	if !health.Synchronized {
+		return &cryptaliasv1.HealthResponse{Ok: false, Message: "btcpay is not synchronized"}, nil
+	}
+	return &cryptaliasv1.HealthResponse{Ok: true, Message: "synchronized"}, nil
+}
+
+type btcpayError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (s *btcpayWalletService) do(ctx context.Context, ep TokenEndpointConfig, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(ep.EndpointAddress, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// Greenfield API keys use the "token" scheme.
+	req.Header.Set("Authorization", "token "+ep.Token)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr btcpayError
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Message != "" {
+			return fmt.Errorf("btcpay %s %s: http %d: %s", method, path, resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("btcpay %s %s: http %d", method, path, resp.StatusCode)
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,102 @@
+package cryptalias
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func newBTCPayStub(t *testing.T, invoices *[]map[string]any) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/stores/{store}/payment-methods/{method}/wallet/address", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.PathValue("method") != "BTC-CHAIN" || r.URL.Query().Get("forceGenerate") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"address": "bc1q-" + r.PathValue("store")})
+	})
+	mux.HandleFunc("POST /api/v1/stores/{store}/invoices", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		*invoices = append(*invoices, body)
+		_ = json.NewEncoder(w).Encode(map[string]string{"id": "inv-1"})
+	})
+	mux.HandleFunc("GET /api/v1/stores/{store}/invoices/inv-1/payment-methods", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode([]map[string]string{
+			{"paymentMethodId": "BTC-LN", "destination": "lnbc1..."},
+			{"paymentMethodId": "BTC-CHAIN", "destination": "bc1q-invoice"},
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestBTCPayIntegrationNextAddress(t *testing.T) {
+	var invoices []map[string]any
+	srv := newBTCPayStub(t, &invoices)
+	defer srv.Close()
+
+	svc := newBTCPayWalletService(TokenEndpointConfig{
+		EndpointAddress: srv.URL,
+		Integration:     IntegrationBTCPay,
+		Token:           "api-key",
+		StoreID:         "default-store",
+	})
+	req := &cryptaliasv1.WalletAddressRequest{Ticker: "btc", Alias: "shop", Domain: "example.com"}
+
+	resp, err := svc.GetAddress(context.Background(), req)
+	if err != nil {
+		t.Fatalf("get address: %v", err)
+	}
+	if resp.GetAddress() != "bc1q-default-store" {
+		t.Fatalf("expected endpoint store address, got %q", resp.GetAddress())
+	}
+
+	req.AccountId = proto.String("merchant-store")
+	resp, err = svc.GetAddress(context.Background(), req)
+	if err != nil {
+		t.Fatalf("get address with account_id: %v", err)
+	}
+	if resp.GetAddress() != "bc1q-merchant-store" {
+		t.Fatalf("expected account_id to select the store, got %q", resp.GetAddress())
+	}
+	if len(invoices) != 0 {
+		t.Fatalf("expected no invoices without an amount")
+	}
+}
+
+func TestBTCPayIntegrationCreatesInvoiceForAmount(t *testing.T) {
+	var invoices []map[string]any
+	srv := newBTCPayStub(t, &invoices)
+	defer srv.Close()
+
+	svc := newBTCPayWalletService(TokenEndpointConfig{
+		EndpointAddress: srv.URL,
+		Token:           "api-key",
+		StoreID:         "default-store",
+	})
+	resp, err := svc.GetAddress(context.Background(), &cryptaliasv1.WalletAddressRequest{
+		Ticker: "btc",
+		Alias:  "shop",
+		Domain: "example.com",
+		Amount: proto.String("0.0015"),
+	})
+	if err != nil {
+		t.Fatalf("get address: %v", err)
+	}
+	if resp.GetAddress() != "bc1q-invoice" {
+		t.Fatalf("expected invoice destination, got %q", resp.GetAddress())
+	}
+	if len(invoices) != 1 || invoices[0]["amount"] != "0.0015" || invoices[0]["currency"] != "BTC" {
+		t.Fatalf("unexpected invoice request: %+v", invoices)
+	}
+}
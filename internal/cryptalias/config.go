@@ -4,11 +4,16 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lestrrat-go/jwx/v3/jwk"
 	"gopkg.in/yaml.v2"
@@ -25,6 +30,18 @@ type Config struct {
 	Verify     VerifyConfig        `yaml:"verify,omitempty"`
 	Domains    []AliasDomainConfig `yaml:"domains"`
 	Tokens     []TokenConfig       `yaml:"tokens"`
+
+	// WalletHealth configures the background wallet backend health checker.
+	WalletHealth WalletHealthConfig `yaml:"wallet_health,omitempty"`
+	// PaymentWatch configures polling wallet services for incoming payments.
+	PaymentWatch PaymentWatchConfig `yaml:"payment_watch,omitempty"`
+	// WebhookDelivery configures retries for the domains' webhooks.
+	WebhookDelivery WebhookDeliveryConfig `yaml:"webhook_delivery,omitempty"`
+	// AliasStore selects where domain aliases live: this file (default) or
+	// an embedded SQLite database.
+	AliasStore AliasStoreConfig `yaml:"alias_store,omitempty"`
+	// Admin enables the authenticated admin REST API.
+	Admin AdminConfig `yaml:"admin,omitempty"`
 }
 
 func (c *Config) Clone() *Config {
@@ -32,14 +49,19 @@ func (c *Config) Clone() *Config {
 		return nil
 	}
 	out := &Config{
-		BaseURL:    c.BaseURL,
-		PublicPort: c.PublicPort,
-		Logging:    c.Logging,
-		RateLimit:  c.RateLimit.Clone(),
-		Resolution: c.Resolution.Clone(),
-		Verify:     c.Verify.Clone(),
-		Domains:    make([]AliasDomainConfig, len(c.Domains)),
-		Tokens:     make([]TokenConfig, len(c.Tokens)),
+		BaseURL:         c.BaseURL,
+		PublicPort:      c.PublicPort,
+		Logging:         c.Logging,
+		RateLimit:       c.RateLimit.Clone(),
+		Resolution:      c.Resolution.Clone(),
+		Verify:          c.Verify.Clone(),
+		WalletHealth:    c.WalletHealth,
+		PaymentWatch:    c.PaymentWatch,
+		WebhookDelivery: c.WebhookDelivery,
+		AliasStore:      c.AliasStore,
+		Admin:           c.Admin,
+		Domains:         make([]AliasDomainConfig, len(c.Domains)),
+		Tokens:          make([]TokenConfig, len(c.Tokens)),
 	}
 	for i := range c.Domains {
 		out.Domains[i] = c.Domains[i].Clone()
@@ -65,9 +87,18 @@ func (c *Config) Normalize(path string) {
 	if c.RateLimit.Burst <= 0 {
 		c.RateLimit.Burst = 10
 	}
+	if c.RateLimit.UncachedRequestsPerMinute <= 0 {
+		c.RateLimit.UncachedRequestsPerMinute = 6
+	}
+	if c.RateLimit.UncachedBurst <= 0 {
+		c.RateLimit.UncachedBurst = 2
+	}
 	if c.Resolution.TTLSeconds <= 0 {
 		c.Resolution.TTLSeconds = 60
 	}
+	if c.Resolution.IssuedRetentionDays <= 0 {
+		c.Resolution.IssuedRetentionDays = 90
+	}
 	if c.Resolution.ClientIdentity.Strategy == "" {
 		c.Resolution.ClientIdentity.Strategy = ClientIdentityStrategyXFF
 	}
@@ -77,19 +108,39 @@ func (c *Config) Normalize(path string) {
 	if c.Verify.IntervalMinutes <= 0 {
 		c.Verify.IntervalMinutes = 5
 	}
+	if c.WalletHealth.IntervalSeconds <= 0 {
+		c.WalletHealth.IntervalSeconds = 60
+	}
+	if c.PaymentWatch.IntervalSeconds <= 0 {
+		c.PaymentWatch.IntervalSeconds = 30
+	}
+	if c.PaymentWatch.Confirmations == 0 {
+		c.PaymentWatch.Confirmations = 10
+	}
+	c.WebhookDelivery.normalize()
+	c.AliasStore.Type = strings.ToLower(strings.TrimSpace(c.AliasStore.Type))
+	c.AliasStore.Path = strings.TrimSpace(c.AliasStore.Path)
+	c.Admin.Listen = strings.TrimSpace(c.Admin.Listen)
 	triggerSave := false
 	// Normalize case for stable matching across requests.
+	for i := range c.Tokens {
+		c.Tokens[i].Chain = strings.ToLower(strings.TrimSpace(c.Tokens[i].Chain))
+		c.Tokens[i].Network = strings.ToLower(strings.TrimSpace(c.Tokens[i].Network))
+		c.Tokens[i].Endpoint.normalize()
+		for e := range c.Tokens[i].Endpoints {
+			c.Tokens[i].Endpoints[e].normalize()
+		}
+	}
 	for i := range c.Domains {
 		c.Domains[i].Domain = strings.ToLower(c.Domains[i].Domain)
+		for w := range c.Domains[i].Webhooks {
+			c.Domains[i].Webhooks[w].normalize()
+		}
+		if c.Domains[i].AliasProvider != nil {
+			c.Domains[i].AliasProvider.normalize()
+		}
 		for a := range c.Domains[i].Aliases {
-			c.Domains[i].Aliases[a].Alias = strings.ToLower(c.Domains[i].Aliases[a].Alias)
-			c.Domains[i].Aliases[a].Wallet.Ticker = strings.ToLower(c.Domains[i].Aliases[a].Wallet.Ticker)
-			normalizeWalletAddress(&c.Domains[i].Aliases[a].Wallet)
-			for t := range c.Domains[i].Aliases[a].Tags {
-				c.Domains[i].Aliases[a].Tags[t].Tag = strings.ToLower(c.Domains[i].Aliases[a].Tags[t].Tag)
-				c.Domains[i].Aliases[a].Tags[t].Wallet.Ticker = strings.ToLower(c.Domains[i].Aliases[a].Tags[t].Wallet.Ticker)
-				normalizeWalletAddress(&c.Domains[i].Aliases[a].Tags[t].Wallet)
-			}
+			normalizeWalletAlias(&c.Domains[i].Aliases[a])
 		}
 		if result, err := c.Domains[i].GenerateKeys(); !result && err != nil {
 			panic(err)
@@ -123,13 +174,34 @@ func (c *Config) Validate() error {
 		if c.RateLimit.Burst <= 0 {
 			return fmt.Errorf("rate_limit.burst must be > 0")
 		}
+		if c.RateLimit.UncachedRequestsPerMinute <= 0 {
+			return fmt.Errorf("rate_limit.uncached_requests_per_minute must be > 0")
+		}
+		if c.RateLimit.UncachedBurst <= 0 {
+			return fmt.Errorf("rate_limit.uncached_burst must be > 0")
+		}
 	}
 	if c.Resolution.TTLSeconds <= 0 {
 		return fmt.Errorf("resolution.ttl_seconds must be > 0")
 	}
+	if c.Resolution.IssuedRetentionDays <= 0 {
+		return fmt.Errorf("resolution.issued_retention_days must be > 0")
+	}
 	if c.Verify.IntervalMinutes <= 0 {
 		return fmt.Errorf("verify.interval_minutes must be > 0")
 	}
+	if c.WalletHealth.IntervalSeconds <= 0 {
+		return fmt.Errorf("wallet_health.interval_seconds must be > 0")
+	}
+	if c.PaymentWatch.IntervalSeconds <= 0 {
+		return fmt.Errorf("payment_watch.interval_seconds must be > 0")
+	}
+	if err := c.WebhookDelivery.validate(); err != nil {
+		return err
+	}
+	if err := c.Admin.validate(); err != nil {
+		return err
+	}
 	switch c.Resolution.ClientIdentity.Strategy {
 	case ClientIdentityStrategyRemoteAddr, ClientIdentityStrategyXFF, ClientIdentityStrategyXFFUA, ClientIdentityStrategyHeader, ClientIdentityStrategyHeaderUA:
 	default:
@@ -148,6 +220,29 @@ func (c *Config) Validate() error {
 		if len(d.PrivateKey) == 0 || len(d.PublicKey) == 0 {
 			return fmt.Errorf("domains[%d] keys are required", i)
 		}
+		for w, hook := range d.Webhooks {
+			if err := hook.validate(fmt.Sprintf("domains[%d].webhooks[%d]", i, w)); err != nil {
+				return err
+			}
+		}
+		if d.AliasProvider != nil {
+			if err := d.AliasProvider.validate(fmt.Sprintf("domains[%d].alias_provider", i)); err != nil {
+				return err
+			}
+		}
+		if len(d.Aliases) > 0 && c.AliasStore.Type == AliasStoreTypeSQLite {
+			return fmt.Errorf("domains[%d].aliases must be empty with alias_store.type sqlite; import them with `cryptalias alias migrate`", i)
+		}
+		for a, alias := range d.Aliases {
+			if err := c.validateLightning(fmt.Sprintf("domains[%d].aliases[%d].lightning", i, a), alias.Lightning); err != nil {
+				return err
+			}
+		}
+	}
+	switch c.AliasStore.Type {
+	case "", AliasStoreTypeYAML, AliasStoreTypeSQLite:
+	default:
+		return fmt.Errorf("alias_store.type must be one of: yaml, sqlite")
 	}
 	if len(c.Tokens) == 0 {
 		return fmt.Errorf("at least one token (i.e. cryptocurrency / asset) is required")
@@ -159,12 +254,264 @@ func (c *Config) Validate() error {
 		if len(t.Tickers) == 0 {
 			return fmt.Errorf("at least one tokens[%d].tickers is required, i.e. xmr, btc etc.", i)
 		}
-		if t.Endpoint.EndpointType == "" {
-			return fmt.Errorf("tokens[%d].endpoint.type is required (internal or external)", i)
+		if len(t.Endpoints) > 0 && t.Endpoint.EndpointType != "" {
+			return fmt.Errorf("tokens[%d]: use either endpoint or endpoints, not both", i)
+		}
+		names := map[string]bool{}
+		for e, backend := range t.backends() {
+			path := fmt.Sprintf("tokens[%d].endpoint", i)
+			if len(t.Endpoints) > 0 {
+				path = fmt.Sprintf("tokens[%d].endpoints[%d]", i, e)
+				if names[backend.Endpoint.Name] {
+					return fmt.Errorf("%s.name %q is duplicated", path, backend.Endpoint.Name)
+				}
+				names[backend.Endpoint.Name] = true
+			}
+			if err := validateTokenEndpoint(path, backend); err != nil {
+				return err
+			}
+		}
+		if t.Chain != "" {
+			if _, ok := addressValidators[t.Chain]; !ok {
+				return fmt.Errorf("tokens[%d].chain %q has no address validator", i, t.Chain)
+			}
+		}
+		for _, ticker := range t.Tickers {
+			v, ok := addressValidatorFor(t, ticker)
+			if !ok || len(v.Networks()) == 0 {
+				continue
+			}
+			if !networkSupported(v, t.NetworkOrDefault()) {
+				return fmt.Errorf("tokens[%d].network %q is not supported for %s", i, t.NetworkOrDefault(), ticker)
+			}
+		}
+	}
+	return c.validateStaticAddresses()
+}
+
+// validateTokenEndpoint checks one backend of a token; path is its yaml path.
+func validateTokenEndpoint(path string, t TokenConfig) error {
+	switch t.Endpoint.EndpointType {
+	case "":
+		return fmt.Errorf("%s.type is required (internal, external, http or exec)", path)
+	case TokenEndpointTypeInternal, TokenEndpointTypeExternal, TokenEndpointTypeHTTP, TokenEndpointTypeExec:
+	default:
+		return fmt.Errorf("%s.type must be one of: internal, external, http, exec", path)
+	}
+	if t.Endpoint.EndpointAddress == "" {
+		return fmt.Errorf("%s.address is required", path)
+	}
+	if t.Endpoint.EndpointType == TokenEndpointTypeHTTP {
+		u, err := url.Parse(t.Endpoint.EndpointAddress)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s.address must be an http(s) URL for http endpoints", path)
+		}
+		if t.Endpoint.HTTP.TimeoutSeconds < 0 {
+			return fmt.Errorf("%s.http.timeout_seconds must be >= 0", path)
+		}
+		if err := checkCredentialTransport(t.Endpoint); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if t.Endpoint.EndpointType == TokenEndpointTypeExec {
+		if !filepath.IsAbs(t.Endpoint.EndpointAddress) {
+			return fmt.Errorf("%s.address must be an absolute command path for exec endpoints", path)
+		}
+		switch t.Endpoint.Exec.Mode {
+		case "", ExecModeOneshot, ExecModePersistent:
+		default:
+			return fmt.Errorf("%s.exec.mode must be one of: oneshot, persistent", path)
+		}
+		if t.Endpoint.Exec.TimeoutSeconds < 0 {
+			return fmt.Errorf("%s.exec.timeout_seconds must be >= 0", path)
+		}
+	}
+	if t.Endpoint.EndpointType == TokenEndpointTypeExternal {
+		if err := t.Endpoint.TLS.validate(); err != nil {
+			return fmt.Errorf("%s.tls.%v", path, err)
+		}
+		if err := checkCredentialTransport(t.Endpoint); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	} else if t.Endpoint.TLS.IsEnabled() {
+		return fmt.Errorf("%s.tls is only supported for external endpoints", path)
+	}
+	if t.Endpoint.MaxConcurrency < 0 {
+		return fmt.Errorf("%s.max_concurrency must be >= 0", path)
+	}
+	if t.Endpoint.TimeoutSeconds < 0 {
+		return fmt.Errorf("%s.timeout_seconds must be >= 0", path)
+	}
+	if t.Endpoint.Retries != nil && (*t.Endpoint.Retries < 0 || *t.Endpoint.Retries > 5) {
+		return fmt.Errorf("%s.retries must be between 0 and 5", path)
+	}
+	if t.Endpoint.RetryBackoffMs < 0 {
+		return fmt.Errorf("%s.retry_backoff_ms must be >= 0", path)
+	}
+	if t.Endpoint.Breaker.FailureThreshold < 0 || t.Endpoint.Breaker.CooldownSeconds < 0 {
+		return fmt.Errorf("%s.breaker failure_threshold and cooldown_seconds must be >= 0", path)
+	}
+	if t.Endpoint.EndpointType == TokenEndpointTypeInternal {
+		integration := t.IntegrationOrDefault()
+		if integration == "" {
+			return fmt.Errorf("%s.integration is required for internal endpoints (one of: %s)", path, strings.Join(internalIntegrationNames(), ", "))
+		}
+		if _, ok := internalIntegrations[integration]; !ok {
+			return fmt.Errorf("%s.integration %q is unknown (one of: %s)", path, integration, strings.Join(internalIntegrationNames(), ", "))
+		}
+	} else if t.Endpoint.Integration != "" {
+		return fmt.Errorf("%s.integration is only supported for internal endpoints", path)
+	}
+	if t.IntegrationOrDefault() == IntegrationBTCPay && t.Endpoint.Token == "" {
+		return fmt.Errorf("%s.token (Greenfield API key) is required for the btcpay integration", path)
+	}
+	if t.Endpoint.AddressType != "" && !bitcoindAddressTypes[t.Endpoint.AddressType] {
+		return fmt.Errorf("%s.address_type must be one of: legacy, p2sh-segwit, bech32, bech32m", path)
+	}
+	if t.Endpoint.CookieFile != "" && (t.Endpoint.Username != "" || t.Endpoint.Password != "") {
+		return fmt.Errorf("%s: use either cookie_file or username/password, not both", path)
+	}
+	if !validAddressMode(t.Endpoint.AddressMode) {
+		return fmt.Errorf("%s.address_mode must be one of: subaddress, integrated", path)
+	}
+	switch t.Endpoint.WalletSession {
+	case "", WalletSessionPerRequest, WalletSessionPersistent:
+	default:
+		return fmt.Errorf("%s.wallet_session must be one of: per_request, persistent", path)
+	}
+	if len(t.Endpoint.Wallets) > 0 && t.Endpoint.EndpointType != TokenEndpointTypeInternal {
+		return fmt.Errorf("%s.wallets is only supported for internal endpoints", path)
+	}
+	walletIDs := map[string]bool{}
+	for w, wallet := range t.Endpoint.Wallets {
+		if wallet.ID == "" {
+			return fmt.Errorf("%s.wallets[%d].id is required", path, w)
 		}
-		if t.Endpoint.EndpointAddress == "" {
-			return fmt.Errorf("tokens[%d].endpoint.address is required", i)
+		if walletIDs[wallet.ID] {
+			return fmt.Errorf("%s.wallets[%d].id %q is duplicated", path, w, wallet.ID)
 		}
+		walletIDs[wallet.ID] = true
+	}
+	switch t.Endpoint.HealthCheck {
+	case "", HealthCheckNone:
+	case HealthCheckCryptalias:
+		if t.Endpoint.EndpointType != TokenEndpointTypeInternal && t.Endpoint.EndpointType != TokenEndpointTypeExternal {
+			return fmt.Errorf("%s.health_check cryptalias is only supported for internal and external endpoints", path)
+		}
+	case HealthCheckGRPC:
+		if t.Endpoint.EndpointType != TokenEndpointTypeExternal {
+			return fmt.Errorf("%s.health_check grpc is only supported for external endpoints", path)
+		}
+	default:
+		return fmt.Errorf("%s.health_check must be one of: cryptalias, grpc, none", path)
+	}
+	switch t.Endpoint.ProtocolVersion {
+	case "", ProtocolVersionV1, ProtocolVersionV2:
+		if t.Endpoint.ProtocolVersion != "" && t.Endpoint.EndpointType == TokenEndpointTypeInternal {
+			return fmt.Errorf("%s.protocol_version is not supported for internal endpoints", path)
+		}
+	case ProtocolVersionAuto:
+		if t.Endpoint.EndpointType != TokenEndpointTypeExternal {
+			return fmt.Errorf("%s.protocol_version auto is only supported for external endpoints", path)
+		}
+	default:
+		return fmt.Errorf("%s.protocol_version must be one of: auto, v1, v2", path)
+	}
+	if t.Endpoint.Priority < 0 || t.Endpoint.Weight < 0 {
+		return fmt.Errorf("%s.priority and weight must be >= 0", path)
+	}
+	return nil
+}
+
+// validateStaticAddresses rejects static wallet addresses (and destination
+// fields) that are malformed or belong to the wrong network, so typos are
+// caught before they are signed.
+func (c *Config) validateStaticAddresses() error {
+	for i, d := range c.Domains {
+		for a, alias := range d.Aliases {
+			if err := c.validateAliasWallets(fmt.Sprintf("domains[%d].aliases[%d]", i, a), alias); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateAlias checks an alias against the tokens of this config, as for
+// aliases in domains[].aliases. path prefixes error messages.
+func (c *Config) validateAlias(path string, a WalletAlias) error {
+	if err := validateAliasOrTag(a.Alias, "alias"); err != nil {
+		return fmt.Errorf("%s.%v", path, err)
+	}
+	if a.Wallet.Ticker == "" {
+		return fmt.Errorf("%s.wallet.ticker is required", path)
+	}
+	for t, tag := range a.Tags {
+		if err := validateAliasOrTag(tag.Tag, "tag"); err != nil {
+			return fmt.Errorf("%s.tags[%d].%v", path, t, err)
+		}
+	}
+	if err := c.validateLightning(path+".lightning", a.Lightning); err != nil {
+		return err
+	}
+	return c.validateAliasWallets(path, a)
+}
+
+func (c *Config) validateLightning(path string, ln *LightningConfig) error {
+	if ln == nil {
+		return nil
+	}
+	if ln.MinSendableMsat < 1000 {
+		return fmt.Errorf("%s.min_sendable_msat must be >= 1000", path)
+	}
+	if ln.MaxSendableMsat < ln.MinSendableMsat {
+		return fmt.Errorf("%s.max_sendable_msat must be >= min_sendable_msat", path)
+	}
+	if ln.CommentAllowed < 0 {
+		return fmt.Errorf("%s.comment_allowed must be >= 0", path)
+	}
+	if _, err := findTokenConfig(c, ln.Ticker); err != nil {
+		return fmt.Errorf("%s.ticker %q has no token configured", path, ln.Ticker)
+	}
+	return nil
+}
+
+func (c *Config) validateAliasWallets(path string, a WalletAlias) error {
+	if err := c.validateAliasWallet(path+".wallet", a.Wallet); err != nil {
+		return err
+	}
+	for t, tag := range a.Tags {
+		if err := c.validateAliasWallet(fmt.Sprintf("%s.tags[%d].wallet", path, t), tag.Wallet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateAliasWallet(path string, w WalletAddress) error {
+	if err := validateDestinationFields(w); err != nil {
+		return fmt.Errorf("%s.%v", path, err)
+	}
+	token, err := findTokenConfig(c, w.Ticker)
+	if err != nil {
+		// Unknown tickers still get ticker-keyed validation on mainnet.
+		token = TokenConfig{}
+	}
+	if w.WalletID != nil && *w.WalletID != "" {
+		named, found := false, false
+		for _, b := range token.backends() {
+			_, ok := b.Endpoint.findWallet(*w.WalletID)
+			named, found = named || len(b.Endpoint.Wallets) > 0, found || ok
+		}
+		if named && !found {
+			return fmt.Errorf("%s.wallet_id %q does not match any tokens endpoint.wallets id", path, *w.WalletID)
+		}
+	}
+	if w.Address == "" {
+		return nil
+	}
+	if err := validateTokenAddress(token, w.Ticker, w.Address); err != nil {
+		return fmt.Errorf("%s.address is not a valid %s address: %w", path, w.Ticker, err)
 	}
 	return nil
 }
@@ -184,6 +531,10 @@ type AliasDomainConfig struct {
 	PrivateKey PrivateKey    `yaml:"private_key"`
 	PublicKey  PublicKey     `yaml:"public_key"`
 	Aliases    []WalletAlias `yaml:"aliases,omitempty"`
+	// Webhooks subscribe URLs to events of this domain and its aliases.
+	Webhooks []WebhookConfig `yaml:"webhooks,omitempty"`
+	// AliasProvider is consulted for aliases that are not listed above.
+	AliasProvider *AliasProviderConfig `yaml:"alias_provider,omitempty"`
 }
 
 type LoggingConfig struct {
@@ -195,6 +546,10 @@ type RateLimitConfig struct {
 	Enabled           *bool `yaml:"enabled,omitempty"`
 	RequestsPerMinute int   `yaml:"requests_per_minute,omitempty"`
 	Burst             int   `yaml:"burst,omitempty"`
+	// UncachedRequestsPerMinute and UncachedBurst additionally limit resolve
+	// requests carrying an amount or memo, which bypass the address cache.
+	UncachedRequestsPerMinute int `yaml:"uncached_requests_per_minute,omitempty"`
+	UncachedBurst             int `yaml:"uncached_burst,omitempty"`
 }
 
 type ResolutionConfig struct {
@@ -202,6 +557,24 @@ type ResolutionConfig struct {
 	TTLSeconds     int                  `yaml:"ttl_seconds,omitempty"`
 	// ClientIdentity determines how "same client" is derived for caching and limits.
 	ClientIdentity ClientIdentityConfig `yaml:"client_identity,omitempty"`
+
+	// RetireUsed stops handing out a cached address once it received funds.
+	// Defaults to true when omitted.
+	RetireUsed *bool `yaml:"retire_used,omitempty"`
+	// ReuseUnused hands previously issued addresses that never received
+	// funds to new clients before asking the wallet for a new one.
+	ReuseUnused bool `yaml:"reuse_unused,omitempty"`
+	// IssuedRetentionDays is how long issued addresses and payment IDs are
+	// remembered for matching payments and reuse. Defaults to 90.
+	IssuedRetentionDays int `yaml:"issued_retention_days,omitempty"`
+}
+
+func (r ResolutionConfig) RetireUsedOrDefault() bool {
+	return r.RetireUsed == nil || *r.RetireUsed
+}
+
+func (r ResolutionConfig) IssuedRetention() time.Duration {
+	return time.Duration(r.IssuedRetentionDays) * 24 * time.Hour
 }
 
 type VerifyConfig struct {
@@ -209,11 +582,38 @@ type VerifyConfig struct {
 	IntervalMinutes int `yaml:"interval_minutes,omitempty"`
 }
 
+type WalletHealthConfig struct {
+	// Disabled turns off the background wallet health checks.
+	Disabled bool `yaml:"disabled,omitempty"`
+	// IntervalSeconds controls how often each token backend is checked.
+	IntervalSeconds int `yaml:"interval_seconds,omitempty"`
+	// GateResolution answers dynamic resolves with 503 while every backend
+	// of the ticker is unhealthy, like unhealthy domains.
+	GateResolution bool `yaml:"gate_resolution,omitempty"`
+}
+
+type PaymentWatchConfig struct {
+	// Enabled turns on polling wallet services with ListPayments.
+	Enabled bool `yaml:"enabled,omitempty"`
+	// IntervalSeconds controls how often each token backend is polled.
+	IntervalSeconds int `yaml:"interval_seconds,omitempty"`
+	// Confirmations a payment needs before it is reported as confirmed.
+	Confirmations uint64 `yaml:"confirmations,omitempty"`
+}
+
 func (r ResolutionConfig) Clone() ResolutionConfig {
-	return ResolutionConfig{
+	out := ResolutionConfig{
 		TTLSeconds:     r.TTLSeconds,
 		ClientIdentity: r.ClientIdentity,
+		ReuseUnused:    r.ReuseUnused,
+
+		IssuedRetentionDays: r.IssuedRetentionDays,
+	}
+	if r.RetireUsed != nil {
+		v := *r.RetireUsed
+		out.RetireUsed = &v
 	}
+	return out
 }
 
 func (v VerifyConfig) Clone() VerifyConfig {
@@ -232,6 +632,9 @@ func (r RateLimitConfig) Clone() RateLimitConfig {
 		Enabled:           enabled,
 		RequestsPerMinute: r.RequestsPerMinute,
 		Burst:             r.Burst,
+
+		UncachedRequestsPerMinute: r.UncachedRequestsPerMinute,
+		UncachedBurst:             r.UncachedBurst,
 	}
 }
 
@@ -248,6 +651,9 @@ func (a AliasDomainConfig) Clone() AliasDomainConfig {
 		PrivateKey: PrivateKey(append([]byte(nil), a.PrivateKey...)),
 		PublicKey:  PublicKey(append([]byte(nil), a.PublicKey...)),
 		Aliases:    append([]WalletAlias(nil), a.Aliases...),
+		Webhooks:   cloneWebhooks(a.Webhooks),
+
+		AliasProvider: a.AliasProvider.Clone(),
 	}
 }
 
@@ -309,17 +715,63 @@ func (a *AliasDomainConfig) DNSTXTRecord() string {
 }
 
 type TokenConfig struct {
-	Name     string              `yaml:"name"`
-	Tickers  []string            `yaml:"tickers"`
-	Endpoint TokenEndpointConfig `yaml:"endpoint"`
+	Name    string   `yaml:"name"`
+	Tickers []string `yaml:"tickers"`
+	// Chain selects an address validator explicitly (e.g. evm for ERC-20
+	// tickers). When empty, the validator is looked up by ticker.
+	Chain string `yaml:"chain,omitempty"`
+	// Network is the network addresses must belong to; defaults to mainnet.
+	Network  string              `yaml:"network,omitempty"`
+	Endpoint TokenEndpointConfig `yaml:"endpoint,omitempty"`
+	// Endpoints lists several backends instead of endpoint. The lowest
+	// priority is tried first, weight spreads load within a priority, and a
+	// failed call fails over to the next backend.
+	Endpoints []TokenEndpointConfig `yaml:"endpoints,omitempty"`
+	// Sticky keeps each client on the same backend while it is healthy.
+	Sticky bool `yaml:"sticky,omitempty"`
 }
 
 func (t TokenConfig) Clone() TokenConfig {
-	return TokenConfig{
+	out := TokenConfig{
 		Name:     t.Name,
 		Tickers:  append([]string(nil), t.Tickers...),
-		Endpoint: t.Endpoint,
+		Chain:    t.Chain,
+		Network:  t.Network,
+		Endpoint: t.Endpoint.Clone(),
+		Sticky:   t.Sticky,
 	}
+	for _, e := range t.Endpoints {
+		out.Endpoints = append(out.Endpoints, e.Clone())
+	}
+	return out
+}
+
+// backends returns one TokenConfig per configured endpoint, with Endpoint
+// set to it. With an endpoints list each copy is named "<token>/<endpoint>"
+// so breakers and concurrency limits are tracked per backend.
+func (t TokenConfig) backends() []TokenConfig {
+	if len(t.Endpoints) == 0 {
+		return []TokenConfig{t}
+	}
+	out := make([]TokenConfig, 0, len(t.Endpoints))
+	for i, e := range t.Endpoints {
+		b := t
+		b.Endpoints = nil
+		b.Endpoint = e
+		if b.Endpoint.Name == "" {
+			b.Endpoint.Name = strconv.Itoa(i)
+		}
+		b.Name = t.Name + "/" + b.Endpoint.Name
+		out = append(out, b)
+	}
+	return out
+}
+
+func (t TokenConfig) NetworkOrDefault() string {
+	if t.Network == "" {
+		return "mainnet"
+	}
+	return t.Network
 }
 
 type TokenEndpointType string
@@ -327,11 +779,22 @@ type TokenEndpointType string
 const (
 	TokenEndpointTypeInternal = "internal"
 	TokenEndpointTypeExternal = "external"
+	TokenEndpointTypeHTTP     = "http"
+	TokenEndpointTypeExec     = "exec"
 )
 
 type TokenEndpointConfig struct {
+	// Name identifies an entry in a token's endpoints list; defaults to its index.
+	Name string `yaml:"name,omitempty"`
+	// Priority orders endpoints (lowest first); Weight (default 1) shares
+	// load between endpoints of equal priority.
+	Priority        int               `yaml:"priority,omitempty"`
+	Weight          int               `yaml:"weight,omitempty"`
 	EndpointAddress string            `yaml:"address,omitempty"`
 	EndpointType    TokenEndpointType `yaml:"type"`
+	// Integration names the built-in backend for internal endpoints, e.g.
+	// monero-wallet-rpc. Monero tokens default to monero-wallet-rpc.
+	Integration string `yaml:"integration,omitempty"`
 	// Token/Username/Password are forwarded as auth metadata to external gRPC services.
 	Token           string            `yaml:"token,omitempty"`
 	Username        string            `yaml:"username,omitempty"`
@@ -339,12 +802,238 @@ type TokenEndpointConfig struct {
 	// WalletFile/WalletPassword are used by internal integrations (e.g. Monero).
 	WalletFile      string            `yaml:"wallet_file,omitempty"`
 	WalletPassword  string            `yaml:"wallet_password,omitempty"`
+	// AddressMode selects how internal Monero addresses are issued: subaddress
+	// (default) or integrated. Aliases may override it.
+	AddressMode string `yaml:"address_mode,omitempty"`
+	// WalletSession controls the Monero wallet lifecycle: per_request (default)
+	// opens and closes wallet_file around every address, persistent keeps it open.
+	WalletSession string `yaml:"wallet_session,omitempty"`
+	// WalletFilePath is an optional local path to the wallet file (e.g. a shared
+	// volume). In persistent mode the wallet is reopened when it changes.
+	WalletFilePath string `yaml:"wallet_file_path,omitempty"`
+	// CookieFile authenticates to bitcoind-compatible nodes with the .cookie
+	// file instead of rpcuser/rpcpassword.
+	CookieFile string `yaml:"cookie_file,omitempty"`
+	// AddressType is the bitcoind getnewaddress address type, e.g. bech32.
+	AddressType string `yaml:"address_type,omitempty"`
+	// StoreID and PaymentMethod select the BTCPay store and payment method
+	// (default BTC-CHAIN). An alias account_id overrides the store.
+	StoreID       string `yaml:"store_id,omitempty"`
+	PaymentMethod string `yaml:"payment_method,omitempty"`
+	// HTTP configures the http endpoint type (a JSON webhook).
+	HTTP HTTPEndpointConfig `yaml:"http,omitempty"`
+	// Exec configures the exec endpoint type; address is the command to run.
+	Exec ExecEndpointConfig `yaml:"exec,omitempty"`
+	// MaxConcurrency caps in-flight backend calls for this token. Zero means
+	// unlimited, except exec endpoints which default to 4.
+	MaxConcurrency int `yaml:"max_concurrency,omitempty"`
+	// TimeoutSeconds bounds each backend call (default 15).
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// Retries is how many times a call that never reached the wallet (e.g.
+	// connection refused) is retried, with RetryBackoffMs doubling between
+	// attempts. Defaults to 1 retry after 200ms.
+	Retries        *int `yaml:"retries,omitempty"`
+	RetryBackoffMs int  `yaml:"retry_backoff_ms,omitempty"`
+	// Breaker fails fast while the backend keeps failing.
+	Breaker BreakerConfig `yaml:"breaker,omitempty"`
+	// HealthCheck selects the active health check: cryptalias (the
+	// WalletService Health RPC, default for internal and external), grpc
+	// (grpc.health.v1, external only) or none (default for http and exec).
+	HealthCheck string `yaml:"health_check,omitempty"`
+	// ProtocolVersion selects the WalletService version: auto (default for
+	// external, negotiated per endpoint), v1 or v2. http and exec endpoints
+	// default to v1 and send the v2 request fields only with v2.
+	ProtocolVersion string `yaml:"protocol_version,omitempty"`
+	// TLS secures external gRPC endpoints.
+	TLS EndpointTLSConfig `yaml:"tls,omitempty"`
+	// AllowInsecureCredentials permits sending token/username/password to a
+	// non-loopback external endpoint without TLS.
+	AllowInsecureCredentials bool `yaml:"allow_insecure_credentials,omitempty"`
+	// Wallets are named wallet definitions selected by an alias wallet_id hint.
+	// Unset fields fall back to the endpoint's own settings.
+	Wallets []InternalWalletConfig `yaml:"wallets,omitempty"`
+}
+
+// HTTPEndpointConfig describes how Cryptalias calls an HTTP/JSON wallet
+// webhook and reads its response. Paths are dotted, e.g. "data.address".
+type HTTPEndpointConfig struct {
+	Headers            map[string]string `yaml:"headers,omitempty"`
+	AddressPath        string            `yaml:"address_path,omitempty"`
+	MemoPath           string            `yaml:"memo_path,omitempty"`
+	DestinationTagPath string            `yaml:"destination_tag_path,omitempty"`
+	PaymentIDPath      string            `yaml:"payment_id_path,omitempty"`
+	ExpiresInPath      string            `yaml:"expires_in_path,omitempty"`
+	ReferencePath      string            `yaml:"reference_path,omitempty"`
+	TimeoutSeconds     int               `yaml:"timeout_seconds,omitempty"`
+	// HMACSecret signs each request body; see X-Cryptalias-Signature.
+	HMACSecret string `yaml:"hmac_secret,omitempty"`
+}
+
+func (h HTTPEndpointConfig) TimeoutSecondsOrDefault() int {
+	if h.TimeoutSeconds <= 0 {
+		return defaultHTTPTimeoutSeconds
+	}
+	return h.TimeoutSeconds
+}
+
+// ExecEndpointConfig describes a plugin command that reads a JSON
+// WalletAddressRequest on stdin and writes a WalletAddressResponse to stdout.
+type ExecEndpointConfig struct {
+	Args []string `yaml:"args,omitempty"`
+	// Env is the plugin's entire environment (plus a default PATH); nothing
+	// is inherited from Cryptalias.
+	Env            map[string]string `yaml:"env,omitempty"`
+	Dir            string            `yaml:"dir,omitempty"`
+	TimeoutSeconds int               `yaml:"timeout_seconds,omitempty"`
+	// Mode is oneshot (default, one process per request) or persistent (one
+	// long-running process speaking line-delimited JSON).
+	Mode string `yaml:"mode,omitempty"`
+}
+
+func (e ExecEndpointConfig) TimeoutSecondsOrDefault() int {
+	if e.TimeoutSeconds <= 0 {
+		return defaultExecTimeoutSeconds
+	}
+	return e.TimeoutSeconds
+}
+
+// MaxConcurrencyOrDefault returns the in-flight limit for a token's backend.
+func (e TokenEndpointConfig) MaxConcurrencyOrDefault() int {
+	if e.MaxConcurrency > 0 {
+		return e.MaxConcurrency
+	}
+	if e.EndpointType == TokenEndpointTypeExec {
+		return 4
+	}
+	return 0
+}
+
+// TimeoutOrDefault returns the deadline applied to each backend call.
+func (e TokenEndpointConfig) TimeoutOrDefault() time.Duration {
+	if e.TimeoutSeconds <= 0 {
+		return defaultBackendTimeoutSeconds * time.Second
+	}
+	return time.Duration(e.TimeoutSeconds) * time.Second
+}
+
+// RetriesOrDefault returns how many times a retryable failure is retried.
+func (e TokenEndpointConfig) RetriesOrDefault() int {
+	if e.Retries == nil {
+		return defaultBackendRetries
+	}
+	return *e.Retries
+}
+
+func (e TokenEndpointConfig) retryBackoff(attempt int) time.Duration {
+	base := e.RetryBackoffMs
+	if base <= 0 {
+		base = defaultBackendRetryBackoffMs
+	}
+	return time.Duration(base<<attempt) * time.Millisecond
+}
+
+func (e *TokenEndpointConfig) normalize() {
+	e.Name = strings.TrimSpace(e.Name)
+	e.AddressMode = strings.ToLower(strings.TrimSpace(e.AddressMode))
+	e.WalletSession = strings.ToLower(strings.TrimSpace(e.WalletSession))
+	e.Integration = strings.ToLower(strings.TrimSpace(e.Integration))
+	e.AddressType = strings.ToLower(strings.TrimSpace(e.AddressType))
+	e.Exec.Mode = strings.ToLower(strings.TrimSpace(e.Exec.Mode))
+	e.HealthCheck = strings.ToLower(strings.TrimSpace(e.HealthCheck))
+	e.ProtocolVersion = strings.ToLower(strings.TrimSpace(e.ProtocolVersion))
+	for w := range e.Wallets {
+		e.Wallets[w].ID = strings.TrimSpace(e.Wallets[w].ID)
+	}
+}
+
+func (e TokenEndpointConfig) weightOrDefault() int {
+	if e.Weight <= 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// InternalWalletConfig names a wallet file, optionally on its own wallet RPC,
+// so aliases can be routed to it with wallet_id.
+type InternalWalletConfig struct {
+	ID             string `yaml:"id"`
+	Address        string `yaml:"address,omitempty"`
+	Username       string `yaml:"username,omitempty"`
+	Password       string `yaml:"password,omitempty"`
+	WalletFile     string `yaml:"wallet_file,omitempty"`
+	WalletPassword string `yaml:"wallet_password,omitempty"`
+	WalletFilePath string `yaml:"wallet_file_path,omitempty"`
+}
+
+func (e TokenEndpointConfig) Clone() TokenEndpointConfig {
+	out := e
+	out.Wallets = append([]InternalWalletConfig(nil), e.Wallets...)
+	if e.Retries != nil {
+		retries := *e.Retries
+		out.Retries = &retries
+	}
+	out.TLS.PinSHA256 = append([]string(nil), e.TLS.PinSHA256...)
+	out.Exec.Args = append([]string(nil), e.Exec.Args...)
+	if e.Exec.Env != nil {
+		out.Exec.Env = make(map[string]string, len(e.Exec.Env))
+		for k, v := range e.Exec.Env {
+			out.Exec.Env[k] = v
+		}
+	}
+	if e.HTTP.Headers != nil {
+		out.HTTP.Headers = make(map[string]string, len(e.HTTP.Headers))
+		for k, v := range e.HTTP.Headers {
+			out.HTTP.Headers[k] = v
+		}
+	}
+	return out
+}
+
+// findWallet returns the named wallet definition for a wallet_id hint.
+func (e TokenEndpointConfig) findWallet(id string) (InternalWalletConfig, bool) {
+	for _, w := range e.Wallets {
+		if w.ID == id {
+			return w, true
+		}
+	}
+	return InternalWalletConfig{}, false
+}
+
+const (
+	WalletSessionPerRequest = "per_request"
+	WalletSessionPersistent = "persistent"
+)
+
+const (
+	AddressModeSubaddress = "subaddress"
+	AddressModeIntegrated = "integrated"
+)
+
+func validAddressMode(mode string) bool {
+	switch mode {
+	case "", AddressModeSubaddress, AddressModeIntegrated:
+		return true
+	default:
+		return false
+	}
 }
 
 func boolPtr(v bool) *bool {
 	return &v
 }
 
+func normalizeWalletAlias(a *WalletAlias) {
+	a.Alias = strings.ToLower(strings.TrimSpace(a.Alias))
+	a.Wallet.Ticker = strings.ToLower(a.Wallet.Ticker)
+	normalizeWalletAddress(&a.Wallet)
+	normalizeLightning(a.Lightning)
+	for t := range a.Tags {
+		a.Tags[t].Tag = strings.ToLower(a.Tags[t].Tag)
+		a.Tags[t].Wallet.Ticker = strings.ToLower(a.Tags[t].Wallet.Ticker)
+		normalizeWalletAddress(&a.Tags[t].Wallet)
+	}
+}
+
 func normalizeWalletAddress(w *WalletAddress) {
 	if w == nil {
 		return
@@ -358,6 +1047,62 @@ func normalizeWalletAddress(w *WalletAddress) {
 		v := strings.TrimSpace(*w.WalletID)
 		w.WalletID = &v
 	}
+	if w.Memo != nil {
+		v := strings.TrimSpace(*w.Memo)
+		w.Memo = &v
+	}
+	if w.PaymentID != nil {
+		v := strings.ToLower(strings.TrimSpace(*w.PaymentID))
+		w.PaymentID = &v
+	}
+	if w.AddressMode != nil {
+		v := strings.ToLower(strings.TrimSpace(*w.AddressMode))
+		w.AddressMode = &v
+	}
+}
+
+// validateDestinationFields checks chain-specific destination fields that can
+// be validated without knowing the chain.
+func validateDestinationFields(w WalletAddress) error {
+	if w.PaymentID != nil && *w.PaymentID != "" {
+		id := *w.PaymentID
+		if len(id) != 16 && len(id) != 64 {
+			return fmt.Errorf("payment_id must be 16 or 64 hex characters")
+		}
+		if _, err := hex.DecodeString(id); err != nil {
+			return fmt.Errorf("payment_id must be hex")
+		}
+	}
+	if w.Memo != nil && len(*w.Memo) > 512 {
+		return fmt.Errorf("memo must be at most 512 bytes")
+	}
+	if w.AddressMode != nil && !validAddressMode(*w.AddressMode) {
+		return fmt.Errorf("address_mode must be one of: subaddress, integrated")
+	}
+	return nil
+}
+
+const (
+	defaultLightningTicker          = "ln"
+	defaultLightningMinSendableMsat = 1000
+	defaultLightningMaxSendableMsat = 100_000_000_000
+)
+
+func normalizeLightning(ln *LightningConfig) {
+	if ln == nil {
+		return
+	}
+	ln.Ticker = strings.ToLower(strings.TrimSpace(ln.Ticker))
+	if ln.Ticker == "" {
+		ln.Ticker = defaultLightningTicker
+	}
+	if ln.MinSendableMsat == 0 {
+		ln.MinSendableMsat = defaultLightningMinSendableMsat
+	}
+	if ln.MaxSendableMsat == 0 {
+		ln.MaxSendableMsat = defaultLightningMaxSendableMsat
+	}
+	ln.Description = strings.TrimSpace(ln.Description)
 }
 
 func LoadOrCreateConfig(path string, defaultCfg *Config) (*Config, error) {
@@ -32,14 +32,14 @@ func testConfig(t *testing.T) *Config {
 						Alias: "Demo",
 						Wallet: WalletAddress{
 							Ticker:  "XMR",
-							Address: "addr-root",
+							Address: testMoneroAddress(t, 1),
 						},
 						Tags: []WalletTag{
 							{
 								Tag: "Tip",
 								Wallet: WalletAddress{
 									Ticker:  "XMR",
-									Address: "addr-tag",
+									Address: testMoneroAddress(t, 2),
 								},
 							},
 						},
@@ -91,33 +91,75 @@ func TestValidateRejectsInvalidLogLevel(t *testing.T) {
 	}
 }
 
+func TestValidateRejectsUnknownWalletID(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Tokens[0].Endpoint.EndpointType = TokenEndpointTypeInternal
+	cfg.Tokens[0].Endpoint.Wallets = []InternalWalletConfig{{ID: "business", WalletFile: "business"}}
+	walletID := "business"
+	cfg.Domains[0].Aliases[0].Wallet.WalletID = &walletID
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected known wallet_id to pass validation: %v", err)
+	}
+
+	missing := "personal"
+	cfg.Domains[0].Aliases[0].Wallet.WalletID = &missing
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected unknown wallet_id to fail validation")
+	}
+}
+
+func TestValidateInternalIntegration(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Tokens = append(cfg.Tokens, TokenConfig{
+		Name:    "Wownero",
+		Tickers: []string{"wow"},
+		Endpoint: TokenEndpointConfig{
+			EndpointType:    TokenEndpointTypeInternal,
+			EndpointAddress: "http://wownero-wallet-rpc:34568/json_rpc",
+		},
+	})
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected internal endpoint without integration to fail validation")
+	}
+
+	cfg.Tokens[1].Endpoint.Integration = IntegrationMoneroWalletRPC
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected monero-wallet-rpc integration to validate: %v", err)
+	}
+
+	cfg.Tokens[1].Endpoint.Integration = "unknown"
+	if err := cfg.Validate(); err == nil {
+		t.Fatalf("expected unknown integration to fail validation")
+	}
+}
+
 func TestParseAliasResolvesRootAndTag(t *testing.T) {
 	cfg := testConfig(t)
 
-	root, err := ParseAlias("Demo$127.0.0.1", "XMR", cfg)
+	root, err := ParseAlias("Demo$127.0.0.1", "XMR", cfg, NewConfigStore("", cfg))
 	if err != nil {
 		t.Fatalf("parse root alias: %v", err)
 	}
-	if root.Wallet.Address != "addr-root" {
-		t.Fatalf("expected root address addr-root, got %q", root.Wallet.Address)
+	if root.Wallet.Address != testMoneroAddress(t, 1) {
+		t.Fatalf("expected root address, got %q", root.Wallet.Address)
 	}
 	if root.SigningKey == nil {
 		t.Fatalf("expected signing key to be attached")
 	}
 
-	tagged, err := ParseAlias("demo+tip$127.0.0.1", "xmr", cfg)
+	tagged, err := ParseAlias("demo+tip$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg))
 	if err != nil {
 		t.Fatalf("parse tagged alias: %v", err)
 	}
-	if tagged.Wallet.Address != "addr-tag" {
-		t.Fatalf("expected tagged address addr-tag, got %q", tagged.Wallet.Address)
+	if tagged.Wallet.Address != testMoneroAddress(t, 2) {
+		t.Fatalf("expected tagged address, got %q", tagged.Wallet.Address)
 	}
 }
 
 func TestParseAliasUnknownTicker(t *testing.T) {
 	cfg := testConfig(t)
 
-	if _, err := ParseAlias("demo$127.0.0.1", "btc", cfg); err == nil {
+	if _, err := ParseAlias("demo$127.0.0.1", "btc", cfg, NewConfigStore("", cfg)); err == nil {
 		t.Fatalf("expected unknown ticker to return an error")
 	}
 }
@@ -125,19 +167,19 @@ func TestParseAliasUnknownTicker(t *testing.T) {
 func TestParseAliasAcceptsTickerPrefix(t *testing.T) {
 	cfg := testConfig(t)
 
-	alias, err := ParseAlias("xmr:demo$127.0.0.1", "xmr", cfg)
+	alias, err := ParseAlias("xmr:demo$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg))
 	if err != nil {
 		t.Fatalf("parse alias with prefix: %v", err)
 	}
-	if alias.Wallet.Address != "addr-root" {
-		t.Fatalf("expected root address addr-root, got %q", alias.Wallet.Address)
+	if alias.Wallet.Address != testMoneroAddress(t, 1) {
+		t.Fatalf("expected root address, got %q", alias.Wallet.Address)
 	}
 }
 
 func TestParseAliasTickerPrefixMismatch(t *testing.T) {
 	cfg := testConfig(t)
 
-	if _, err := ParseAlias("btc:demo$127.0.0.1", "xmr", cfg); err == nil {
+	if _, err := ParseAlias("btc:demo$127.0.0.1", "xmr", cfg, NewConfigStore("", cfg)); err == nil {
 		t.Fatalf("expected prefix mismatch to return an error")
 	}
 }
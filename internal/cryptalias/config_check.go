@@ -0,0 +1,369 @@
+package cryptalias
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// CheckConfig loads, normalizes and validates the config at path like a
+// reload would, without writing anything. Keys missing from a domain are
+// left empty in the result and reported as a warning; unknown fields are
+// warnings too, since they are ignored at runtime.
+func CheckConfig(path string) (*Config, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, err
+	}
+	var warnings []string
+	if err := yaml.UnmarshalStrict(data, &Config{}); err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	// Stand-in keys let Normalize and Validate run without generating and
+	// persisting real ones.
+	var pending []int
+	for i := range cfg.Domains {
+		d := &cfg.Domains[i]
+		if len(d.PrivateKey) > 0 || len(d.PublicKey) > 0 {
+			continue
+		}
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, warnings, err
+		}
+		d.PrivateKey, d.PublicKey = PrivateKey(priv), PublicKey(pub)
+		pending = append(pending, i)
+		warnings = append(warnings, fmt.Sprintf("domains[%d] (%s) has no keys; they will be generated on first start", i, d.Domain))
+	}
+	cfg.Normalize(path)
+	if err := cfg.Validate(); err != nil {
+		return &cfg, warnings, err
+	}
+	for _, i := range pending {
+		cfg.Domains[i].PrivateKey, cfg.Domains[i].PublicKey = nil, nil
+	}
+	return &cfg, warnings, nil
+}
+
+// ConfigChange is one semantic difference between two configs.
+type ConfigChange struct {
+	// Kind is added, removed or changed.
+	Kind string `json:"kind"`
+	// Scope is setting, domain, key, alias, tag, routing or token.
+	Scope  string `json:"scope"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func (c ConfigChange) String() string {
+	sign := map[string]string{"added": "+", "removed": "-", "changed": "~"}[c.Kind]
+	s := sign + " " + c.Scope + " " + c.Name
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+type configDiff []ConfigChange
+
+func (d *configDiff) add(kind, scope, name, detail string) {
+	*d = append(*d, ConfigChange{Kind: kind, Scope: scope, Name: name, Detail: detail})
+}
+
+// DiffConfigs lists what changes between two normalized configs: settings,
+// domains and their keys, aliases and tags, how they are routed, and tokens.
+// Secret values are never included.
+func DiffConfigs(old, new *Config) []ConfigChange {
+	var d configDiff
+	for _, s := range []struct {
+		name     string
+		old, new any
+	}{
+		{"base_url", old.BaseURL, new.BaseURL},
+		{"public_port", old.PublicPort, new.PublicPort},
+		{"logging", old.Logging, new.Logging},
+		{"rate_limit", old.RateLimit, new.RateLimit},
+		{"resolution", old.Resolution, new.Resolution},
+		{"verify", old.Verify, new.Verify},
+		{"wallet_health", old.WalletHealth, new.WalletHealth},
+		{"payment_watch", old.PaymentWatch, new.PaymentWatch},
+		{"webhook_delivery", old.WebhookDelivery, new.WebhookDelivery},
+		{"alias_store", old.AliasStore, new.AliasStore},
+		{"admin", old.Admin, new.Admin},
+	} {
+		if !sameYAML(s.old, s.new) {
+			d.add("changed", "setting", s.name, "")
+		}
+	}
+
+	oldDomains, newDomains := map[string]AliasDomainConfig{}, map[string]AliasDomainConfig{}
+	for _, dc := range old.Domains {
+		oldDomains[dc.Domain] = dc
+	}
+	for _, dc := range new.Domains {
+		newDomains[dc.Domain] = dc
+	}
+	for _, name := range unionKeys(oldDomains, newDomains) {
+		od, inOld := oldDomains[name]
+		nd, inNew := newDomains[name]
+		switch {
+		case !inOld:
+			d.add("added", "domain", name, "")
+			d.diffAliases(name, nil, nd.Aliases)
+		case !inNew:
+			d.add("removed", "domain", name, fmt.Sprintf("%d aliases", len(od.Aliases)))
+		default:
+			d.diffDomain(od, nd)
+		}
+	}
+
+	oldTokens, newTokens := map[string]TokenConfig{}, map[string]TokenConfig{}
+	for _, t := range old.Tokens {
+		oldTokens[t.Name] = t
+	}
+	for _, t := range new.Tokens {
+		newTokens[t.Name] = t
+	}
+	for _, name := range unionKeys(oldTokens, newTokens) {
+		ot, inOld := oldTokens[name]
+		nt, inNew := newTokens[name]
+		switch {
+		case !inOld:
+			d.add("added", "token", name, strings.Join(nt.Tickers, ", "))
+		case !inNew:
+			d.add("removed", "token", name, strings.Join(ot.Tickers, ", "))
+		default:
+			if changes := tokenChanges(ot, nt); len(changes) > 0 {
+				d.add("changed", "token", name, strings.Join(changes, ", "))
+			}
+		}
+	}
+	return d
+}
+
+func (d *configDiff) diffDomain(od, nd AliasDomainConfig) {
+	name := nd.Domain
+	switch {
+	case len(nd.PublicKey) == 0 && len(od.PublicKey) > 0:
+		d.add("changed", "key", name, "keys removed; new ones will be generated on first start")
+	case !bytes.Equal(od.PublicKey, nd.PublicKey):
+		d.add("changed", "key", name, "public key changed; publish "+nd.DNSTXTRecord())
+	case !bytes.Equal(od.PrivateKey, nd.PrivateKey):
+		d.add("changed", "key", name, "private key changed")
+	}
+	if !sameYAML(od.Webhooks, nd.Webhooks) {
+		d.add("changed", "setting", name+" webhooks", "")
+	}
+	if !sameYAML(od.AliasProvider, nd.AliasProvider) {
+		d.add("changed", "routing", name+" alias provider", aliasProviderLabel(od.AliasProvider)+" -> "+aliasProviderLabel(nd.AliasProvider))
+	}
+	d.diffAliases(name, od.Aliases, nd.Aliases)
+}
+
+// diffAliases compares alias entries by alias name and root ticker, and
+// their tags by tag name and ticker.
+func (d *configDiff) diffAliases(domain string, old, new []WalletAlias) {
+	key := func(a WalletAlias) string { return a.Alias + "/" + a.Wallet.Ticker }
+	oldAliases, newAliases := map[string]WalletAlias{}, map[string]WalletAlias{}
+	for _, a := range old {
+		oldAliases[key(a)] = a
+	}
+	for _, a := range new {
+		newAliases[key(a)] = a
+	}
+	for _, k := range unionKeys(oldAliases, newAliases) {
+		oa, inOld := oldAliases[k]
+		na, inNew := newAliases[k]
+		switch {
+		case !inOld:
+			d.add("added", "alias", aliasLabel(na.Alias, "", domain, na.Wallet.Ticker), walletLabel(na.Wallet))
+			continue
+		case !inNew:
+			d.add("removed", "alias", aliasLabel(oa.Alias, "", domain, oa.Wallet.Ticker), "")
+			continue
+		}
+		name := aliasLabel(na.Alias, "", domain, na.Wallet.Ticker)
+		if changes := walletChanges(oa.Wallet, na.Wallet); len(changes) > 0 {
+			d.add("changed", "routing", name, strings.Join(changes, ", "))
+		}
+		if !sameYAML(oa.Lightning, na.Lightning) {
+			d.add("changed", "routing", name, "lightning settings changed")
+		}
+
+		tagKey := func(t WalletTag) string { return t.Tag + "/" + t.Wallet.Ticker }
+		oldTags, newTags := map[string]WalletTag{}, map[string]WalletTag{}
+		for _, t := range oa.Tags {
+			oldTags[tagKey(t)] = t
+		}
+		for _, t := range na.Tags {
+			newTags[tagKey(t)] = t
+		}
+		for _, tk := range unionKeys(oldTags, newTags) {
+			ot, inOld := oldTags[tk]
+			nt, inNew := newTags[tk]
+			switch {
+			case !inOld:
+				d.add("added", "tag", aliasLabel(na.Alias, nt.Tag, domain, nt.Wallet.Ticker), walletLabel(nt.Wallet))
+			case !inNew:
+				d.add("removed", "tag", aliasLabel(na.Alias, ot.Tag, domain, ot.Wallet.Ticker), "")
+			default:
+				if changes := walletChanges(ot.Wallet, nt.Wallet); len(changes) > 0 {
+					d.add("changed", "routing", aliasLabel(na.Alias, nt.Tag, domain, nt.Wallet.Ticker), strings.Join(changes, ", "))
+				}
+			}
+		}
+	}
+}
+
+// walletChanges describes changed address, routing hint and destination
+// fields.
+func walletChanges(old, new WalletAddress) []string {
+	var out []string
+	cmp := func(field, a, b string) {
+		if a != b {
+			out = append(out, fmt.Sprintf("%s %s -> %s", field, a, b))
+		}
+	}
+	cmp("address", walletLabel(old), walletLabel(new))
+	cmp("account_index", optUint(old.AccountIndex), optUint(new.AccountIndex))
+	cmp("account_id", optString(old.AccountID), optString(new.AccountID))
+	cmp("wallet_id", optString(old.WalletID), optString(new.WalletID))
+	cmp("address_mode", optString(old.AddressMode), optString(new.AddressMode))
+	cmp("memo", optString(old.Memo), optString(new.Memo))
+	cmp("payment_id", optString(old.PaymentID), optString(new.PaymentID))
+	var oldTag, newTag *uint64
+	if old.DestinationTag != nil {
+		v := uint64(*old.DestinationTag)
+		oldTag = &v
+	}
+	if new.DestinationTag != nil {
+		v := uint64(*new.DestinationTag)
+		newTag = &v
+	}
+	cmp("destination_tag", optUint(oldTag), optUint(newTag))
+	return out
+}
+
+// tokenChanges describes how a token's tickers and backends changed without
+// showing endpoint settings, which may hold credentials.
+func tokenChanges(old, new TokenConfig) []string {
+	var out []string
+	if strings.Join(old.Tickers, ",") != strings.Join(new.Tickers, ",") {
+		out = append(out, fmt.Sprintf("tickers [%s] -> [%s]", strings.Join(old.Tickers, " "), strings.Join(new.Tickers, " ")))
+	}
+	if old.Chain != new.Chain || old.Network != new.Network {
+		out = append(out, fmt.Sprintf("chain/network %s/%s -> %s/%s", old.Chain, old.NetworkOrDefault(), new.Chain, new.NetworkOrDefault()))
+	}
+	if old.Sticky != new.Sticky {
+		out = append(out, fmt.Sprintf("sticky %t -> %t", old.Sticky, new.Sticky))
+	}
+	oe, ne := old.Endpoint, new.Endpoint
+	switch {
+	case oe.EndpointType != ne.EndpointType || oe.EndpointAddress != ne.EndpointAddress:
+		out = append(out, fmt.Sprintf("endpoint %s %s -> %s %s", oe.EndpointType, redactAddress(oe.EndpointAddress), ne.EndpointType, redactAddress(ne.EndpointAddress)))
+	case !sameYAML(oe, ne):
+		out = append(out, "endpoint settings changed")
+	}
+	if !sameYAML(old.Endpoints, new.Endpoints) {
+		out = append(out, fmt.Sprintf("endpoints changed (%d -> %d)", len(old.Endpoints), len(new.Endpoints)))
+	}
+	return out
+}
+
+func aliasLabel(alias, tag, domain, ticker string) string {
+	if tag != "" {
+		alias += "+" + tag
+	}
+	return fmt.Sprintf("%s$%s (%s)", alias, domain, ticker)
+}
+
+func walletLabel(w WalletAddress) string {
+	if w.Address == "" {
+		return "dynamic"
+	}
+	return w.Address
+}
+
+func aliasProviderLabel(p *AliasProviderConfig) string {
+	if p == nil {
+		return "none"
+	}
+	return redactAddress(p.Address)
+}
+
+// redactAddress drops the userinfo of an endpoint URL and redacts query
+// parameters named like the secrets the admin API hides, so change
+// summaries can be logged.
+func redactAddress(address string) string {
+	// Bare host:port addresses (gRPC) are parsed as network paths.
+	bare := !strings.Contains(address, "://") && !strings.HasPrefix(address, "/")
+	raw := address
+	if bare {
+		raw = "//" + address
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedSecret
+	}
+	u.User = nil
+	if u.RawQuery != "" {
+		query := u.Query()
+		for k := range query {
+			if secretConfigKeys[strings.ToLower(k)] {
+				query[k] = []string{redactedSecret}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	if bare {
+		return strings.TrimPrefix(u.String(), "//")
+	}
+	return u.String()
+}
+
+func optString(v *string) string {
+	if v == nil {
+		return "none"
+	}
+	return strconv.Quote(*v)
+}
+
+func optUint(v *uint64) string {
+	if v == nil {
+		return "none"
+	}
+	return strconv.FormatUint(*v, 10)
+}
+
+func sameYAML(a, b any) bool {
+	x, errA := yaml.Marshal(a)
+	y, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(x, y)
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
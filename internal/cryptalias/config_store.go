@@ -1,26 +1,87 @@
 package cryptalias
 
 import (
+	"crypto/sha256"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type ConfigStore struct {
 	mu   sync.RWMutex
 	cfg  *Config
 	path string
+	// revision counts the configs applied since start.
+	revision uint64
+	// secretRevision changes when any secret in the config changes. It is
+	// read without mu, since admin preconditions run inside Update.
+	secretRevision atomic.Uint64
+	secrets        [sha256.Size]byte
+
+	// index serves alias lookups for cfg; aliases overrides it with another
+	// AliasStore such as SQLite.
+	index   *aliasIndex
+	aliases AliasStore
 }
 
 // NewConfigStore creates a threadsafe config holder that also knows its path,
 // enabling Save/Update to persist without callers passing paths around.
 func NewConfigStore(path string, cfg *Config) *ConfigStore {
-	return &ConfigStore{
+	s := &ConfigStore{
 		path: path,
 		cfg:  cfg.Clone(),
 	}
+	s.index = newAliasIndex(s.cfg)
+	s.secrets = configSecretsDigest(s.cfg)
+	// Start from the clock so ETags issued before a restart never match.
+	s.secretRevision.Store(uint64(time.Now().UnixNano()))
+	return s
+}
+
+// UseAliasStore routes alias lookups and edits to another store.
+func (s *ConfigStore) UseAliasStore(aliases AliasStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aliases = aliases
+}
+
+// Aliases returns the active alias store: the one set with UseAliasStore, or
+// the config itself.
+func (s *ConfigStore) Aliases() AliasStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.aliases != nil {
+		return s.aliases
+	}
+	return s
+}
+
+// Revision changes whenever a config is applied, e.g. on reload.
+func (s *ConfigStore) Revision() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.revision
+}
+
+// SecretRevision changes whenever a secret in the config changes, so admin
+// ETags can cover secrets without revealing anything about them.
+func (s *ConfigStore) SecretRevision() uint64 {
+	return s.secretRevision.Load()
+}
+
+// applyLocked swaps in a new config. s.mu must be held.
+func (s *ConfigStore) applyLocked(cfg *Config) {
+	s.cfg = cfg
+	s.index = newAliasIndex(cfg)
+	s.revision++
+	if secrets := configSecretsDigest(cfg); secrets != s.secrets {
+		s.secrets = secrets
+		s.secretRevision.Add(1)
+	}
 }
 
 // Get returns a defensive clone so callers cannot mutate shared state.
@@ -40,8 +101,12 @@ func (s *ConfigStore) Set(cfg *Config) error {
 		return err
 	}
 	s.mu.Lock()
-	s.cfg = cfg.Clone()
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+	// The alias store is opened once at startup.
+	if !sameAliasStore(s.cfg, cfg, s.path) {
+		return errRestartRequired
+	}
+	s.applyLocked(cfg.Clone())
 	slog.Debug("config applied in memory", "path", s.path)
 	return nil
 }
@@ -57,10 +122,13 @@ func (s *ConfigStore) Save(cfg *Config) error {
 	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if !sameAliasStore(s.cfg, cfg, s.path) {
+		return errRestartRequired
+	}
 	if err := SaveConfig(s.path, cfg); err != nil {
 		return err
 	}
-	s.cfg = cfg.Clone()
+	s.applyLocked(cfg.Clone())
 	slog.Info("config saved", "path", s.path)
 	return nil
 }
@@ -99,14 +167,40 @@ func (s *ConfigStore) Update(fn func(*Config) error) error {
 	if err := next.Validate(); err != nil {
 		return err
 	}
+	if !sameAliasStore(s.cfg, next, s.path) {
+		return errRestartRequired
+	}
 	if err := SaveConfig(s.path, next); err != nil {
 		return err
 	}
-	s.cfg = next
+	s.applyLocked(next)
 	slog.Info("config updated", "path", s.path)
 	return nil
 }
 
+// Check applies fn to a copy of the config and validates the result without
+// saving or applying it, e.g. for dry runs. fn may be run again by Update.
+func (s *ConfigStore) Check(fn func(*Config) error) (*Config, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("update function is nil")
+	}
+	next := s.Get()
+	if err := fn(next); err != nil {
+		return nil, err
+	}
+	// Generate missing domain keys up front so Normalize has nothing to persist.
+	for i := range next.Domains {
+		if _, err := next.Domains[i].GenerateKeys(); err != nil {
+			return nil, err
+		}
+	}
+	next.Normalize(s.path)
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
 // saveConfigAtomic writes to a temp file in the same directory and renames it,
 // so readers never observe partial writes and fsnotify sees a clean replace.
 func saveConfigAtomic(path string, data []byte) error {
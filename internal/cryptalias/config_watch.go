@@ -1,6 +1,7 @@
 package cryptalias
 
 import (
+	"errors"
 	"log/slog"
 	"path/filepath"
 
@@ -59,7 +60,10 @@ func WatchConfigFile(path string, store *ConfigStore) (*fsnotify.Watcher, error)
 						slog.Warn("config reload rejected", "path", path, "error", err)
 						continue
 					}
-					if err := store.Set(cfg); err != nil {
+					if err := store.Set(cfg); errors.Is(err, errRestartRequired) {
+						slog.Warn("config reload rejected; restart Cryptalias to apply it", "path", path, "error", err)
+						continue
+					} else if err != nil {
 						slog.Error("config apply failed", "path", path, "error", err)
 						continue
 					}
@@ -28,17 +28,19 @@ var (
 type domainVerifier struct {
 	store    *ConfigStore
 	statuses *DomainStatusStore
+	webhooks *WebhookDispatcher
 	client   *http.Client
 	interval time.Duration
 }
 
-func newDomainVerifier(store *ConfigStore, statuses *DomainStatusStore, interval time.Duration) *domainVerifier {
+func newDomainVerifier(store *ConfigStore, statuses *DomainStatusStore, webhooks *WebhookDispatcher, interval time.Duration) *domainVerifier {
 	if interval <= 0 {
 		interval = defaultDomainVerifyInterval
 	}
 	return &domainVerifier{
 		store:    store,
 		statuses: statuses,
+		webhooks: webhooks,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -56,6 +58,9 @@ func (v *domainVerifier) Start(ctx context.Context) {
 		v.statuses.Reconcile(cfg)
 		for _, domainCfg := range cfg.Domains {
 			status := v.verifyDomain(ctx, cfg, domainCfg)
+			if prev, ok := v.statuses.Get(status.Domain); ok && prev.Healthy != status.Healthy {
+				v.webhooks.Emit(newWebhookEvent(EventDomainHealthChanged, status.Domain, "", "", status))
+			}
 			v.statuses.Update(status)
 			if status.Healthy {
 				slog.Info("domain verification ok", "domain", status.Domain)
@@ -56,7 +56,7 @@ func TestDomainVerifierHealthy(t *testing.T) {
 		lookupTXT = origLookupTXT
 	})
 
-	verifier := newDomainVerifier(store, statuses, 0)
+	verifier := newDomainVerifier(store, statuses, nil, 0)
 	status := verifier.verifyDomain(context.Background(), cfg, cfg.Domains[0])
 	if !status.Healthy {
 		t.Fatalf("expected healthy domain, got unhealthy: %s", status.Message)
@@ -81,7 +81,7 @@ func TestAliasResolverHandlerGatesUnhealthyDomain(t *testing.T) {
 	req.SetPathValue("alias", "demo$127.0.0.1")
 	rr := httptest.NewRecorder()
 
-	AliasResolverHandler(store, resolver, statuses).ServeHTTP(rr, req)
+	AliasResolverHandler(store, resolver, statuses, nil).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusServiceUnavailable {
 		t.Fatalf("expected 503, got %d: %s", rr.Code, rr.Body.String())
@@ -114,3 +114,8 @@ func (r *gateTestResolver) Resolve(context.Context, *Config, dynamicAliasInput)
 	r.called = true
 	return WalletAddress{}, nil
 }
+
+func (r *gateTestResolver) LookupAlias(context.Context, *Config, AliasDomainConfig, string, string, string) (WalletAddress, error) {
+	r.called = true
+	return WalletAddress{}, ErrAliasNotFound
+}
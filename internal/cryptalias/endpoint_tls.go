@@ -0,0 +1,169 @@
+package cryptalias
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// EndpointTLSConfig secures connections to external gRPC wallet services.
+type EndpointTLSConfig struct {
+	// Enabled turns on TLS with the system roots. Setting any other field
+	// implies it.
+	Enabled bool `yaml:"enabled,omitempty"`
+	// CAFile is a PEM bundle used instead of the system roots.
+	CAFile string `yaml:"ca_file,omitempty"`
+	// CertFile/KeyFile present a client certificate (mTLS).
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// ServerName overrides the name verified in the server certificate.
+	ServerName string `yaml:"server_name,omitempty"`
+	// PinSHA256 lists accepted SHA-256 hashes (base64 or hex) of the server
+	// certificate's public key. The chain is still verified.
+	PinSHA256 []string `yaml:"pin_sha256,omitempty"`
+}
+
+func (t EndpointTLSConfig) IsEnabled() bool {
+	return t.Enabled || t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != "" || len(t.PinSHA256) > 0
+}
+
+func (t EndpointTLSConfig) validate() error {
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return fmt.Errorf("cert_file and key_file must be set together")
+	}
+	for _, pin := range t.PinSHA256 {
+		if _, err := decodePin(pin); err != nil {
+			return fmt.Errorf("pin_sha256 %q: %w", pin, err)
+		}
+	}
+	return nil
+}
+
+// fingerprint identifies the TLS settings and the files behind them, so a
+// config edit or a rotated certificate leads to a fresh connection.
+func (t EndpointTLSConfig) fingerprint() string {
+	if !t.IsEnabled() {
+		return "insecure"
+	}
+	parts := []string{"tls", t.ServerName, strings.Join(t.PinSHA256, ",")}
+	for _, path := range []string{t.CAFile, t.CertFile, t.KeyFile} {
+		mod := ""
+		if info, err := os.Stat(path); path != "" && err == nil {
+			mod = info.ModTime().String()
+		}
+		parts = append(parts, path, mod)
+	}
+	return strings.Join(parts, "|")
+}
+
+// clientConfig builds the crypto/tls config for the endpoint.
+func (t EndpointTLSConfig) clientConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.ServerName,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca_file %s contains no certificates", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if len(t.PinSHA256) > 0 {
+		pins := map[string]bool{}
+		for _, p := range t.PinSHA256 {
+			b, err := decodePin(p)
+			if err != nil {
+				return nil, err
+			}
+			pins[string(b)] = true
+		}
+		// VerifyConnection runs after normal chain verification.
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("tls: no server certificate to pin")
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+			if !pins[string(sum[:])] {
+				return fmt.Errorf("tls: server public key does not match pin_sha256")
+			}
+			return nil
+		}
+	}
+	return cfg, nil
+}
+
+func decodePin(pin string) ([]byte, error) {
+	pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+	if b, err := hex.DecodeString(pin); err == nil && len(b) == sha256.Size {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(pin); err == nil && len(b) == sha256.Size {
+		return b, nil
+	}
+	return nil, fmt.Errorf("must be a base64 or hex SHA-256 hash")
+}
+
+// endpointSendsCredentials reports whether auth metadata is attached to calls.
+func endpointSendsCredentials(e TokenEndpointConfig) bool {
+	return e.Token != "" || e.Username != "" || e.Password != ""
+}
+
+// checkCredentialTransport fails closed when credentials would be sent in
+// cleartext to another host. Loopback targets and an explicit
+// allow_insecure_credentials are exempt.
+func checkCredentialTransport(e TokenEndpointConfig) error {
+	if !endpointSendsCredentials(e) || e.TLS.IsEnabled() || e.AllowInsecureCredentials {
+		return nil
+	}
+	target, fix := e.EndpointAddress, "configure endpoint.tls"
+	if e.EndpointType == TokenEndpointTypeHTTP {
+		// http endpoints use the URL scheme rather than endpoint.tls.
+		u, err := url.Parse(e.EndpointAddress)
+		if err == nil && strings.EqualFold(u.Scheme, "https") {
+			return nil
+		}
+		if err == nil {
+			target = u.Host
+		}
+		fix = "use an https:// address"
+	}
+	if isLoopbackTarget(target) {
+		return nil
+	}
+	return fmt.Errorf("credentials for %s would be sent without TLS; %s or set allow_insecure_credentials", redactAddress(e.EndpointAddress), fix)
+}
+
+func isLoopbackTarget(addr string) bool {
+	if strings.HasPrefix(addr, "unix:") {
+		return true
+	}
+	addr = strings.TrimPrefix(addr, "dns:///")
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,191 @@
+package cryptalias
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+)
+
+type staticWalletService struct {
+	cryptaliasv1.UnimplementedWalletServiceServer
+}
+
+func (staticWalletService) GetAddress(context.Context, *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	return &cryptaliasv1.WalletAddressResponse{Address: "addr-tls"}, nil
+}
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+func newTestCert(t *testing.T, dir, name string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{name},
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if isCA {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	tc := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	_ = os.WriteFile(tc.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	_ = os.WriteFile(tc.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return tc
+}
+
+func TestGRPCWalletClientMutualTLSAndPinning(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "test-ca", nil, true)
+	server := newTestCert(t, dir, "wallet.internal", ca, false)
+	client := newTestCert(t, dir, "cryptalias", ca, false)
+
+	serverPair, err := tls.LoadX509KeyPair(server.certFile, server.keyFile)
+	if err != nil {
+		t.Fatalf("load server pair: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	s := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})))
+	cryptaliasv1.RegisterWalletServiceServer(s, staticWalletService{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	pin := sha256.Sum256(server.cert.RawSubjectPublicKeyInfo)
+	endpoint := TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeExternal,
+		EndpointAddress: lis.Addr().String(),
+		Token:           "secret",
+		TLS: EndpointTLSConfig{
+			CAFile:     ca.certFile,
+			CertFile:   client.certFile,
+			KeyFile:    client.keyFile,
+			ServerName: "wallet.internal",
+			PinSHA256:  []string{base64.StdEncoding.EncodeToString(pin[:])},
+		},
+	}
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := newGRPCWalletClient()
+	resp, err := c.GetAddress(ctx, endpoint, in)
+	if err != nil {
+		t.Fatalf("get address over mTLS: %v", err)
+	}
+	if resp.GetAddress() != "addr-tls" {
+		t.Fatalf("unexpected address %q", resp.GetAddress())
+	}
+
+	// Another endpoint on the same address with a different pin gets its own
+	// connection and must be rejected, without touching the first one.
+	first := c.conns[endpoint.EndpointAddress+"\x00"+endpoint.TLS.fingerprint()]
+	other := endpoint
+	wrong := sha256.Sum256([]byte("other key"))
+	other.TLS.PinSHA256 = []string{base64.StdEncoding.EncodeToString(wrong[:])}
+	if _, err := c.GetAddress(ctx, other, in); err == nil {
+		t.Fatalf("expected pin mismatch to fail")
+	}
+	if _, err := c.GetAddress(ctx, endpoint, in); err != nil {
+		t.Fatalf("expected the first endpoint to keep working: %v", err)
+	}
+	if len(c.conns) != 2 || first.retired || first.conn.GetState() == connectivity.Shutdown {
+		t.Fatalf("expected both connections to stay open, got %d", len(c.conns))
+	}
+
+	// Replaced certificate files are picked up on the next config revision,
+	// and the old connection is closed.
+	revision := uint64(0)
+	c.revision = func() uint64 { return revision }
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(client.certFile, later, later); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if _, err := c.GetAddress(ctx, endpoint, in); err != nil || first.retired {
+		t.Fatalf("expected the connection to be kept until a reload: %v", err)
+	}
+	revision++
+	if _, err := c.GetAddress(ctx, endpoint, in); err != nil {
+		t.Fatalf("get address after reload: %v", err)
+	}
+	if !first.retired || first.conn.GetState() != connectivity.Shutdown || len(c.conns) != 2 {
+		t.Fatalf("expected the superseded connection to be closed, got %d connections", len(c.conns))
+	}
+}
+
+func TestCheckCredentialTransport(t *testing.T) {
+	cases := []struct {
+		name    string
+		ep      TokenEndpointConfig
+		wantErr bool
+	}{
+		{"no credentials", TokenEndpointConfig{EndpointAddress: "wallet:50051"}, false},
+		{"cleartext token", TokenEndpointConfig{EndpointAddress: "wallet:50051", Token: "t"}, true},
+		{"loopback", TokenEndpointConfig{EndpointAddress: "127.0.0.1:50051", Token: "t"}, false},
+		{"tls", TokenEndpointConfig{EndpointAddress: "wallet:50051", Token: "t", TLS: EndpointTLSConfig{Enabled: true}}, false},
+		{"override", TokenEndpointConfig{EndpointAddress: "wallet:50051", Username: "u", AllowInsecureCredentials: true}, false},
+		{"http cleartext", TokenEndpointConfig{EndpointType: TokenEndpointTypeHTTP, EndpointAddress: "http://wallet.internal/next", Token: "t"}, true},
+		{"http basic auth", TokenEndpointConfig{EndpointType: TokenEndpointTypeHTTP, EndpointAddress: "http://wallet.internal:8080/next", Username: "u", Password: "p"}, true},
+		{"http loopback", TokenEndpointConfig{EndpointType: TokenEndpointTypeHTTP, EndpointAddress: "http://127.0.0.1:8080/next", Token: "t"}, false},
+		{"https", TokenEndpointConfig{EndpointType: TokenEndpointTypeHTTP, EndpointAddress: "https://wallet.internal/next", Token: "t"}, false},
+		{"http override", TokenEndpointConfig{EndpointType: TokenEndpointTypeHTTP, EndpointAddress: "http://wallet.internal/next", Token: "t", AllowInsecureCredentials: true}, false},
+	}
+	for _, tc := range cases {
+		if err := checkCredentialTransport(tc.ep); (err != nil) != tc.wantErr {
+			t.Fatalf("%s: expected error=%v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package cryptalias
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	ExecModeOneshot    = "oneshot"
+	ExecModePersistent = "persistent"
+
+	defaultExecTimeoutSeconds = 10
+	defaultExecPath           = "/usr/local/bin:/usr/bin:/bin"
+	// execMaxOutput caps what is read from a plugin's stdout and stderr.
+	execMaxOutput = 1 << 20
+)
+
+var (
+	execMarshal   = protojson.MarshalOptions{UseProtoNames: true}
+	execUnmarshal = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// execWalletClient runs plugin commands that speak protojson-encoded
+// WalletAddressRequest/WalletAddressResponse over stdin and stdout.
+type execWalletClient struct {
+	mu      sync.Mutex
+	plugins map[string]*execPlugin
+}
+
+func newExecWalletClient() *execWalletClient {
+	return &execWalletClient{plugins: map[string]*execPlugin{}}
+}
+
+// GetAddress runs the plugin of the named backend.
+func (c *execWalletClient) GetAddress(ctx context.Context, backend string, endpoint TokenEndpointConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	var req proto.Message = newWalletAddressRequest(in)
+	if endpoint.ProtocolVersion == ProtocolVersionV2 {
+		req = newWalletAddressRequestV2(in)
+	}
+	line, err := execMarshal.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(endpoint.Exec.TimeoutSecondsOrDefault()) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out []byte
+	if endpoint.Exec.Mode == ExecModePersistent {
+		out, err = c.plugin(backend, endpoint).roundTrip(ctx, line)
+	} else {
+		out, err = runExecOneshot(ctx, endpoint, line)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &cryptaliasv1.WalletAddressResponse{}
+	if err := execUnmarshal.Unmarshal(bytes.TrimSpace(out), resp); err != nil {
+		return nil, fmt.Errorf("exec plugin returned invalid response: %w", err)
+	}
+	if resp.GetAddress() == "" {
+		return nil, fmt.Errorf("exec plugin returned empty address")
+	}
+	return resp, nil
+}
+
+// plugin returns the long-running process of a backend. A change to its
+// command or exec settings stops the old process and starts a fresh one.
+func (c *execWalletClient) plugin(backend string, endpoint TokenEndpointConfig) *execPlugin {
+	key := execPluginKey(endpoint)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.plugins[backend]
+	if ok && p.key == key {
+		return p
+	}
+	if ok {
+		go p.close()
+	}
+	p = &execPlugin{key: key, endpoint: endpoint}
+	c.plugins[backend] = p
+	return p
+}
+
+// Close stops every persistent plugin.
+func (c *execWalletClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for backend, p := range c.plugins {
+		p.close()
+		delete(c.plugins, backend)
+	}
+}
+
+func execPluginKey(endpoint TokenEndpointConfig) string {
+	data, _ := yaml.Marshal(struct {
+		Command string             `yaml:"command"`
+		Exec    ExecEndpointConfig `yaml:"exec"`
+	}{endpoint.EndpointAddress, endpoint.Exec})
+	sum := sha256.Sum256(data)
+	return string(sum[:])
+}
+
+func execCommand(ctx context.Context, endpoint TokenEndpointConfig) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, endpoint.EndpointAddress, endpoint.Exec.Args...)
+	cmd.Env = execEnv(endpoint.Exec.Env)
+	cmd.Dir = endpoint.Exec.Dir
+	// Make sure a timed out plugin cannot hold its pipes open forever.
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// execEnv builds a restricted environment: plugins only see the variables
+// configured for them, plus a default PATH.
+func execEnv(env map[string]string) []string {
+	out := make([]string, 0, len(env)+1)
+	if _, ok := env["PATH"]; !ok {
+		out = append(out, "PATH="+defaultExecPath)
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		out = append(out, k+"="+env[k])
+	}
+	return out
+}
+
+func runExecOneshot(ctx context.Context, endpoint TokenEndpointConfig, line []byte) ([]byte, error) {
+	cmd := execCommand(ctx, endpoint)
+	cmd.Stdin = bytes.NewReader(append(line, '\n'))
+	stdout := &limitedBuffer{max: execMaxOutput}
+	stderr := &limitedBuffer{max: execMaxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("exec plugin timed out: %w", ctx.Err())
+		}
+		return nil, fmt.Errorf("exec plugin failed: %w: %s", err, firstLine(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// execPlugin is a long-running plugin speaking line-delimited JSON. Requests
+// are serialized; a failed or timed out exchange restarts the process. key
+// and endpoint are fixed when the plugin is created.
+type execPlugin struct {
+	key      string
+	endpoint TokenEndpointConfig
+
+	mu     sync.Mutex
+	closed bool
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan execLine
+}
+
+type execLine struct {
+	data []byte
+	err  error
+}
+
+func (p *execPlugin) roundTrip(ctx context.Context, line []byte) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errors.New("exec plugin was replaced")
+	}
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		p.stop()
+		return nil, fmt.Errorf("exec plugin write failed: %w", err)
+	}
+	select {
+	case res := <-p.lines:
+		if res.err != nil {
+			p.stop()
+			return nil, fmt.Errorf("exec plugin exited: %w", res.err)
+		}
+		return res.data, nil
+	case <-ctx.Done():
+		// The reply may still arrive later and would desync the protocol.
+		p.stop()
+		return nil, fmt.Errorf("exec plugin timed out: %w", ctx.Err())
+	}
+}
+
+func (p *execPlugin) start() error {
+	// The process outlives the request, so it must not use the request context.
+	cmd := execCommand(context.Background(), p.endpoint)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr := &limitedBuffer{max: execMaxOutput}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("exec plugin start failed: %w", err)
+	}
+
+	lines := make(chan execLine, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		scanner.Buffer(make([]byte, 64*1024), execMaxOutput)
+		for scanner.Scan() {
+			lines <- execLine{data: append([]byte(nil), scanner.Bytes()...)}
+		}
+		err := scanner.Err()
+		if err == nil {
+			err = errors.New("stdout closed")
+		}
+		if msg := firstLine(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		lines <- execLine{err: err}
+		close(lines)
+	}()
+
+	p.cmd, p.stdin, p.lines = cmd, stdin, lines
+	slog.Info("exec plugin started", "command", p.endpoint.EndpointAddress, "pid", cmd.Process.Pid)
+	return nil
+}
+
+// close stops the process for good, e.g. when the config changed.
+func (p *execPlugin) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.stop()
+}
+
+func (p *execPlugin) stop() {
+	if p.cmd == nil {
+		return
+	}
+	_ = p.stdin.Close()
+	_ = p.cmd.Process.Kill()
+	cmd, lines := p.cmd, p.lines
+	go func() {
+		// Drain the reader so its goroutine can exit, then reap the process.
+		for range lines {
+		}
+		_ = cmd.Wait()
+	}()
+	p.cmd, p.stdin, p.lines = nil, nil, nil
+}
+
+// limitedBuffer keeps at most max bytes and silently drops the rest so a
+// chatty plugin cannot exhaust memory. It may be read while os/exec is still
+// copying into it.
+type limitedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[:i]
+	}
+	if len(s) > 200 {
+		s = s[:200]
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+package cryptalias
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeExecPlugin(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700); err != nil {
+		t.Fatalf("write plugin: %v", err)
+	}
+	return path
+}
+
+func TestExecPluginOneshot(t *testing.T) {
+	t.Setenv("CRYPTALIAS_SECRET", "leak")
+	// The plugin echoes the alias back and proves the environment is restricted.
+	plugin := writeExecPlugin(t, `read req
+alias=$(echo "$req" | sed 's/.*"alias":"\([^"]*\)".*/\1/')
+echo "{\"address\":\"addr-$alias\",\"memo\":\"$PLUGIN_MODE${CRYPTALIAS_SECRET}\"}"
+`)
+	client := newExecWalletClient()
+	resp, err := client.GetAddress(context.Background(), "Monero", TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeExec,
+		EndpointAddress: plugin,
+		Exec:            ExecEndpointConfig{Env: map[string]string{"PLUGIN_MODE": "test"}},
+	}, dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"})
+	if err != nil {
+		t.Fatalf("exec get address: %v", err)
+	}
+	if resp.GetAddress() != "addr-demo" {
+		t.Fatalf("unexpected address %q", resp.GetAddress())
+	}
+	if resp.GetMemo() != "test" {
+		t.Fatalf("expected only configured env to reach the plugin, got memo %q", resp.GetMemo())
+	}
+}
+
+func TestExecPluginReportsFailureAndTimeout(t *testing.T) {
+	client := newExecWalletClient()
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	failing := writeExecPlugin(t, "echo 'wallet locked' >&2\nexit 3\n")
+	_, err := client.GetAddress(context.Background(), "Monero", TokenEndpointConfig{EndpointAddress: failing}, in)
+	if err == nil || !strings.Contains(err.Error(), "wallet locked") {
+		t.Fatalf("expected plugin stderr in error, got %v", err)
+	}
+
+	slow := writeExecPlugin(t, "sleep 5\n")
+	start := time.Now()
+	_, err = client.GetAddress(context.Background(), "Monero", TokenEndpointConfig{
+		EndpointAddress: slow,
+		Exec:            ExecEndpointConfig{TimeoutSeconds: 1},
+	}, in)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if time.Since(start) > 4*time.Second {
+		t.Fatalf("timeout was not enforced")
+	}
+}
+
+func TestExecPluginPersistent(t *testing.T) {
+	starts := filepath.Join(t.TempDir(), "starts")
+	plugin := writeExecPlugin(t, `echo started >> "$STARTS"
+n=0
+while read req; do
+  n=$((n+1))
+  echo "{\"address\":\"addr-$n\"}"
+done
+`)
+	client := newExecWalletClient()
+	t.Cleanup(client.Close)
+	endpoint := TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeExec,
+		EndpointAddress: plugin,
+		Exec: ExecEndpointConfig{
+			Mode: ExecModePersistent,
+			Env:  map[string]string{"STARTS": starts},
+		},
+	}
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	for i, want := range []string{"addr-1", "addr-2", "addr-3"} {
+		resp, err := client.GetAddress(context.Background(), "Monero", endpoint, in)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if resp.GetAddress() != want {
+			t.Fatalf("request %d: expected %s, got %s", i, want, resp.GetAddress())
+		}
+	}
+	b, _ := os.ReadFile(starts)
+	if n := strings.Count(string(b), "started"); n != 1 {
+		t.Fatalf("expected the plugin to start once, got %d", n)
+	}
+
+	// Changing the environment replaces the plugin and stops the old one.
+	old := client.plugins["Monero"]
+	endpoint.Exec.Env = map[string]string{"STARTS": starts, "MODE": "new"}
+	if resp, err := client.GetAddress(context.Background(), "Monero", endpoint, in); err != nil || resp.GetAddress() != "addr-1" {
+		t.Fatalf("expected a fresh plugin, got %v %v", resp, err)
+	}
+	if len(client.plugins) != 1 {
+		t.Fatalf("expected one plugin per backend, got %d", len(client.plugins))
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		old.mu.Lock()
+		stopped := old.closed && old.cmd == nil
+		old.mu.Unlock()
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the replaced plugin to be stopped")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWalletResolverEnforcesMaxConcurrency(t *testing.T) {
+	r := newWalletResolverWithDeps(nil, nil, nil)
+	token := TokenConfig{Name: "Monero", Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeExec, MaxConcurrency: 1}}
+
+	release, err := r.acquire(context.Background(), token)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := r.acquire(ctx, token); err == nil {
+		t.Fatalf("expected second acquire to wait and fail")
+	}
+	release()
+	release2, err := r.acquire(context.Background(), token)
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
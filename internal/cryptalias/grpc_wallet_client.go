@@ -4,78 +4,309 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
 	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	cryptaliasv2 "github.com/kaigoh/cryptalias/proto/cryptalias/v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/protobuf/proto"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ProtocolVersionAuto = "auto"
+	ProtocolVersionV1   = "v1"
+	ProtocolVersionV2   = "v2"
 )
 
 type grpcWalletClient struct {
+	mu sync.Mutex
+	// conns is keyed by address and TLS fingerprint, so endpoints sharing an
+	// address with different TLS settings get their own connection.
+	conns map[string]*grpcWalletConn
+
+	// revision reports the config revision. TLS fingerprints stat the
+	// certificate files, so they are only recomputed when it changes.
+	revision   func() uint64
+	seen       uint64
+	transports map[string]string
+}
+
+// grpcConnIdle is how long a connection may go unused before it is closed,
+// e.g. after a reload changed its endpoint's TLS settings.
+const grpcConnIdle = time.Hour
+
+// grpcWalletConn is a cached connection together with the transport settings
+// it was dialed with.
+type grpcWalletConn struct {
+	address   string
+	settings  string
+	transport string
+	conn      *grpc.ClientConn
+	client    cryptaliasv1.WalletServiceClient
+	v2        cryptaliasv2.WalletServiceClient
+	aliases   cryptaliasv1.AliasProviderClient
+
+	// users counts calls in flight; a retired connection is closed once it
+	// drops to zero. Guarded by the client's mutex.
+	users    int
+	retired  bool
+	lastUsed time.Time
+
+	// version is the protocol version negotiated for auto endpoints.
 	mu      sync.Mutex
-	clients map[string]cryptaliasv1.WalletServiceClient
-	conns   map[string]*grpc.ClientConn
+	version string
+}
+
+// walletServiceRPCs are the WalletService RPCs shared by v1 and v2.
+type walletServiceRPCs interface {
+	Health(ctx context.Context, in *cryptaliasv1.HealthRequest, opts ...grpc.CallOption) (*cryptaliasv1.HealthResponse, error)
+	CreateInvoice(ctx context.Context, in *cryptaliasv1.InvoiceRequest, opts ...grpc.CallOption) (*cryptaliasv1.InvoiceResponse, error)
+	ListPayments(ctx context.Context, in *cryptaliasv1.ListPaymentsRequest, opts ...grpc.CallOption) (*cryptaliasv1.ListPaymentsResponse, error)
+	IsAddressUsed(ctx context.Context, in *cryptaliasv1.AddressUsedRequest, opts ...grpc.CallOption) (*cryptaliasv1.AddressUsedResponse, error)
 }
 
 func newGRPCWalletClient() *grpcWalletClient {
 	return &grpcWalletClient{
-		clients: map[string]cryptaliasv1.WalletServiceClient{},
-		conns:   map[string]*grpc.ClientConn{},
+		conns:      map[string]*grpcWalletConn{},
+		transports: map[string]string{},
 	}
 }
 
 // GetAddress resolves via an external gRPC wallet service. Connections are
-// cached per endpoint address to avoid re-dialing on each request.
-func (c *grpcWalletClient) GetAddress(ctx context.Context, endpoint TokenEndpointConfig, in dynamicAliasInput) (string, error) {
-	client, err := c.clientFor(endpoint.EndpointAddress)
+// cached per endpoint address and TLS settings to avoid re-dialing on each
+// request.
+func (c *grpcWalletClient) GetAddress(ctx context.Context, endpoint TokenEndpointConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	wc, version, release, err := c.clientFor(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx = withEndpointAuth(ctx, endpoint)
+	var resp *cryptaliasv1.WalletAddressResponse
+	if version == ProtocolVersionV2 {
+		resp, err = wc.v2.GetAddress(ctx, newWalletAddressRequestV2(in))
+	} else {
+		resp, err = wc.client.GetAddress(ctx, newWalletAddressRequest(in))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.GetAddress() == "" {
+		return nil, fmt.Errorf("wallet service returned empty address")
+	}
+	return resp, nil
+}
+
+// CreateInvoice asks an external gRPC wallet service for a Lightning invoice.
+func (c *grpcWalletClient) CreateInvoice(ctx context.Context, endpoint TokenEndpointConfig, in dynamicAliasInput, invoice lightningInvoiceInput) (string, error) {
+	wc, version, release, err := c.clientFor(ctx, endpoint)
 	if err != nil {
 		return "", err
 	}
+	defer release()
 
 	ctx = withEndpointAuth(ctx, endpoint)
-	req := &cryptaliasv1.WalletAddressRequest{
-		Ticker: in.Ticker,
-		Alias:  in.Alias,
-		Tag:    in.Tag,
-		Domain: in.Domain,
+	resp, err := wc.rpcs(version).CreateInvoice(ctx, newInvoiceRequest(in, invoice))
+	if err != nil {
+		return "", err
+	}
+	return resp.GetPaymentRequest(), nil
+}
+
+// ListPayments asks an external gRPC wallet service for incoming transfers.
+func (c *grpcWalletClient) ListPayments(ctx context.Context, endpoint TokenEndpointConfig, req *cryptaliasv1.ListPaymentsRequest) (*cryptaliasv1.ListPaymentsResponse, error) {
+	wc, version, release, err := c.clientFor(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return wc.rpcs(version).ListPayments(withEndpointAuth(ctx, endpoint), req)
+}
+
+// IsAddressUsed asks an external gRPC wallet service whether an address
+// received funds.
+func (c *grpcWalletClient) IsAddressUsed(ctx context.Context, endpoint TokenEndpointConfig, req *cryptaliasv1.AddressUsedRequest) (*cryptaliasv1.AddressUsedResponse, error) {
+	wc, version, release, err := c.clientFor(ctx, endpoint)
+	if err != nil {
+		return nil, err
 	}
-	if in.AccountIndex != nil {
-		req.AccountIndex = proto.Uint64(*in.AccountIndex)
+	defer release()
+	return wc.rpcs(version).IsAddressUsed(withEndpointAuth(ctx, endpoint), req)
+}
+
+// Health calls the WalletService Health RPC.
+func (c *grpcWalletClient) Health(ctx context.Context, endpoint TokenEndpointConfig) (bool, string, error) {
+	wc, version, release, err := c.clientFor(ctx, endpoint)
+	if err != nil {
+		return false, "", err
 	}
-	if in.AccountID != nil {
-		req.AccountId = proto.String(*in.AccountID)
+	defer release()
+	resp, err := wc.rpcs(version).Health(withEndpointAuth(ctx, endpoint), &cryptaliasv1.HealthRequest{})
+	if err != nil {
+		return false, "", err
 	}
-	if in.WalletID != nil {
-		req.WalletId = proto.String(*in.WalletID)
+	return resp.GetOk(), resp.GetMessage(), nil
+}
+
+// StandardHealth asks the standard grpc.health.v1 service for the overall
+// server status.
+func (c *grpcWalletClient) StandardHealth(ctx context.Context, endpoint TokenEndpointConfig) (bool, string, error) {
+	wc, release, err := c.connFor(endpoint)
+	if err != nil {
+		return false, "", err
 	}
-	resp, err := client.GetAddress(ctx, req)
+	defer release()
+	resp, err := healthpb.NewHealthClient(wc.conn).Check(withEndpointAuth(ctx, endpoint), &healthpb.HealthCheckRequest{})
 	if err != nil {
+		return false, "", err
+	}
+	return resp.GetStatus() == healthpb.HealthCheckResponse_SERVING, resp.GetStatus().String(), nil
+}
+
+// clientFor returns the cached connection for an endpoint and the protocol
+// version to speak, redialing when its TLS settings or certificate files
+// changed since the connection was made. release must be called when the
+// call is done.
+func (c *grpcWalletClient) clientFor(ctx context.Context, endpoint TokenEndpointConfig) (*grpcWalletConn, string, func(), error) {
+	wc, release, err := c.connFor(endpoint)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	version, err := wc.negotiate(ctx, endpoint)
+	if err != nil {
+		release()
+		return nil, "", nil, err
+	}
+	return wc, version, release, nil
+}
+
+// negotiate returns the endpoint's configured protocol version. For auto it
+// calls the v2 Health RPC once per connection and falls back to v1 when the
+// service does not implement it.
+func (wc *grpcWalletConn) negotiate(ctx context.Context, endpoint TokenEndpointConfig) (string, error) {
+	switch endpoint.ProtocolVersion {
+	case ProtocolVersionV1, ProtocolVersionV2:
+		return endpoint.ProtocolVersion, nil
+	}
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	if wc.version != "" {
+		return wc.version, nil
+	}
+	_, err := wc.v2.Health(withEndpointAuth(ctx, endpoint), &cryptaliasv1.HealthRequest{})
+	switch {
+	case err == nil:
+		wc.version = ProtocolVersionV2
+	case status.Code(err) == codes.Unimplemented:
+		wc.version = ProtocolVersionV1
+	default:
+		// Not cached: the service may just be unreachable right now.
 		return "", err
 	}
-	if resp.GetAddress() == "" {
-		return "", fmt.Errorf("wallet service returned empty address")
+	slog.Info("wallet service protocol negotiated", "address", endpoint.EndpointAddress, "version", wc.version)
+	return wc.version, nil
+}
+
+func (wc *grpcWalletConn) rpcs(version string) walletServiceRPCs {
+	if version == ProtocolVersionV2 {
+		return wc.v2
 	}
-	return resp.GetAddress(), nil
+	return wc.client
 }
 
-func (c *grpcWalletClient) clientFor(addr string) (cryptaliasv1.WalletServiceClient, error) {
+// connFor returns the connection for an endpoint and marks it in use until
+// release is called.
+func (c *grpcWalletClient) connFor(endpoint TokenEndpointConfig) (*grpcWalletConn, func(), error) {
+	// Checked on every call as well as in Validate so credentials never leak
+	// in cleartext, even with a config built in code.
+	if err := checkCredentialTransport(endpoint); err != nil {
+		return nil, nil, err
+	}
+	addr := endpoint.EndpointAddress
+	settings := fmt.Sprintf("%#v", endpoint.TLS)
+	now := time.Now()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if client, ok := c.clients[addr]; ok {
-		return client, nil
+	if c.revision != nil {
+		if rev := c.revision(); rev != c.seen {
+			c.seen = rev
+			c.transports = map[string]string{}
+		}
 	}
+	transport, ok := c.transports[settings]
+	if !ok {
+		transport = endpoint.TLS.fingerprint()
+		c.transports[settings] = transport
+	}
+
+	key := addr + "\x00" + transport
+	wc, ok := c.conns[key]
+	if !ok {
+		var err error
+		if wc, err = dialGRPCWallet(endpoint, settings, transport); err != nil {
+			return nil, nil, err
+		}
+		for k, old := range c.conns {
+			// The new connection supersedes one dialed with the old
+			// certificate files; idle ones are left over from old configs.
+			if (old.address == addr && old.settings == settings) || now.Sub(old.lastUsed) > grpcConnIdle {
+				delete(c.conns, k)
+				old.retired = true
+				c.closeIfUnused(old)
+			}
+		}
+		c.conns[key] = wc
+	}
+	wc.users++
+	wc.lastUsed = now
+	return wc, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		wc.users--
+		c.closeIfUnused(wc)
+	}, nil
+}
 
-	conn, err := grpc.DialContext(context.Background(), addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// closeIfUnused closes a retired connection once no call uses it. c.mu must
+// be held.
+func (c *grpcWalletClient) closeIfUnused(wc *grpcWalletConn) {
+	if wc.retired && wc.users == 0 {
+		_ = wc.conn.Close()
+	}
+}
+
+func dialGRPCWallet(endpoint TokenEndpointConfig, settings, transport string) (*grpcWalletConn, error) {
+	creds := insecure.NewCredentials()
+	if endpoint.TLS.IsEnabled() {
+		tlsCfg, err := endpoint.TLS.clientConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	conn, err := grpc.DialContext(context.Background(), endpoint.EndpointAddress, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
-	client := cryptaliasv1.NewWalletServiceClient(conn)
-	c.clients[addr] = client
-	c.conns[addr] = conn
-	return client, nil
+	return &grpcWalletConn{
+		address:   endpoint.EndpointAddress,
+		settings:  settings,
+		transport: transport,
+		conn:      conn,
+		client:    cryptaliasv1.NewWalletServiceClient(conn),
+		v2:        cryptaliasv2.NewWalletServiceClient(conn),
+		aliases:   cryptaliasv1.NewAliasProviderClient(conn),
+	}, nil
 }
 
 func withEndpointAuth(ctx context.Context, endpoint TokenEndpointConfig) context.Context {
@@ -0,0 +1,100 @@
+package cryptalias
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	cryptaliasv2 "github.com/kaigoh/cryptalias/proto/cryptalias/v2"
+	"google.golang.org/grpc"
+)
+
+type v2WalletService struct {
+	cryptaliasv2.UnimplementedWalletServiceServer
+	requests []*cryptaliasv2.WalletAddressRequest
+}
+
+func (s *v2WalletService) Health(context.Context, *cryptaliasv1.HealthRequest) (*cryptaliasv1.HealthResponse, error) {
+	return &cryptaliasv1.HealthResponse{Ok: true}, nil
+}
+
+func (s *v2WalletService) GetAddress(_ context.Context, req *cryptaliasv2.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	s.requests = append(s.requests, req)
+	return &cryptaliasv1.WalletAddressResponse{Address: "addr-v2"}, nil
+}
+
+func serveWalletService(t *testing.T, register func(*grpc.Server)) string {
+	t.Helper()
+	s := grpc.NewServer()
+	register(s)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+	return lis.Addr().String()
+}
+
+func TestGRPCWalletClientNegotiatesProtocolVersion(t *testing.T) {
+	v2 := &v2WalletService{}
+	v2Addr := serveWalletService(t, func(s *grpc.Server) { cryptaliasv2.RegisterWalletServiceServer(s, v2) })
+	v1Addr := serveWalletService(t, func(s *grpc.Server) { cryptaliasv1.RegisterWalletServiceServer(s, staticWalletService{}) })
+
+	client := newGRPCWalletClient()
+	in := dynamicAliasInput{
+		Ticker:        "xmr",
+		Alias:         "shop",
+		Domain:        "example.com",
+		Memo:          "order 42",
+		RequestID:     "req-1",
+		ClientKeyHash: "hash",
+		Cache:         cachePolicy{TTLSeconds: 60, RetireUsed: true},
+	}
+	resp, err := client.GetAddress(context.Background(), TokenEndpointConfig{EndpointType: TokenEndpointTypeExternal, EndpointAddress: v2Addr}, in)
+	if err != nil || resp.GetAddress() != "addr-v2" {
+		t.Fatalf("expected the v2 service to answer, got %v %v", resp, err)
+	}
+	req := v2.requests[0]
+	if req.GetRequestId() != "req-1" || req.GetClientKeyHash() != "hash" || req.GetMemo() != "order 42" || req.GetCachePolicy().GetTtlSeconds() != 60 || !req.GetCachePolicy().GetRetireUsed() {
+		t.Fatalf("unexpected v2 request %v", req)
+	}
+
+	// A v1-only service keeps working under auto, and v1 can be forced.
+	for _, version := range []string{"", ProtocolVersionV1} {
+		resp, err = client.GetAddress(context.Background(), TokenEndpointConfig{EndpointType: TokenEndpointTypeExternal, EndpointAddress: v1Addr, ProtocolVersion: version}, in)
+		if err != nil || resp.GetAddress() != "addr-tls" {
+			t.Fatalf("expected the v1 service to answer with %q, got %v %v", version, resp, err)
+		}
+	}
+	wc, release, _ := client.connFor(TokenEndpointConfig{EndpointAddress: v1Addr})
+	release()
+	if wc.version != ProtocolVersionV1 {
+		t.Fatalf("expected v1 to be negotiated, got %q", wc.version)
+	}
+}
+
+func TestClientKeyHashIsSaltedAndStable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	state, err := newAddressStore(path)
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	a := state.ClientKeyHash("203.0.113.7")
+	if a == "" || a == state.ClientKeyHash("203.0.113.8") {
+		t.Fatalf("unexpected client key hash %q", a)
+	}
+	// The salt survives a restart.
+	reloaded, err := newAddressStore(path)
+	if err != nil {
+		t.Fatalf("reload address store: %v", err)
+	}
+	if reloaded.ClientKeyHash("203.0.113.7") != a {
+		t.Fatalf("expected the hash to be stable across restarts")
+	}
+	if state.ClientKeyHash("") != "" {
+		t.Fatalf("expected no hash for an unknown client")
+	}
+}
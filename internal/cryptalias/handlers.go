@@ -50,7 +50,7 @@ func WellKnownHandler(store *ConfigStore) http.HandlerFunc {
 	}
 }
 
-func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses *DomainStatusStore) http.HandlerFunc {
+func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses *DomainStatusStore, webhooks *WebhookDispatcher) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ticker := r.PathValue("ticker")
 		rawAlias := r.PathValue("alias")
@@ -80,6 +80,21 @@ func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses
 			ticker = prefix
 		}
 
+		amount := strings.TrimSpace(r.URL.Query().Get("amount"))
+		if amount != "" && !validAmount(amount) {
+			slog.Warn("resolve rejected invalid amount", "ticker", ticker, "alias", rawAlias)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "400 amount must be a positive decimal number")
+			return
+		}
+		memo := r.URL.Query().Get("memo")
+		if !validRequestMemo(memo) {
+			slog.Warn("resolve rejected invalid memo", "ticker", ticker, "alias", rawAlias)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "400 memo must be valid UTF-8 of at most 512 bytes")
+			return
+		}
+
 		c := store.Get()
 		if statuses != nil {
 			statuses.Reconcile(c)
@@ -98,8 +113,14 @@ func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses
 		clientKey := identity.Key(r)
 		// Propagate the derived client identity so the resolver cache can bind to it.
 		ctx := withClientKey(r.Context(), clientKey)
+		if amount != "" {
+			ctx = withRequestAmount(ctx, amount)
+		}
+		if memo != "" {
+			ctx = withRequestMemo(ctx, memo)
+		}
 
-		alias, err := ResolveAlias(ctx, rawAlias, ticker, c, resolver)
+		alias, err := ResolveAlias(ctx, rawAlias, ticker, c, store.Aliases(), resolver)
 		if err != nil {
 			if errors.Is(err, ErrAliasNotFound) {
 				slog.Warn("resolve alias not found", "ticker", ticker, "alias", rawAlias, "client", clientKey)
@@ -113,6 +134,24 @@ func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses
 				fmt.Fprintf(w, "400 %s", err.Error())
 				return
 			}
+			if errors.Is(err, ErrAliasProviderUnavailable) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintf(w, "503 %s", ErrAliasProviderUnavailable.Error())
+				return
+			}
+			var unavailable *BackendUnavailableError
+			if errors.As(err, &unavailable) {
+				w.Header().Set("Retry-After", retryAfterSeconds(unavailable.RetryAfter))
+				w.WriteHeader(http.StatusServiceUnavailable)
+				fmt.Fprintf(w, "503 %s", err.Error())
+				return
+			}
+			if errors.Is(err, errBackendTimeout) {
+				slog.Error("resolve timed out", "ticker", ticker, "alias", rawAlias, "client", clientKey, "error", err)
+				w.WriteHeader(http.StatusGatewayTimeout)
+				fmt.Fprintf(w, "504 %s", errBackendTimeout.Error())
+				return
+			}
 			slog.Error("resolve failed", "ticker", ticker, "alias", rawAlias, "client", clientKey, "error", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintf(w, "500 %s", err.Error())
@@ -128,11 +167,22 @@ func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses
 			return
 		}
 		o := ResolvedAddress{
-			Version: VERSION,
-			Ticker:  alias.Wallet.Ticker,
-			Address: alias.Wallet.Address,
-			Expires: time.Now().UTC().Add(60 * time.Second),
-			Nonce:   nonce,
+			Version:        VERSION,
+			Ticker:         alias.Wallet.Ticker,
+			Address:        alias.Wallet.Address,
+			DestinationTag: alias.Wallet.DestinationTag,
+			Expires:        time.Now().UTC().Add(60 * time.Second),
+			Nonce:          nonce,
+		}
+		if exp := alias.Wallet.ExpiresAt; !exp.IsZero() && exp.Before(o.Expires) {
+			// The wallet service asked for the address to expire sooner.
+			o.Expires = exp
+		}
+		if alias.Wallet.Memo != nil {
+			o.Memo = *alias.Wallet.Memo
+		}
+		if alias.Wallet.PaymentID != nil {
+			o.PaymentID = *alias.Wallet.PaymentID
 		}
 		j, err := json.Marshal(o)
 		if err != nil {
@@ -157,5 +207,10 @@ func AliasResolverHandler(store *ConfigStore, resolver walletResolver, statuses
 		w.WriteHeader(http.StatusOK)
 		w.Write(signed)
 		slog.Debug("resolve response sent", "ticker", alias.Wallet.Ticker, "domain", alias.Domain)
+		webhooks.Emit(newWebhookEvent(EventAliasResolved, alias.Domain, alias.Alias, alias.Tag, addressEventData{
+			Ticker:    o.Ticker,
+			Address:   o.Address,
+			PaymentID: o.PaymentID,
+		}))
 	}
 }
@@ -54,7 +54,7 @@ func TestWellKnownStatusHandler(t *testing.T) {
 	req.Host = "127.0.0.1"
 	rr := httptest.NewRecorder()
 
-	WellKnownStatusHandler(store, statuses).ServeHTTP(rr, req)
+	WellKnownStatusHandler(store, statuses, nil).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
@@ -79,7 +79,7 @@ func TestAliasResolverHandlerSignsPayload(t *testing.T) {
 	req.SetPathValue("alias", "demo$127.0.0.1")
 	rr := httptest.NewRecorder()
 
-	AliasResolverHandler(store, resolver, statuses).ServeHTTP(rr, req)
+	AliasResolverHandler(store, resolver, statuses, nil).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
@@ -102,8 +102,8 @@ func TestAliasResolverHandlerSignsPayload(t *testing.T) {
 	if payload.Ticker != "xmr" {
 		t.Fatalf("expected ticker xmr, got %q", payload.Ticker)
 	}
-	if payload.Address != "addr-root" {
-		t.Fatalf("expected address addr-root, got %q", payload.Address)
+	if payload.Address != testMoneroAddress(t, 1) {
+		t.Fatalf("expected root address, got %q", payload.Address)
 	}
 	if payload.Nonce == "" {
 		t.Fatalf("expected nonce to be set")
@@ -120,7 +120,7 @@ func TestAliasResolverHandlerAcceptsPrefixedAlias(t *testing.T) {
 	req.SetPathValue("alias", "xmr:demo$127.0.0.1")
 	rr := httptest.NewRecorder()
 
-	AliasResolverHandler(store, resolver, statuses).ServeHTTP(rr, req)
+	AliasResolverHandler(store, resolver, statuses, nil).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
@@ -143,7 +143,58 @@ func TestAliasResolverHandlerAcceptsPrefixedAlias(t *testing.T) {
 	if payload.Ticker != "xmr" {
 		t.Fatalf("expected ticker xmr, got %q", payload.Ticker)
 	}
-	if payload.Address != "addr-root" {
-		t.Fatalf("expected address addr-root, got %q", payload.Address)
+	if payload.Address != testMoneroAddress(t, 1) {
+		t.Fatalf("expected root address, got %q", payload.Address)
+	}
+}
+
+func TestAliasResolverHandlerSignsDestinationFields(t *testing.T) {
+	store, resolver := newTestStore(t)
+	if err := store.Update(func(c *Config) error {
+		memo := "order-7"
+		tag := uint32(99)
+		c.Domains[0].Aliases[0].Wallet.Memo = &memo
+		c.Domains[0].Aliases[0].Wallet.DestinationTag = &tag
+		return nil
+	}); err != nil {
+		t.Fatalf("update config: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/_cryptalias/resolve/xmr/demo$127.0.0.1", nil)
+	req.SetPathValue("ticker", "xmr")
+	req.SetPathValue("alias", "demo$127.0.0.1")
+	rr := httptest.NewRecorder()
+
+	AliasResolverHandler(store, resolver, nil, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	pubKey := ed25519.PublicKey(store.Get().Domains[0].PublicKey)
+	verified, err := jws.Verify(rr.Body.Bytes(), jws.WithKey(jwa.EdDSA(), pubKey))
+	if err != nil {
+		t.Fatalf("verify jws: %v", err)
+	}
+	var payload ResolvedAddress
+	if err := json.Unmarshal(verified, &payload); err != nil {
+		t.Fatalf("unmarshal payload: %v", err)
+	}
+	if payload.Memo != "order-7" || payload.DestinationTag == nil || *payload.DestinationTag != 99 {
+		t.Fatalf("expected signed destination fields, got %+v", payload)
+	}
+}
+
+func TestAliasResolverHandlerRejectsInvalidAmount(t *testing.T) {
+	store, resolver := newTestStore(t)
+	for _, amount := range []string{"-1", "0", "1e5", "abc"} {
+		req := httptest.NewRequest(http.MethodGet, "/_cryptalias/resolve/xmr/demo$127.0.0.1?amount="+amount, nil)
+		req.SetPathValue("ticker", "xmr")
+		req.SetPathValue("alias", "demo$127.0.0.1")
+		rr := httptest.NewRecorder()
+
+		AliasResolverHandler(store, resolver, nil, nil).ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("amount %q: expected 400, got %d", amount, rr.Code)
+		}
 	}
 }
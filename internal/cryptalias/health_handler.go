@@ -12,11 +12,18 @@ type healthResponse struct {
 	Time             time.Time `json:"time"`
 	OverallOK        bool      `json:"overall_ok"`
 	UnhealthyDomains int       `json:"unhealthy_domains"`
+	// Backends lists wallet backend circuit breakers; any that is not closed
+	// clears overall_ok.
+	Backends []BackendStatus `json:"backends,omitempty"`
+	// Wallets lists active wallet health checks. A token whose backends are
+	// all unhealthy counts in unhealthy_tokens and clears overall_ok.
+	Wallets         []WalletHealth `json:"wallets,omitempty"`
+	UnhealthyTokens int            `json:"unhealthy_tokens"`
 }
 
 // HealthHandler is a liveness endpoint intended for container health checks.
 // It always returns 200 when the process is up, and reports domain health in the body.
-func HealthHandler(statuses *DomainStatusStore) http.HandlerFunc {
+func HealthHandler(statuses *DomainStatusStore, wallets walletStatusSource) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		unhealthy := 0
 		overall := true
@@ -30,12 +37,34 @@ func HealthHandler(statuses *DomainStatusStore) http.HandlerFunc {
 			}
 		}
 
+		backendStatuses := listBackendStatuses(wallets)
+		for _, b := range backendStatuses {
+			if b.State != BackendStateClosed {
+				overall = false
+			}
+		}
+		walletHealth := listWalletHealth(wallets)
+		tokenHealthy := map[string]bool{}
+		for _, h := range walletHealth {
+			tokenHealthy[h.Token] = tokenHealthy[h.Token] || h.Healthy
+		}
+		unhealthyTokens := 0
+		for _, ok := range tokenHealthy {
+			if !ok {
+				unhealthyTokens++
+				overall = false
+			}
+		}
+
 		resp := healthResponse{
 			Status:           "ok",
 			Version:          VERSION,
 			Time:             time.Now().UTC(),
 			OverallOK:        overall,
 			UnhealthyDomains: unhealthy,
+			Backends:         backendStatuses,
+			Wallets:          walletHealth,
+			UnhealthyTokens:  unhealthyTokens,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
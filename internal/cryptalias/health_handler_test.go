@@ -15,7 +15,7 @@ func TestHealthHandlerReportsDomainHealth(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
 	rr := httptest.NewRecorder()
 
-	HealthHandler(statuses).ServeHTTP(rr, req)
+	HealthHandler(statuses, nil).ServeHTTP(rr, req)
 
 	if rr.Code != http.StatusOK {
 		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
@@ -0,0 +1,241 @@
+package cryptalias
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	defaultHTTPAddressPath    = "address"
+	defaultHTTPTimeoutSeconds = 10
+	// httpWalletMaxResponse caps how much of a webhook response is read.
+	httpWalletMaxResponse = 1 << 20
+)
+
+// httpWalletRequest is the JSON body POSTed to http wallet endpoints. It
+// mirrors WalletAddressRequest so webhooks see the same fields as gRPC services.
+type httpWalletRequest struct {
+	Ticker       string  `json:"ticker"`
+	Alias        string  `json:"alias"`
+	Tag          string  `json:"tag,omitempty"`
+	Domain       string  `json:"domain"`
+	AccountIndex *uint64 `json:"account_index,omitempty"`
+	AccountID    *string `json:"account_id,omitempty"`
+	WalletID     *string `json:"wallet_id,omitempty"`
+	AddressMode  *string `json:"address_mode,omitempty"`
+	Amount       string  `json:"amount,omitempty"`
+
+	// v2 request context, sent with protocol_version: v2.
+	RequestID     string           `json:"request_id,omitempty"`
+	ClientKeyHash string           `json:"client_key_hash,omitempty"`
+	Memo          string           `json:"memo,omitempty"`
+	CachePolicy   *httpCachePolicy `json:"cache_policy,omitempty"`
+}
+
+type httpCachePolicy struct {
+	TTLSeconds  int  `json:"ttl_seconds"`
+	RetireUsed  bool `json:"retire_used"`
+	ReuseUnused bool `json:"reuse_unused"`
+}
+
+type httpWalletClient struct {
+	client *http.Client
+}
+
+func newHTTPWalletClient() *httpWalletClient {
+	// Per-request timeouts come from the endpoint via the context.
+	return &httpWalletClient{client: &http.Client{}}
+}
+
+// GetAddress resolves via a plain HTTP/JSON webhook. The response is decoded
+// as JSON and the address is read from the configured path.
+func (c *httpWalletClient) GetAddress(ctx context.Context, endpoint TokenEndpointConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	cfg := endpoint.HTTP
+	payload := httpWalletRequest{
+		Ticker:       in.Ticker,
+		Alias:        in.Alias,
+		Tag:          in.Tag,
+		Domain:       in.Domain,
+		AccountIndex: in.AccountIndex,
+		AccountID:    in.AccountID,
+		WalletID:     in.WalletID,
+		AddressMode:  in.AddressMode,
+		Amount:       in.Amount,
+	}
+	if endpoint.ProtocolVersion == ProtocolVersionV2 {
+		payload.RequestID = in.RequestID
+		payload.ClientKeyHash = in.ClientKeyHash
+		payload.Memo = in.Memo
+		payload.CachePolicy = &httpCachePolicy{
+			TTLSeconds:  in.Cache.TTLSeconds,
+			RetireUsed:  in.Cache.RetireUsed,
+			ReuseUnused: in.Cache.ReuseUnused,
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(cfg.TimeoutSecondsOrDefault()) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := checkCredentialTransport(endpoint); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.EndpointAddress, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if endpoint.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+endpoint.Token)
+	} else if endpoint.Username != "" || endpoint.Password != "" {
+		req.SetBasicAuth(endpoint.Username, endpoint.Password)
+	}
+	if cfg.HMACSecret != "" {
+		signHTTPWalletRequest(req, body, cfg.HMACSecret, time.Now())
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable {
+		// The webhook (or its proxy) refused the request before handling it.
+		return nil, fmt.Errorf("%w: http wallet endpoint returned status %d", errRetryableBackend, resp.StatusCode)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("http wallet endpoint returned status %d", resp.StatusCode)
+	}
+	if mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return nil, fmt.Errorf("http wallet endpoint returned non-JSON content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	var doc any
+	dec := json.NewDecoder(io.LimitReader(resp.Body, httpWalletMaxResponse))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("http wallet endpoint returned invalid JSON: %w", err)
+	}
+	return httpWalletResponse(doc, cfg)
+}
+
+// httpWalletResponse extracts the address and optional destination and
+// metadata fields.
+func httpWalletResponse(doc any, cfg HTTPEndpointConfig) (*cryptaliasv1.WalletAddressResponse, error) {
+	addressPath := cfg.AddressPath
+	if addressPath == "" {
+		addressPath = defaultHTTPAddressPath
+	}
+	address, ok := jsonPathLookup(doc, addressPath).(string)
+	if !ok || strings.TrimSpace(address) == "" {
+		return nil, fmt.Errorf("http wallet endpoint response has no string at %q", addressPath)
+	}
+	out := &cryptaliasv1.WalletAddressResponse{Address: strings.TrimSpace(address)}
+
+	if cfg.MemoPath != "" {
+		if v, ok := jsonPathLookup(doc, cfg.MemoPath).(string); ok && v != "" {
+			out.Memo = proto.String(v)
+		}
+	}
+	if cfg.PaymentIDPath != "" {
+		if v, ok := jsonPathLookup(doc, cfg.PaymentIDPath).(string); ok && v != "" {
+			out.PaymentId = proto.String(v)
+		}
+	}
+	if cfg.DestinationTagPath != "" {
+		switch v := jsonPathLookup(doc, cfg.DestinationTagPath).(type) {
+		case nil:
+		case json.Number:
+			n, err := strconv.ParseUint(v.String(), 10, 32)
+			if err != nil || n > math.MaxUint32 {
+				return nil, fmt.Errorf("http wallet endpoint destination tag %q is not a uint32", v)
+			}
+			out.DestinationTag = proto.Uint32(uint32(n))
+		default:
+			return nil, fmt.Errorf("http wallet endpoint destination tag at %q is not a number", cfg.DestinationTagPath)
+		}
+	}
+	if cfg.ReferencePath != "" {
+		if v, ok := jsonPathLookup(doc, cfg.ReferencePath).(string); ok && v != "" {
+			out.Reference = proto.String(v)
+		}
+	}
+	if cfg.ExpiresInPath != "" {
+		switch v := jsonPathLookup(doc, cfg.ExpiresInPath).(type) {
+		case nil:
+		case json.Number:
+			n, err := strconv.ParseUint(v.String(), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("http wallet endpoint expiry %q is not a uint32", v)
+			}
+			out.ExpiresInSeconds = proto.Uint32(uint32(n))
+		default:
+			return nil, fmt.Errorf("http wallet endpoint expiry at %q is not a number", cfg.ExpiresInPath)
+		}
+	}
+	return out, nil
+}
+
+// jsonPathLookup walks a dotted path such as "data.addresses.0.address"
+// through decoded JSON. A leading "$." is accepted and ignored.
+func jsonPathLookup(doc any, path string) any {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return doc
+	}
+	cur := doc
+	for _, part := range strings.Split(path, ".") {
+		switch node := cur.(type) {
+		case map[string]any:
+			cur = node[part]
+		case []any:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			cur = node[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// signHTTPWalletRequest adds an HMAC-SHA256 over "<timestamp>.<body>" so
+// webhooks can authenticate Cryptalias and reject replays.
+func signHTTPWalletRequest(req *http.Request, body []byte, secret string, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("X-Cryptalias-Timestamp", ts)
+	req.Header.Set("X-Cryptalias-Signature", "sha256="+httpWalletSignature(body, secret, ts))
+}
+
+func httpWalletSignature(body []byte, secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
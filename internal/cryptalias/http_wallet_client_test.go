@@ -0,0 +1,142 @@
+package cryptalias
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPWalletEndpointResolvesAndCaches(t *testing.T) {
+	address := testMoneroAddress(t, 7)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		ts := r.Header.Get("X-Cryptalias-Timestamp")
+		if r.Header.Get("X-Cryptalias-Signature") != "sha256="+httpWalletSignature(body, "shh", ts) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("X-Api-Key") != "k1" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var in httpWalletRequest
+		if err := json.Unmarshal(body, &in); err != nil || in.Alias != "demo" || in.Domain != "example.com" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"data":{"addresses":[{"address":"` + address + `","payment_id":"0011223344556677"}]}}`))
+	}))
+	defer srv.Close()
+
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	resolver := newWalletResolverWithDeps(state, nil, nil)
+	cfg := &Config{
+		Tokens: []TokenConfig{{
+			Name:    "Monero",
+			Tickers: []string{"xmr"},
+			Endpoint: TokenEndpointConfig{
+				EndpointType:    TokenEndpointTypeHTTP,
+				EndpointAddress: srv.URL,
+				HTTP: HTTPEndpointConfig{
+					Headers:       map[string]string{"X-Api-Key": "k1"},
+					AddressPath:   "$.data.addresses.0.address",
+					PaymentIDPath: "data.addresses.0.payment_id",
+					HMACSecret:    "shh",
+				},
+			},
+		}},
+	}
+	cfg.Normalize("")
+
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	for i := 0; i < 2; i++ {
+		got, err := resolver.Resolve(context.Background(), cfg, in)
+		if err != nil {
+			t.Fatalf("resolve %d: %v", i, err)
+		}
+		if got.Address != address || got.PaymentID == nil || *got.PaymentID != "0011223344556677" {
+			t.Fatalf("resolve %d: unexpected wallet %+v", i, got)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected the http endpoint to be cached, got %d calls", calls)
+	}
+}
+
+func TestHTTPWalletEndpointValidatesResponse(t *testing.T) {
+	cases := map[string]http.HandlerFunc{
+		"status": func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		},
+		"content type": func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"address":"x"}`))
+		},
+		"missing address": func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"addr":"x"}`))
+		},
+		"timeout": func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(1500 * time.Millisecond)
+		},
+	}
+	for name, handler := range cases {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(handler)
+			defer srv.Close()
+
+			client := newHTTPWalletClient()
+			_, err := client.GetAddress(context.Background(), TokenEndpointConfig{
+				EndpointType:    TokenEndpointTypeHTTP,
+				EndpointAddress: srv.URL,
+				HTTP:            HTTPEndpointConfig{TimeoutSeconds: 1},
+			}, dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"})
+			if err == nil {
+				t.Fatalf("expected %s to be rejected", name)
+			}
+		})
+	}
+}
+
+func TestHTTPWalletResponseReadsMetadata(t *testing.T) {
+	var doc any
+	dec := json.NewDecoder(strings.NewReader(`{"data":{"address":"addr","ttl":300,"ref":"inv-1"}}`))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	resp, err := httpWalletResponse(doc, HTTPEndpointConfig{AddressPath: "data.address", ExpiresInPath: "data.ttl", ReferencePath: "data.ref"})
+	if err != nil {
+		t.Fatalf("http wallet response: %v", err)
+	}
+	if resp.GetExpiresInSeconds() != 300 || resp.GetReference() != "inv-1" {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+	if _, err := httpWalletResponse(doc, HTTPEndpointConfig{AddressPath: "data.address", ExpiresInPath: "data.ref"}); err == nil {
+		t.Fatalf("expected a non-numeric expiry to be rejected")
+	}
+}
+
+func TestJSONPathLookup(t *testing.T) {
+	var doc any
+	if err := json.NewDecoder(strings.NewReader(`{"a":{"b":[{"c":"x"}]}}`)).Decode(&doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := jsonPathLookup(doc, "a.b.0.c"); got != "x" {
+		t.Fatalf("expected x, got %v", got)
+	}
+	if got := jsonPathLookup(doc, "a.b.1.c"); got != nil {
+		t.Fatalf("expected nil for out of range index, got %v", got)
+	}
+}
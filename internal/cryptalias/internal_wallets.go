@@ -1,34 +1,231 @@
 package cryptalias
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/yaml.v2"
 )
 
+const internalBufSize = 1024 * 1024
+
+// internalIntegration is a built-in wallet backend. It hands out WalletService
+// clients so internal integrations speak the same contract as external ones.
+type internalIntegration interface {
+	Client(endpoint TokenEndpointConfig, walletID *string) (cryptaliasv1.WalletServiceClient, error)
+}
+
+type internalIntegrationFactory func() internalIntegration
+
+// internalIntegrations is the registry of built-in integrations by name.
+// Integrations register themselves from init so no dispatch switch is needed.
+var internalIntegrations = map[string]internalIntegrationFactory{}
+
+func registerInternalIntegration(name string, factory internalIntegrationFactory) {
+	internalIntegrations[name] = factory
+}
+
+func internalIntegrationNames() []string {
+	names := make([]string, 0, len(internalIntegrations))
+	for name := range internalIntegrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IntegrationOrDefault returns the internal integration a token uses. Monero
+// tokens predate the integration option and keep working without it.
+func (t TokenConfig) IntegrationOrDefault() string {
+	if t.Endpoint.Integration != "" {
+		return t.Endpoint.Integration
+	}
+	if t.Chain == "monero" {
+		return IntegrationMoneroWalletRPC
+	}
+	for _, tk := range t.Tickers {
+		if strings.EqualFold(strings.TrimSpace(tk), "xmr") {
+			return IntegrationMoneroWalletRPC
+		}
+	}
+	return ""
+}
+
 type internalWallets struct {
-	mu     sync.Mutex
-	monero *internalMoneroGRPC
+	mu           sync.Mutex
+	integrations map[string]internalIntegration
 }
 
 func newInternalWallets() *internalWallets {
-	return &internalWallets{}
+	return &internalWallets{integrations: map[string]internalIntegration{}}
 }
 
-func (w *internalWallets) moneroClient(endpoint TokenEndpointConfig) (cryptaliasv1.WalletServiceClient, error) {
+// client returns a WalletService client from the token's integration. Each
+// integration is created once and keeps its own per-wallet instances.
+func (w *internalWallets) client(token TokenConfig, walletID *string) (cryptaliasv1.WalletServiceClient, error) {
+	name := token.IntegrationOrDefault()
+	if name == "" {
+		return nil, fmt.Errorf("no internal integration configured for token %q", token.Name)
+	}
+
+	w.mu.Lock()
+	integration, ok := w.integrations[name]
+	if !ok {
+		factory, known := internalIntegrations[name]
+		if !known {
+			w.mu.Unlock()
+			return nil, fmt.Errorf("unknown internal integration %q", name)
+		}
+		integration = factory()
+		w.integrations[name] = integration
+	}
+	w.mu.Unlock()
+
+	return integration.Client(token.Endpoint, walletID)
+}
+
+// walletTargetIntegration keeps one in-process service per resolved wallet
+// endpoint so tokens and aliases routed to different wallets never share a
+// target. Services that share a wallet RPC coordinate through state of their
+// own, e.g. moneroRPC.
+type walletTargetIntegration struct {
+	name       string
+	endpoint   func(TokenEndpointConfig, *string) (TokenEndpointConfig, error)
+	newService func(TokenEndpointConfig) cryptaliasv1.WalletServiceServer
+
+	mu        sync.Mutex
+	instances map[string]*internalWalletGRPC
+}
+
+type internalWalletGRPC struct {
+	client   cryptaliasv1.WalletServiceClient
+	stop     func()
+	lastUsed time.Time
+}
+
+// internalInstanceIdle is how long an instance may go unused before it is
+// stopped, e.g. after a reload changed its endpoint settings.
+const internalInstanceIdle = time.Hour
+
+func newWalletTargetIntegration(name string, endpoint func(TokenEndpointConfig, *string) (TokenEndpointConfig, error), newService func(TokenEndpointConfig) cryptaliasv1.WalletServiceServer) *walletTargetIntegration {
+	return &walletTargetIntegration{
+		name:       name,
+		endpoint:   endpoint,
+		newService: newService,
+		instances:  map[string]*internalWalletGRPC{},
+	}
+}
+
+func (w *walletTargetIntegration) Client(endpoint TokenEndpointConfig, walletID *string) (cryptaliasv1.WalletServiceClient, error) {
+	ep, err := w.endpoint(endpoint, walletID)
+	if err != nil {
+		return nil, err
+	}
+	// Instances are keyed by every endpoint setting and never retargeted, so a
+	// reload starts new instances once instead of changing the endpoint under
+	// a running call, and tokens with different settings never share one.
+	data, err := yaml.Marshal(ep)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	key := hex.EncodeToString(sum[:])
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
-	if w.monero == nil {
-		grpcSvc, err := newInternalMoneroGRPC(endpoint)
+	now := time.Now()
+	inst, ok := w.instances[key]
+	if !ok {
+		for k, idle := range w.instances {
+			if now.Sub(idle.lastUsed) > internalInstanceIdle {
+				idle.stop()
+				delete(w.instances, k)
+			}
+		}
+		client, stop, err := newInternalGRPC(w.name, w.newService(ep))
 		if err != nil {
 			return nil, err
 		}
-		w.monero = grpcSvc
+		inst = &internalWalletGRPC{client: client, stop: stop}
+		w.instances[key] = inst
+	}
+	inst.lastUsed = now
+	return inst.client, nil
+}
+
+// namedWalletEndpoint applies the named wallet selected by wallet_id on top of
+// the token endpoint. Without named wallets the hint is passed through as-is.
+func namedWalletEndpoint(endpoint TokenEndpointConfig, walletID *string) (TokenEndpointConfig, error) {
+	ep := endpoint
+	ep.Wallets = nil
+	if walletID == nil || *walletID == "" || len(endpoint.Wallets) == 0 {
+		return ep, nil
+	}
+	wallet, ok := endpoint.findWallet(*walletID)
+	if !ok {
+		return TokenEndpointConfig{}, fmt.Errorf("unknown wallet_id %q", *walletID)
+	}
+	if wallet.Address != "" {
+		ep.EndpointAddress = wallet.Address
+		// A separate wallet RPC does not inherit the endpoint's credentials.
+		ep.Username, ep.Password = wallet.Username, wallet.Password
+	} else if wallet.Username != "" || wallet.Password != "" {
+		ep.Username, ep.Password = wallet.Username, wallet.Password
+	}
+	ep.WalletFile = wallet.WalletFile
+	ep.WalletPassword = wallet.WalletPassword
+	ep.WalletFilePath = wallet.WalletFilePath
+	return ep, nil
+}
+
+// walletAddressLabel labels issued addresses so wallet owners can tell which
+// alias handed them out.
+func walletAddressLabel(req *cryptaliasv1.WalletAddressRequest) string {
+	label := req.GetDomain() + ":" + req.GetAlias()
+	if tag := req.GetTag(); tag != "" {
+		label += "+" + tag
+	}
+	return label
+}
+
+// newInternalGRPC spins up an in-process gRPC server over bufconn so internal
+// integrations exercise the same protobuf contract as external plugins.
+// The returned func stops the server.
+func newInternalGRPC(name string, svc cryptaliasv1.WalletServiceServer) (cryptaliasv1.WalletServiceClient, func(), error) {
+	lis := bufconn.Listen(internalBufSize)
+
+	s := grpc.NewServer()
+	cryptaliasv1.RegisterWalletServiceServer(s, svc)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	conn, err := grpc.DialContext(context.Background(), "bufnet-"+name,
+		grpc.WithContextDialer(dialer),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		s.Stop()
+		return nil, nil, err
 	}
-	if w.monero == nil {
-		return nil, fmt.Errorf("internal monero service unavailable")
+	stop := func() {
+		_ = conn.Close()
+		s.Stop()
 	}
-	return w.monero.Client(endpoint), nil
+	return cryptaliasv1.NewWalletServiceClient(conn), stop, nil
 }
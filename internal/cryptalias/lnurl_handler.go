@@ -0,0 +1,205 @@
+package cryptalias
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type invoiceCreator interface {
+	CreateInvoice(ctx context.Context, cfg *Config, in dynamicAliasInput, invoice lightningInvoiceInput) (string, error)
+}
+
+// lnurlPayResponse is the LUD-06 payRequest served from /.well-known/lnurlp.
+type lnurlPayResponse struct {
+	Tag            string `json:"tag"`
+	Callback       string `json:"callback"`
+	MinSendable    uint64 `json:"minSendable"`
+	MaxSendable    uint64 `json:"maxSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed int    `json:"commentAllowed,omitempty"`
+}
+
+type lnurlInvoiceResponse struct {
+	PR     string   `json:"pr"`
+	Routes []string `json:"routes"`
+}
+
+type lnurlErrorResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// LNURLPayHandler serves Lightning Address discovery (LUD-16) for aliases
+// that have a lightning block. It is served on the alias domain itself.
+func LNURLPayHandler(store *ConfigStore, statuses *DomainStatusStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := store.Get()
+		user := r.PathValue("user")
+		slog.Debug("lnurlp request", "host", r.Host, "user", user)
+
+		domain, err := cfg.GetDomain(strings.ToLower(r.Host))
+		if err != nil {
+			writeLNURLError(w, http.StatusNotFound, "unknown domain")
+			return
+		}
+		if !lnurlDomainHealthy(w, cfg, statuses, domain.Domain) {
+			return
+		}
+		alias, ln, ok, err := findLightningAlias(store.Aliases(), domain.Domain, user)
+		if err != nil {
+			slog.Error("lightning alias lookup failed", "domain", domain.Domain, "user", user, "error", err)
+			writeLNURLError(w, http.StatusInternalServerError, "alias lookup failed")
+			return
+		}
+		if !ok {
+			writeLNURLError(w, http.StatusNotFound, "unknown lightning address")
+			return
+		}
+
+		resp := lnurlPayResponse{
+			Tag:            "payRequest",
+			Callback:       lnurlCallbackURL(cfg.BaseURL, domain.Domain, alias),
+			MinSendable:    ln.MinSendableMsat,
+			MaxSendable:    ln.MaxSendableMsat,
+			Metadata:       lnurlMetadata(alias, domain.Domain, ln),
+			CommentAllowed: ln.CommentAllowed,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// LNURLCallbackHandler issues an invoice for a Lightning Address payment once
+// the payer has chosen an amount (LUD-06 step 2).
+func LNURLCallbackHandler(store *ConfigStore, invoices invoiceCreator, statuses *DomainStatusStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := store.Get()
+		domainName := strings.ToLower(r.PathValue("domain"))
+		user := r.PathValue("user")
+
+		domain, err := cfg.GetDomain(domainName)
+		if err != nil {
+			writeLNURLError(w, http.StatusNotFound, "unknown domain")
+			return
+		}
+		if !lnurlDomainHealthy(w, cfg, statuses, domain.Domain) {
+			return
+		}
+		alias, ln, ok, err := findLightningAlias(store.Aliases(), domain.Domain, user)
+		if err != nil {
+			slog.Error("lightning alias lookup failed", "domain", domain.Domain, "user", user, "error", err)
+			writeLNURLError(w, http.StatusInternalServerError, "alias lookup failed")
+			return
+		}
+		if !ok {
+			writeLNURLError(w, http.StatusNotFound, "unknown lightning address")
+			return
+		}
+
+		amount, err := strconv.ParseUint(r.URL.Query().Get("amount"), 10, 64)
+		if err != nil {
+			writeLNURLError(w, http.StatusBadRequest, "amount must be an integer number of millisatoshis")
+			return
+		}
+		if amount < ln.MinSendableMsat || amount > ln.MaxSendableMsat {
+			writeLNURLError(w, http.StatusBadRequest, "amount out of range")
+			return
+		}
+		comment := r.URL.Query().Get("comment")
+		if len(comment) > ln.CommentAllowed {
+			writeLNURLError(w, http.StatusBadRequest, "comment too long")
+			return
+		}
+
+		hash := sha256.Sum256([]byte(lnurlMetadata(alias, domain.Domain, ln)))
+		in := dynamicAliasInput{
+			Ticker:       ln.Ticker,
+			Alias:        alias.Alias,
+			Domain:       domain.Domain,
+			AccountIndex: ln.AccountIndex,
+			AccountID:    ln.AccountID,
+			WalletID:     ln.WalletID,
+		}
+		pr, err := invoices.CreateInvoice(r.Context(), cfg, in, lightningInvoiceInput{
+			AmountMsat:      amount,
+			DescriptionHash: hash[:],
+			Comment:         comment,
+		})
+		if err != nil {
+			var unavailable *BackendUnavailableError
+			if errors.As(err, &unavailable) {
+				w.Header().Set("Retry-After", retryAfterSeconds(unavailable.RetryAfter))
+				writeLNURLError(w, http.StatusServiceUnavailable, "wallet temporarily unavailable")
+				return
+			}
+			slog.Error("lnurlp invoice failed", "domain", domain.Domain, "alias", alias.Alias, "error", err)
+			writeLNURLError(w, http.StatusBadGateway, "unable to create invoice")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(lnurlInvoiceResponse{PR: pr, Routes: []string{}})
+		slog.Debug("lnurlp invoice issued", "domain", domain.Domain, "alias", alias.Alias, "amount_msat", amount)
+	}
+}
+
+func lnurlDomainHealthy(w http.ResponseWriter, cfg *Config, statuses *DomainStatusStore, domain string) bool {
+	if statuses == nil {
+		return true
+	}
+	statuses.Reconcile(cfg)
+	if healthy, status := statuses.Healthy(domain); !healthy {
+		slog.Warn("lnurlp gated unhealthy domain", "domain", domain, "message", status.Message)
+		writeLNURLError(w, http.StatusServiceUnavailable, "domain unhealthy: "+status.Message)
+		return false
+	}
+	return true
+}
+
+// findLightningAlias matches the Lightning Address local part against
+// stored aliases. Only aliases with a lightning block are served.
+func findLightningAlias(aliases AliasStore, domain, user string) (WalletAlias, LightningConfig, bool, error) {
+	user = strings.ToLower(strings.TrimSpace(user))
+	if err := validateAliasOrTag(user, "alias"); err != nil {
+		return WalletAlias{}, LightningConfig{}, false, nil
+	}
+	a, ok, err := aliases.FindLightning(domain, user)
+	if err != nil || !ok {
+		return WalletAlias{}, LightningConfig{}, false, err
+	}
+	return a, *a.Lightning, true, nil
+}
+
+// lnurlMetadata renders the metadata string whose SHA-256 the invoice
+// description hash must commit to. The output must be stable across calls.
+func lnurlMetadata(alias WalletAlias, domain string, ln LightningConfig) string {
+	identifier := alias.Alias + "@" + domain
+	description := ln.Description
+	if description == "" {
+		description = "Payment to " + identifier
+	}
+	b, _ := json.Marshal([][]string{
+		{"text/plain", description},
+		{"text/identifier", identifier},
+	})
+	return string(b)
+}
+
+func lnurlCallbackURL(baseURL, domain string, alias WalletAlias) string {
+	return baseURL + "/_cryptalias/lnurlp/" + url.PathEscape(domain) + "/" + url.PathEscape(alias.Alias) + "/callback"
+}
+
+func writeLNURLError(w http.ResponseWriter, code int, reason string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(lnurlErrorResponse{Status: "ERROR", Reason: reason})
+}
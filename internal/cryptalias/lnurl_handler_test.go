@@ -0,0 +1,133 @@
+package cryptalias
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+type fakeInvoiceCreator struct {
+	called  bool
+	in      dynamicAliasInput
+	invoice lightningInvoiceInput
+}
+
+func (f *fakeInvoiceCreator) CreateInvoice(_ context.Context, _ *Config, in dynamicAliasInput, invoice lightningInvoiceInput) (string, error) {
+	f.called = true
+	f.in = in
+	f.invoice = invoice
+	return "lnbc10n1fake", nil
+}
+
+func newLightningTestStore(t *testing.T) *ConfigStore {
+	t.Helper()
+	cfg := testConfig(t)
+	cfg.Domains[0].Aliases[0].Lightning = &LightningConfig{
+		MinSendableMsat: 1000,
+		MaxSendableMsat: 5000,
+		CommentAllowed:  10,
+	}
+	cfg.Tokens = append(cfg.Tokens, TokenConfig{
+		Name:    "Lightning",
+		Tickers: []string{"ln"},
+		Endpoint: TokenEndpointConfig{
+			EndpointType:    TokenEndpointTypeExternal,
+			EndpointAddress: "wallet-ln:50051",
+		},
+	})
+	cfg.Normalize("")
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate config: %v", err)
+	}
+	return NewConfigStore(filepath.Join(t.TempDir(), "config.yml"), cfg)
+}
+
+func TestLNURLPayHandlerServesPayRequest(t *testing.T) {
+	store := newLightningTestStore(t)
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/lnurlp/demo", nil)
+	req.Host = "127.0.0.1"
+	req.SetPathValue("user", "demo")
+	rr := httptest.NewRecorder()
+
+	LNURLPayHandler(store, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp lnurlPayResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Tag != "payRequest" || resp.MinSendable != 1000 || resp.MaxSendable != 5000 {
+		t.Fatalf("unexpected pay request: %+v", resp)
+	}
+	if resp.Callback != "http://127.0.0.1:8080/_cryptalias/lnurlp/127.0.0.1/demo/callback" {
+		t.Fatalf("unexpected callback %q", resp.Callback)
+	}
+}
+
+func TestLNURLPayHandlerUnknownUser(t *testing.T) {
+	store := newLightningTestStore(t)
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/lnurlp/nobody", nil)
+	req.Host = "127.0.0.1"
+	req.SetPathValue("user", "nobody")
+	rr := httptest.NewRecorder()
+
+	LNURLPayHandler(store, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestLNURLCallbackCommitsToMetadata(t *testing.T) {
+	store := newLightningTestStore(t)
+	invoices := &fakeInvoiceCreator{}
+	req := httptest.NewRequest(http.MethodGet, "/_cryptalias/lnurlp/127.0.0.1/demo/callback?amount=2000", nil)
+	req.SetPathValue("domain", "127.0.0.1")
+	req.SetPathValue("user", "demo")
+	rr := httptest.NewRecorder()
+
+	LNURLCallbackHandler(store, invoices, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp lnurlInvoiceResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.PR != "lnbc10n1fake" {
+		t.Fatalf("unexpected payment request %q", resp.PR)
+	}
+	if invoices.in.Ticker != "ln" || invoices.in.Alias != "demo" || invoices.invoice.AmountMsat != 2000 {
+		t.Fatalf("unexpected invoice input: %+v %+v", invoices.in, invoices.invoice)
+	}
+	alias := store.Get().Domains[0].Aliases[0]
+	want := sha256.Sum256([]byte(lnurlMetadata(alias, "127.0.0.1", *alias.Lightning)))
+	if string(invoices.invoice.DescriptionHash) != string(want[:]) {
+		t.Fatalf("description hash does not commit to metadata")
+	}
+}
+
+func TestLNURLCallbackRejectsOutOfRangeAmount(t *testing.T) {
+	store := newLightningTestStore(t)
+	invoices := &fakeInvoiceCreator{}
+	req := httptest.NewRequest(http.MethodGet, "/_cryptalias/lnurlp/127.0.0.1/demo/callback?amount=999999", nil)
+	req.SetPathValue("domain", "127.0.0.1")
+	req.SetPathValue("user", "demo")
+	rr := httptest.NewRecorder()
+
+	LNURLCallbackHandler(store, invoices, nil).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if invoices.called {
+		t.Fatalf("expected invoice not to be requested")
+	}
+}
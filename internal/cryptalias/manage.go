@@ -0,0 +1,221 @@
+package cryptalias
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrAliasExists  = errors.New("alias already exists")
+	ErrTagNotFound  = errors.New("unknown tag")
+	ErrDomainExists = errors.New("domain already exists")
+)
+
+// DomainSummary describes a domain for the management commands.
+type DomainSummary struct {
+	Domain    string `json:"domain"`
+	PublicKey string `json:"public_key"`
+	DNSTXT    string `json:"dns_txt"`
+}
+
+func summarizeDomain(d AliasDomainConfig) DomainSummary {
+	return DomainSummary{
+		Domain:    d.Domain,
+		PublicKey: base64.StdEncoding.EncodeToString(d.PublicKey),
+		DNSTXT:    d.DNSTXTValue(),
+	}
+}
+
+// AliasManager edits domains and aliases, either in a config file directly
+// (OpenFileAliasManager) or through a running server's admin API
+// (NewAdminAliasManager).
+type AliasManager interface {
+	ListDomains() ([]DomainSummary, error)
+	// AddDomain creates a domain with a fresh signing key.
+	AddDomain(domain string) (DomainSummary, error)
+	RemoveDomain(domain string) error
+	ListAliases(domain string) ([]WalletAlias, error)
+	// AddAlias stores a new alias entry and fails with ErrAliasExists if the
+	// alias already has one for the wallet's ticker.
+	AddAlias(domain string, alias WalletAlias) error
+	// RemoveAlias removes the alias's entry for ticker, or all of them when
+	// ticker is empty.
+	RemoveAlias(domain, alias, ticker string) error
+	// PutTag adds or replaces a tag on the alias entry for ticker.
+	PutTag(domain, alias, ticker string, tag WalletTag) error
+	// RemoveTag removes a tag from the alias entry for ticker, or from every
+	// entry of the alias when ticker is empty.
+	RemoveTag(domain, alias, ticker, tag string) error
+	Close() error
+}
+
+// ParseAliasName splits alias[+tag]$domain into its parts.
+func ParseAliasName(input string) (alias, tag, domain string, err error) {
+	prefix, alias, tag, domain, err := parseAliasParts(input)
+	if err != nil {
+		return "", "", "", err
+	}
+	if prefix != "" {
+		return "", "", "", fmt.Errorf("%w: ticker prefixes are not allowed here", ErrInvalidAlias)
+	}
+	return alias, tag, domain, nil
+}
+
+// fileAliasManager edits a config file with ConfigStore.Update, and aliases
+// in its alias store.
+type fileAliasManager struct {
+	store *ConfigStore
+	db    *SQLiteAliasStore
+}
+
+// OpenFileAliasManager manages the config at configPath without a running
+// server. A running server picks the changes up like any config edit.
+func OpenFileAliasManager(configPath string) (AliasManager, error) {
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	store := NewConfigStore(configPath, cfg)
+	db, err := openAliasStore(store)
+	if err != nil {
+		return nil, err
+	}
+	return &fileAliasManager{store: store, db: db}, nil
+}
+
+func (m *fileAliasManager) Close() error {
+	if m.db == nil {
+		return nil
+	}
+	return m.db.Close()
+}
+
+func (m *fileAliasManager) ListDomains() ([]DomainSummary, error) {
+	cfg := m.store.Get()
+	out := make([]DomainSummary, 0, len(cfg.Domains))
+	for _, d := range cfg.Domains {
+		out = append(out, summarizeDomain(d))
+	}
+	return out, nil
+}
+
+func (m *fileAliasManager) AddDomain(domain string) (DomainSummary, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if err := m.store.Update(func(c *Config) error {
+		if _, err := configDomain(c, domain); err == nil {
+			return fmt.Errorf("%w: %s", ErrDomainExists, domain)
+		}
+		c.Domains = append(c.Domains, AliasDomainConfig{Domain: domain})
+		return nil
+	}); err != nil {
+		return DomainSummary{}, err
+	}
+	d, err := m.store.Get().GetDomain(domain)
+	if err != nil {
+		return DomainSummary{}, err
+	}
+	return summarizeDomain(*d), nil
+}
+
+func (m *fileAliasManager) RemoveDomain(domain string) error {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if err := m.store.Update(func(c *Config) error {
+		for i := range c.Domains {
+			if c.Domains[i].Domain == domain {
+				c.Domains = append(c.Domains[:i], c.Domains[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("domain %q is not configured", domain)
+	}); err != nil {
+		return err
+	}
+	dropDomainAliases(m.store, domain)
+	return nil
+}
+
+func (m *fileAliasManager) ListAliases(domain string) ([]WalletAlias, error) {
+	d, err := configDomain(m.store.Get(), domain)
+	if err != nil {
+		return nil, err
+	}
+	return m.store.Aliases().ListAliases(d.Domain)
+}
+
+func (m *fileAliasManager) AddAlias(domain string, alias WalletAlias) error {
+	normalizeWalletAlias(&alias)
+	if _, found, err := m.entry(domain, alias.Alias, alias.Wallet.Ticker); err != nil {
+		return err
+	} else if found {
+		return fmt.Errorf("%w: %s$%s (%s)", ErrAliasExists, alias.Alias, domain, alias.Wallet.Ticker)
+	}
+	return m.store.Aliases().PutAlias(domain, alias)
+}
+
+func (m *fileAliasManager) RemoveAlias(domain, alias, ticker string) error {
+	return m.store.Aliases().DeleteAlias(strings.ToLower(domain), alias, ticker)
+}
+
+func (m *fileAliasManager) PutTag(domain, alias, ticker string, tag WalletTag) error {
+	entry, found, err := m.entry(domain, alias, ticker)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrAliasNotFound
+	}
+	tag.Tag = strings.ToLower(strings.TrimSpace(tag.Tag))
+	if i := findTag(entry, tag.Tag); i >= 0 {
+		entry.Tags[i] = tag
+	} else {
+		entry.Tags = append(entry.Tags, tag)
+	}
+	return m.store.Aliases().PutAlias(domain, entry)
+}
+
+func (m *fileAliasManager) RemoveTag(domain, alias, ticker, tag string) error {
+	list, err := m.ListAliases(domain)
+	if err != nil {
+		return err
+	}
+	alias, ticker = strings.ToLower(alias), strings.ToLower(ticker)
+	removed := false
+	for _, entry := range list {
+		if entry.Alias != alias || (ticker != "" && entry.Wallet.Ticker != ticker) {
+			continue
+		}
+		i := findTag(entry, tag)
+		if i < 0 {
+			continue
+		}
+		entry.Tags = append(entry.Tags[:i], entry.Tags[i+1:]...)
+		if err := m.store.Aliases().PutAlias(domain, entry); err != nil {
+			return err
+		}
+		removed = true
+	}
+	if !removed {
+		return ErrTagNotFound
+	}
+	return nil
+}
+
+// entry returns the alias entry for ticker.
+func (m *fileAliasManager) entry(domain, alias, ticker string) (WalletAlias, bool, error) {
+	list, err := m.ListAliases(domain)
+	if err != nil {
+		return WalletAlias{}, false, err
+	}
+	alias, ticker = strings.ToLower(alias), strings.ToLower(ticker)
+	for _, entry := range list {
+		if entry.Alias == alias && entry.Wallet.Ticker == ticker {
+			return entry, true, nil
+		}
+	}
+	return WalletAlias{}, false, nil
+}
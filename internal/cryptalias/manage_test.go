@@ -0,0 +1,86 @@
+package cryptalias
+
+import (
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestAliasManagers(t *testing.T) {
+	managers := map[string]func(t *testing.T, path string) AliasManager{
+		"file": func(t *testing.T, path string) AliasManager {
+			m, err := OpenFileAliasManager(path)
+			if err != nil {
+				t.Fatalf("open: %v", err)
+			}
+			return m
+		},
+		"admin": func(t *testing.T, path string) AliasManager {
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			srv := httptest.NewServer(AdminHandler(NewConfigStore(path, cfg)))
+			t.Cleanup(srv.Close)
+			return NewAdminAliasManager(srv.URL, testAdminToken)
+		},
+	}
+	for name, open := range managers {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			cfg := testConfig(t)
+			cfg.Admin = AdminConfig{Listen: "127.0.0.1:0", Token: testAdminToken}
+			if err := SaveConfig(path, cfg); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			m := open(t, path)
+			defer m.Close()
+
+			d, err := m.AddDomain("Shop.Example")
+			if err != nil || d.Domain != "shop.example" || d.DNSTXT != "pubkey="+d.PublicKey || d.PublicKey == "" {
+				t.Fatalf("add domain: %+v %v", d, err)
+			}
+			if _, err := m.AddDomain("shop.example"); !errors.Is(err, ErrDomainExists) {
+				t.Fatalf("expected ErrDomainExists, got %v", err)
+			}
+
+			alias := WalletAlias{Alias: "Sales", Wallet: WalletAddress{Ticker: "xmr", Address: testMoneroAddress(t, 3)}}
+			if err := m.AddAlias("shop.example", alias); err != nil {
+				t.Fatalf("add alias: %v", err)
+			}
+			if err := m.AddAlias("shop.example", alias); !errors.Is(err, ErrAliasExists) {
+				t.Fatalf("expected ErrAliasExists, got %v", err)
+			}
+			if err := m.PutTag("shop.example", "sales", "xmr", WalletTag{Tag: "eu", Wallet: WalletAddress{Ticker: "xmr"}}); err != nil {
+				t.Fatalf("put tag: %v", err)
+			}
+			list, err := m.ListAliases("shop.example")
+			if err != nil || len(list) != 1 || list[0].Alias != "sales" || len(list[0].Tags) != 1 {
+				t.Fatalf("unexpected aliases %+v %v", list, err)
+			}
+
+			if err := m.RemoveTag("shop.example", "sales", "", "eu"); err != nil {
+				t.Fatalf("remove tag: %v", err)
+			}
+			if err := m.RemoveTag("shop.example", "sales", "", "eu"); err == nil {
+				t.Fatalf("expected removing a missing tag to fail")
+			}
+			if err := m.RemoveAlias("shop.example", "sales", ""); err != nil {
+				t.Fatalf("remove alias: %v", err)
+			}
+			if err := m.RemoveDomain("shop.example"); err != nil {
+				t.Fatalf("remove domain: %v", err)
+			}
+
+			domains, err := m.ListDomains()
+			if err != nil || len(domains) != 1 || domains[0].Domain != "127.0.0.1" {
+				t.Fatalf("unexpected domains %+v %v", domains, err)
+			}
+			loaded, err := LoadConfig(path)
+			if err != nil || len(loaded.Domains) != 1 {
+				t.Fatalf("expected the changes on disk, got %+v %v", loaded, err)
+			}
+		})
+	}
+}
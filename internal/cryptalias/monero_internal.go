@@ -2,9 +2,14 @@ package cryptalias
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -13,57 +18,77 @@ import (
 	"github.com/gabstv/httpdigest"
 	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
 	"gitlab.com/moneropay/go-monero/walletrpc"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
 )
 
-const internalBufSize = 1024 * 1024
+const IntegrationMoneroWalletRPC = "monero-wallet-rpc"
 
-type internalMoneroGRPC struct {
-	client cryptaliasv1.WalletServiceClient
-	svc    *moneroWalletService
+func init() {
+	registerInternalIntegration(IntegrationMoneroWalletRPC, func() internalIntegration {
+		// monero-wallet-rpc compatible wallets (Monero and forks such as
+		// Wownero) share the RPC.
+		rpcs := newMoneroRPCs()
+		return newWalletTargetIntegration("monero", namedWalletEndpoint, func(ep TokenEndpointConfig) cryptaliasv1.WalletServiceServer {
+			return newSharedMoneroWalletService(ep, rpcs.get(ep.EndpointAddress))
+		})
+	})
 }
 
-// newInternalMoneroGRPC spins up an in-process gRPC server over bufconn so the
-// internal integration exercises the same protobuf contract as external plugins.
-func newInternalMoneroGRPC(endpoint TokenEndpointConfig) (*internalMoneroGRPC, error) {
-	lis := bufconn.Listen(internalBufSize)
-	svc := newMoneroWalletService(endpoint)
+// moneroRPC is the state of one monero-wallet-rpc process. The RPC has a
+// single open wallet, so every service using it, whatever its token or named
+// wallet, goes through mu and reopens its wallet when another was opened in
+// between.
+type moneroRPC struct {
+	// mu is held exclusively while a wallet is opened or closed and shared
+	// while addresses are issued from an already open persistent session.
+	mu sync.RWMutex
+	// session is the persistent session whose wallet is open, nil when the
+	// open wallet belongs to no session.
+	session *moneroWalletSession
+}
 
-	s := grpc.NewServer()
-	cryptaliasv1.RegisterWalletServiceServer(s, svc)
-	go func() {
-		_ = s.Serve(lis)
-	}()
+type moneroRPCs struct {
+	mu   sync.Mutex
+	rpcs map[string]*moneroRPC
+}
 
-	dialer := func(context.Context, string) (net.Conn, error) {
-		return lis.Dial()
-	}
-	conn, err := grpc.DialContext(context.Background(), "bufnet-monero",
-		grpc.WithContextDialer(dialer),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	if err != nil {
-		return nil, err
-	}
-	client := cryptaliasv1.NewWalletServiceClient(conn)
-	return &internalMoneroGRPC{client: client, svc: svc}, nil
+func newMoneroRPCs() *moneroRPCs {
+	return &moneroRPCs{rpcs: map[string]*moneroRPC{}}
 }
 
-func (i *internalMoneroGRPC) Client(endpoint TokenEndpointConfig) cryptaliasv1.WalletServiceClient {
-	i.svc.SetEndpoint(endpoint)
-	return i.client
+// get returns the shared state of the wallet RPC at address.
+func (r *moneroRPCs) get(address string) *moneroRPC {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rpc, ok := r.rpcs[address]
+	if !ok {
+		rpc = &moneroRPC{}
+		r.rpcs[address] = rpc
+	}
+	return rpc
 }
 
 type moneroWalletService struct {
 	cryptaliasv1.UnimplementedWalletServiceServer
 	endpoint atomic.Value // TokenEndpointConfig
-	mu       sync.Mutex
+	rpc      *moneroRPC
+}
+
+// moneroWalletSession is a wallet kept open across requests in persistent mode.
+type moneroWalletSession struct {
+	client   *walletrpc.Client
+	endpoint TokenEndpointConfig
+	modTime  time.Time
 }
 
+// newMoneroWalletService returns a service that does not share its wallet
+// RPC with other services.
 func newMoneroWalletService(endpoint TokenEndpointConfig) *moneroWalletService {
-	s := &moneroWalletService{}
+	return newSharedMoneroWalletService(endpoint, &moneroRPC{})
+}
+
+func newSharedMoneroWalletService(endpoint TokenEndpointConfig, rpc *moneroRPC) *moneroWalletService {
+	s := &moneroWalletService{rpc: rpc}
 	s.SetEndpoint(endpoint)
 	return s
 }
@@ -73,39 +98,237 @@ func (s *moneroWalletService) SetEndpoint(endpoint TokenEndpointConfig) {
 }
 
 func (s *moneroWalletService) GetAddress(ctx context.Context, req *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	var resp *cryptaliasv1.WalletAddressResponse
+	err := s.withWallet(ctx, func(client *walletrpc.Client) error {
+		var err error
+		resp, err = s.issueAddress(ctx, client, req)
+		return err
+	})
+	return resp, err
+}
+
+// ListPayments reports incoming transfers from get_transfers, including the
+// transaction pool.
+func (s *moneroWalletService) ListPayments(ctx context.Context, req *cryptaliasv1.ListPaymentsRequest) (*cryptaliasv1.ListPaymentsResponse, error) {
+	resp := &cryptaliasv1.ListPaymentsResponse{}
+	err := s.withWallet(ctx, func(client *walletrpc.Client) error {
+		h, err := client.GetHeight(ctx)
+		if err != nil {
+			return err
+		}
+		// wallet2 filters on height > min_height.
+		minHeight := req.GetMinHeight()
+		if minHeight > 0 {
+			minHeight--
+		}
+		transfers, err := client.GetTransfers(ctx, &walletrpc.GetTransfersRequest{
+			In:             true,
+			Pool:           true,
+			FilterByHeight: minHeight > 0,
+			MinHeight:      minHeight,
+			AccountIndex:   req.GetAccountIndex(),
+		})
+		if err != nil {
+			return err
+		}
+		resp.Height = h.Height
+		for _, list := range [][]walletrpc.Transfer{transfers.In, transfers.Pool} {
+			for _, t := range list {
+				resp.Payments = append(resp.Payments, moneroPayment(t))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// IsAddressUsed looks the subaddress up with get_address_index and checks it
+// for incoming transfers, including the transaction pool.
+func (s *moneroWalletService) IsAddressUsed(ctx context.Context, req *cryptaliasv1.AddressUsedRequest) (*cryptaliasv1.AddressUsedResponse, error) {
+	resp := &cryptaliasv1.AddressUsedResponse{}
+	err := s.withWallet(ctx, func(client *walletrpc.Client) error {
+		idx, err := client.GetAddressIndex(ctx, &walletrpc.GetAddressIndexRequest{Address: req.GetAddress()})
+		if err != nil {
+			return err
+		}
+		transfers, err := client.GetTransfers(ctx, &walletrpc.GetTransfersRequest{
+			In:             true,
+			Pool:           true,
+			AccountIndex:   idx.Index.Major,
+			SubaddrIndices: []uint64{idx.Index.Minor},
+		})
+		if err != nil {
+			return err
+		}
+		resp.Used = len(transfers.In) > 0 || len(transfers.Pool) > 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func moneroPayment(t walletrpc.Transfer) *cryptaliasv1.Payment {
+	p := &cryptaliasv1.Payment{
+		Txid:          t.Txid,
+		Address:       t.Address,
+		Amount:        walletrpc.XMRToDecimal(t.Amount),
+		Confirmations: t.Confirmations,
+		Height:        t.Height,
+		Timestamp:     int64(t.Timestamp),
+	}
+	// Transfers without a payment ID report it as all zeros.
+	if pid := strings.ToLower(t.PaymentId); strings.Trim(pid, "0") != "" {
+		p.PaymentId = proto.String(pid)
+	}
+	return p
+}
+
+// withWallet runs fn against the configured wallet: the persistent session,
+// or a wallet opened and closed around the call.
+func (s *moneroWalletService) withWallet(ctx context.Context, fn func(*walletrpc.Client) error) error {
 	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+	if ep.WalletSession == WalletSessionPersistent {
+		return s.withPersistentWallet(ctx, ep, fn)
+	}
+
 	client := s.newWalletRPC(ep.EndpointAddress, ep.Username, ep.Password)
 
 	// monero-wallet-rpc can only have one wallet open at a time; serialize access.
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Alias-local account routing is optional; default to account 0.
-	accountIndex := uint64(0)
-	if req.AccountIndex != nil {
-		accountIndex = req.GetAccountIndex()
-	}
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
 
 	if strings.TrimSpace(ep.WalletFile) != "" {
+		// Opening a wallet here replaces any persistent session's wallet.
+		s.rpc.session = nil
 		// When wallet_file is configured, open/close per request to avoid leaking
 		// state across reloads and to keep behavior explicit.
 		if err := client.OpenWallet(ctx, &walletrpc.OpenWalletRequest{
 			Filename: ep.WalletFile,
 			Password: ep.WalletPassword,
 		}); err != nil {
-			return nil, err
+			return err
 		}
 		defer client.CloseWallet(ctx)
 	}
+	return fn(client)
+}
+
+// withPersistentWallet runs fn against a long-lived wallet session. The
+// wallet is only reopened when the endpoint config or wallet file changes,
+// when another wallet was opened on the same RPC, or once after the wallet
+// RPC lost it.
+func (s *moneroWalletService) withPersistentWallet(ctx context.Context, ep TokenEndpointConfig, fn func(*walletrpc.Client) error) error {
+	for attempt := 0; ; attempt++ {
+		client, err := s.withSession(ctx, ep, fn)
+		if err == nil || client == nil || attempt > 0 || !moneroSessionLost(err) {
+			return err
+		}
+		slog.Warn("monero wallet session lost, reopening", "address", ep.EndpointAddress, "error", err)
+		s.dropSession(client)
+	}
+}
+
+// withSession runs fn while this service's wallet is the one open in the
+// RPC, opening it first if needed. It returns the session client fn ran
+// against, or nil if the wallet could not be opened.
+func (s *moneroWalletService) withSession(ctx context.Context, ep TokenEndpointConfig, fn func(*walletrpc.Client) error) (*walletrpc.Client, error) {
+	modTime := walletFileModTime(ep.WalletFilePath)
+
+	s.rpc.mu.RLock()
+	if sess := s.rpc.session; sess != nil && sameMoneroSession(sess.endpoint, ep) && sess.modTime.Equal(modTime) {
+		defer s.rpc.mu.RUnlock()
+		return sess.client, fn(sess.client)
+	}
+	s.rpc.mu.RUnlock()
+
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
+	// Another request may have reopened the wallet while we waited.
+	sess := s.rpc.session
+	if sess == nil || !sameMoneroSession(sess.endpoint, ep) || !sess.modTime.Equal(modTime) {
+		s.rpc.session = nil
+		client := s.newWalletRPC(ep.EndpointAddress, ep.Username, ep.Password)
+		if strings.TrimSpace(ep.WalletFile) != "" {
+			// open_wallet saves and closes whatever wallet was open before.
+			if err := client.OpenWallet(ctx, &walletrpc.OpenWalletRequest{
+				Filename: ep.WalletFile,
+				Password: ep.WalletPassword,
+			}); err != nil {
+				return nil, err
+			}
+		}
+		sess = &moneroWalletSession{client: client, endpoint: ep, modTime: modTime}
+		s.rpc.session = sess
+		slog.Info("monero wallet session opened", "address", ep.EndpointAddress, "wallet_file", ep.WalletFile)
+	}
+	return sess.client, fn(sess.client)
+}
+
+// sameMoneroSession reports whether an open session still matches the
+// endpoint settings that determine which wallet is open and how.
+func sameMoneroSession(a, b TokenEndpointConfig) bool {
+	return a.EndpointAddress == b.EndpointAddress &&
+		a.Username == b.Username &&
+		a.Password == b.Password &&
+		a.WalletFile == b.WalletFile &&
+		a.WalletPassword == b.WalletPassword &&
+		a.WalletFilePath == b.WalletFilePath
+}
+
+func (s *moneroWalletService) dropSession(client *walletrpc.Client) {
+	s.rpc.mu.Lock()
+	defer s.rpc.mu.Unlock()
+	if s.rpc.session != nil && s.rpc.session.client == client {
+		s.rpc.session = nil
+	}
+}
+
+// moneroSessionLost reports whether an error means the open wallet is gone
+// and the call never ran: the RPC reports no open wallet, e.g. after a
+// restart, or could not be dialed. Other transport errors, such as a reset
+// after create_address was sent, must not be retried since the wallet may
+// already have created the subaddress.
+func moneroSessionLost(err error) bool {
+	if isWalletErr, werr := walletrpc.GetWalletError(err); isWalletErr {
+		return werr.Code == walletrpc.ErrNotOpen
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
 
-	label := req.GetDomain() + ":" + req.GetAlias()
-	if tag := req.GetTag(); tag != "" {
-		label += "+" + tag
+// walletFileModTime returns the wallet file's modification time, or the zero
+// time when no local path is configured or it cannot be read.
+func walletFileModTime(path string) time.Time {
+	if strings.TrimSpace(path) == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// issueAddress creates the address for a request against an open wallet.
+func (s *moneroWalletService) issueAddress(ctx context.Context, client *walletrpc.Client, req *cryptaliasv1.WalletAddressRequest) (*cryptaliasv1.WalletAddressResponse, error) {
+	// Alias-local account routing is optional; default to account 0.
+	accountIndex := uint64(0)
+	if req.AccountIndex != nil {
+		accountIndex = req.GetAccountIndex()
+	}
+
+	if req.GetAddressMode() == AddressModeIntegrated {
+		return s.integratedAddress(ctx, client)
 	}
 
 	resp, err := client.CreateAddress(ctx, &walletrpc.CreateAddressRequest{
 		AccountIndex: accountIndex,
-		Label:        label,
+		Label:        walletAddressLabel(req),
 	})
 	if err != nil {
 		return nil, err
@@ -114,11 +337,78 @@ func (s *moneroWalletService) GetAddress(ctx context.Context, req *cryptaliasv1.
 	if addr == "" {
 		return nil, fmt.Errorf("monero wallet rpc returned empty address")
 	}
-	return &cryptaliasv1.WalletAddressResponse{Address: addr}, nil
+	return &cryptaliasv1.WalletAddressResponse{
+		Address: addr,
+		Index:   &cryptaliasv1.AddressIndex{Account: accountIndex, Address: resp.AddressIndex},
+	}, nil
+}
+
+// integratedAddress issues the wallet's primary address combined with a fresh
+// payment ID. Monero cannot integrate a payment ID into a subaddress, so
+// account routing hints do not apply in this mode.
+func (s *moneroWalletService) integratedAddress(ctx context.Context, client *walletrpc.Client) (*cryptaliasv1.WalletAddressResponse, error) {
+	pid, err := newMoneroPaymentID()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.MakeIntegratedAddress(ctx, &walletrpc.MakeIntegratedAddressRequest{
+		PaymentId: pid,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.IntegratedAddress == "" {
+		return nil, fmt.Errorf("monero wallet rpc returned empty integrated address")
+	}
+	// Prefer the ID echoed by the wallet in case it normalized ours.
+	if resp.PaymentId != "" {
+		pid = resp.PaymentId
+	}
+	return &cryptaliasv1.WalletAddressResponse{
+		Address:   resp.IntegratedAddress,
+		PaymentId: proto.String(strings.ToLower(pid)),
+	}, nil
 }
 
-func (s *moneroWalletService) Health(context.Context, *cryptaliasv1.HealthRequest) (*cryptaliasv1.HealthResponse, error) {
-	return &cryptaliasv1.HealthResponse{Ok: true, Message: "ok"}, nil
+// newMoneroPaymentID returns a random 8-byte short payment ID, hex encoded.
+func newMoneroPaymentID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// Health checks the wallet RPC with get_version. get_height is only asked
+// when a wallet is expected to be open: a persistent session, or an RPC
+// started with its own wallet (no wallet_file).
+func (s *moneroWalletService) Health(ctx context.Context, _ *cryptaliasv1.HealthRequest) (*cryptaliasv1.HealthResponse, error) {
+	ep, _ := s.endpoint.Load().(TokenEndpointConfig)
+	client := s.newWalletRPC(ep.EndpointAddress, ep.Username, ep.Password)
+	v, err := client.GetVersion(ctx)
+	if err != nil {
+		return &cryptaliasv1.HealthResponse{Ok: false, Message: "get_version: " + err.Error()}, nil
+	}
+	msg := fmt.Sprintf("wallet rpc %d.%d", v.Version>>16, v.Version&0xffff)
+
+	var walletClient *walletrpc.Client
+	if ep.WalletSession == WalletSessionPersistent {
+		s.rpc.mu.RLock()
+		if s.rpc.session != nil && sameMoneroSession(s.rpc.session.endpoint, ep) {
+			walletClient = s.rpc.session.client
+		}
+		s.rpc.mu.RUnlock()
+	} else if strings.TrimSpace(ep.WalletFile) == "" {
+		walletClient = client
+	}
+	if walletClient != nil {
+		h, err := walletClient.GetHeight(ctx)
+		if err != nil {
+			return &cryptaliasv1.HealthResponse{Ok: false, Message: "get_height: " + err.Error()}, nil
+		}
+		msg += fmt.Sprintf(", height %d", h.Height)
+	}
+	return &cryptaliasv1.HealthResponse{Ok: true, Message: msg}, nil
 }
 
 func (s *moneroWalletService) newWalletRPC(url, user, password string) *walletrpc.Client {
@@ -0,0 +1,315 @@
+package cryptalias
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeMoneroWalletRPC answers the JSON-RPC methods the internal Monero
+// integration uses and counts calls per method. Like the real RPC it has one
+// open wallet, which prefixes the addresses it creates.
+type fakeMoneroWalletRPC struct {
+	mu    sync.Mutex
+	calls map[string]int
+	open  string
+	// delay holds create_address open so overlapping requests show.
+	delay time.Duration
+	// notOpen makes the next create_address fail as if the RPC restarted.
+	notOpen bool
+	// transfers and height answer get_transfers and get_height.
+	transfers []map[string]any
+	height    uint64
+}
+
+func (f *fakeMoneroWalletRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string `json:"method"`
+		ID     any    `json:"id"`
+		Params struct {
+			Filename string `json:"filename"`
+		} `json:"params"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	f.calls[req.Method]++
+	n := f.calls[req.Method]
+	fail := req.Method == "create_address" && f.notOpen
+	if fail {
+		f.notOpen = false
+	}
+	switch req.Method {
+	case "open_wallet":
+		f.open = req.Params.Filename
+	case "close_wallet":
+		f.open = ""
+	}
+	open, delay := f.open, f.delay
+	f.mu.Unlock()
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	switch {
+	case fail:
+		resp["error"] = map[string]any{"code": -13, "message": "No wallet file"}
+	case req.Method == "create_address":
+		time.Sleep(delay)
+		f.mu.Lock()
+		switched := f.open != open
+		f.mu.Unlock()
+		if switched {
+			resp["error"] = map[string]any{"code": -1, "message": "wallet switched during create_address"}
+			break
+		}
+		addr := fmt.Sprintf("addr-%d", n)
+		if open != "" {
+			addr = fmt.Sprintf("%s-%d", open, n)
+		}
+		resp["result"] = map[string]any{"address": addr, "address_index": n}
+	case req.Method == "get_transfers":
+		f.mu.Lock()
+		resp["result"] = map[string]any{"in": f.transfers}
+		f.mu.Unlock()
+	case req.Method == "get_height":
+		f.mu.Lock()
+		resp["result"] = map[string]any{"height": f.height}
+		f.mu.Unlock()
+	default:
+		resp["result"] = map[string]any{}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeMoneroWalletRPC) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func TestMoneroPersistentSessionOpensWalletOnce(t *testing.T) {
+	rpc := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	ep := TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeInternal,
+		EndpointAddress: srv.URL,
+		WalletFile:      "main",
+		WalletSession:   WalletSessionPersistent,
+	}
+	svc := newMoneroWalletService(ep)
+	req := &cryptaliasv1.WalletAddressRequest{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.GetAddress(context.Background(), req); err != nil {
+				t.Errorf("get address: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := rpc.count("open_wallet"); got != 1 {
+		t.Fatalf("expected wallet to be opened once, got %d", got)
+	}
+	if got := rpc.count("close_wallet"); got != 0 {
+		t.Fatalf("expected wallet to stay open, got %d closes", got)
+	}
+
+	// A lost wallet is reopened and the request retried once.
+	rpc.mu.Lock()
+	rpc.notOpen = true
+	rpc.mu.Unlock()
+	if _, err := svc.GetAddress(context.Background(), req); err != nil {
+		t.Fatalf("get address after lost session: %v", err)
+	}
+	if got := rpc.count("open_wallet"); got != 2 {
+		t.Fatalf("expected wallet to be reopened, got %d opens", got)
+	}
+
+	// A config reload with a different wallet reopens the session.
+	ep.WalletFile = "other"
+	svc.SetEndpoint(ep)
+	if _, err := svc.GetAddress(context.Background(), req); err != nil {
+		t.Fatalf("get address after reload: %v", err)
+	}
+	if got := rpc.count("open_wallet"); got != 3 {
+		t.Fatalf("expected wallet to be reopened after reload, got %d opens", got)
+	}
+}
+
+func TestMoneroPersistentSessionDoesNotRetryDroppedCreateAddress(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     any    `json:"id"`
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		mu.Lock()
+		calls[req.Method]++
+		mu.Unlock()
+		if req.Method == "create_address" {
+			// The wallet received the call, then the connection drops.
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			_ = conn.Close()
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": map[string]any{}})
+	}))
+	defer srv.Close()
+
+	svc := newMoneroWalletService(TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeInternal,
+		EndpointAddress: srv.URL,
+		WalletFile:      "main",
+		WalletSession:   WalletSessionPersistent,
+	})
+	if _, err := svc.GetAddress(context.Background(), &cryptaliasv1.WalletAddressRequest{Ticker: "xmr", Alias: "demo", Domain: "example.com"}); err == nil {
+		t.Fatalf("expected the dropped connection to fail the request")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["create_address"] != 1 || calls["open_wallet"] != 1 {
+		t.Fatalf("expected no retry after create_address was sent, got %v", calls)
+	}
+}
+
+func TestMoneroPerRequestSessionClosesWallet(t *testing.T) {
+	rpc := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	svc := newMoneroWalletService(TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeInternal,
+		EndpointAddress: srv.URL,
+		WalletFile:      "main",
+	})
+	req := &cryptaliasv1.WalletAddressRequest{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	for i := 0; i < 2; i++ {
+		if _, err := svc.GetAddress(context.Background(), req); err != nil {
+			t.Fatalf("get address: %v", err)
+		}
+	}
+	if rpc.count("open_wallet") != 2 || rpc.count("close_wallet") != 2 {
+		t.Fatalf("expected open/close per request, got %d/%d", rpc.count("open_wallet"), rpc.count("close_wallet"))
+	}
+}
+
+func TestMoneroWalletIDRoutesToNamedWallet(t *testing.T) {
+	personal := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	personalSrv := httptest.NewServer(personal)
+	defer personalSrv.Close()
+	business := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	businessSrv := httptest.NewServer(business)
+	defer businessSrv.Close()
+
+	endpoint := TokenEndpointConfig{
+		EndpointType:    TokenEndpointTypeInternal,
+		EndpointAddress: personalSrv.URL,
+		WalletFile:      "personal",
+		Wallets: []InternalWalletConfig{
+			{ID: "savings", WalletFile: "savings"},
+			{ID: "business", Address: businessSrv.URL, WalletFile: "business"},
+		},
+	}
+
+	ep, err := namedWalletEndpoint(endpoint, proto.String("savings"))
+	if err != nil {
+		t.Fatalf("resolve savings wallet: %v", err)
+	}
+	if ep.EndpointAddress != personalSrv.URL || ep.WalletFile != "savings" {
+		t.Fatalf("unexpected savings endpoint: %+v", ep)
+	}
+	if _, err := namedWalletEndpoint(endpoint, proto.String("missing")); err == nil {
+		t.Fatalf("expected unknown wallet_id to fail")
+	}
+
+	// Requests for wallets on the same RPC and for another token's wallet
+	// there overlap; each address must still come from its own wallet.
+	other := TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: personalSrv.URL, WalletFile: "other"}
+	sessions := []string{"", WalletSessionPersistent}
+	for _, session := range sessions {
+		t.Run("session "+session, func(t *testing.T) {
+			personal.mu.Lock()
+			personal.delay = 5 * time.Millisecond
+			personal.mu.Unlock()
+			endpoint := endpoint
+			endpoint.WalletSession = session
+			wallets := internalIntegrations[IntegrationMoneroWalletRPC]()
+			targets := []struct {
+				endpoint TokenEndpointConfig
+				id       *string
+				wallet   string
+			}{
+				{endpoint, nil, "personal"},
+				{endpoint, proto.String("savings"), "savings"},
+				{endpoint, proto.String("business"), "business"},
+				{other, nil, "other"},
+			}
+			req := &cryptaliasv1.WalletAddressRequest{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+			var wg sync.WaitGroup
+			for i := 0; i < 24; i++ {
+				target := targets[i%len(targets)]
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					client, err := wallets.Client(target.endpoint, target.id)
+					if err != nil {
+						t.Errorf("monero client %s: %v", target.wallet, err)
+						return
+					}
+					resp, err := client.GetAddress(context.Background(), req)
+					if err != nil {
+						t.Errorf("get address %s: %v", target.wallet, err)
+						return
+					}
+					if !strings.HasPrefix(resp.GetAddress(), target.wallet+"-") {
+						t.Errorf("wallet %s issued %s", target.wallet, resp.GetAddress())
+					}
+				}()
+			}
+			wg.Wait()
+		})
+	}
+	if business.count("open_wallet") == 0 || business.count("create_address") != 6*len(sessions) {
+		t.Fatalf("unexpected business routing: %v", business.calls)
+	}
+}
+
+func TestInternalIntegrationRegistry(t *testing.T) {
+	cases := []struct {
+		token TokenConfig
+		want  string
+	}{
+		{TokenConfig{Tickers: []string{"XMR"}}, IntegrationMoneroWalletRPC},
+		{TokenConfig{Tickers: []string{"xmr-stagenet"}, Chain: "monero"}, IntegrationMoneroWalletRPC},
+		{TokenConfig{Tickers: []string{"wow"}, Endpoint: TokenEndpointConfig{Integration: IntegrationMoneroWalletRPC}}, IntegrationMoneroWalletRPC},
+		{TokenConfig{Tickers: []string{"btc"}}, ""},
+	}
+	for _, tc := range cases {
+		if got := tc.token.IntegrationOrDefault(); got != tc.want {
+			t.Fatalf("tickers %v: expected integration %q, got %q", tc.token.Tickers, tc.want, got)
+		}
+	}
+
+	wallets := newInternalWallets()
+	if _, err := wallets.client(TokenConfig{Name: "Bitcoin", Tickers: []string{"btc"}}, nil); err == nil {
+		t.Fatalf("expected token without integration to fail")
+	}
+	if _, err := wallets.client(TokenConfig{Name: "Other", Endpoint: TokenEndpointConfig{Integration: "nope"}}, nil); err == nil {
+		t.Fatalf("expected unknown integration to fail")
+	}
+}
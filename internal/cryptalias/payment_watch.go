@@ -0,0 +1,286 @@
+package cryptalias
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ReceivedPayment is an incoming transfer to an address or payment ID that
+// Cryptalias issued, correlated back to the alias it was issued for.
+type ReceivedPayment struct {
+	Ticker        string    `json:"ticker"`
+	Token         string    `json:"token"`
+	Backend       string    `json:"backend,omitempty"`
+	Domain        string    `json:"domain"`
+	Alias         string    `json:"alias"`
+	Tag           string    `json:"tag,omitempty"`
+	Address       string    `json:"address"`
+	PaymentID     string    `json:"payment_id,omitempty"`
+	Reference     string    `json:"reference,omitempty"`
+	TxID          string    `json:"txid"`
+	Amount        string    `json:"amount"`
+	Confirmations uint64    `json:"confirmations"`
+	Height        uint64    `json:"height,omitempty"`
+	Confirmed     bool      `json:"confirmed"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// paymentWatchTarget is one wallet and account polled for a token backend.
+type paymentWatchTarget struct {
+	backend      TokenConfig
+	walletID     *string
+	accountIndex uint64
+}
+
+func (t paymentWatchTarget) key() string {
+	walletID := ""
+	if t.walletID != nil {
+		walletID = *t.walletID
+	}
+	return fmt.Sprintf("%s|%s|%d", t.backend.Name, walletID, t.accountIndex)
+}
+
+// paymentWatchTargets lists the wallets and accounts aliases of the token can
+// be routed to, so every issued address is covered by a poll.
+func paymentWatchTargets(cfg *Config, aliases AliasStore, token TokenConfig) []paymentWatchTarget {
+	type route struct {
+		walletID     string
+		accountIndex uint64
+	}
+	routes := []route{{}}
+	seen := map[route]bool{{}: true}
+	add := func(w WalletAddress) {
+		if !tokenHasTicker(token, w.Ticker) {
+			return
+		}
+		var r route
+		if w.WalletID != nil {
+			r.walletID = *w.WalletID
+		}
+		if w.AccountIndex != nil {
+			r.accountIndex = *w.AccountIndex
+		}
+		if !seen[r] {
+			seen[r] = true
+			routes = append(routes, r)
+		}
+	}
+	for _, d := range cfg.Domains {
+		list, err := aliases.ListAliases(d.Domain)
+		if err != nil {
+			slog.Warn("payment watch alias listing failed", "domain", d.Domain, "error", err)
+			continue
+		}
+		for _, a := range list {
+			add(a.Wallet)
+			for _, t := range a.Tags {
+				add(t.Wallet)
+			}
+		}
+	}
+
+	var out []paymentWatchTarget
+	for _, b := range token.backends() {
+		switch b.Endpoint.EndpointType {
+		case TokenEndpointTypeInternal, TokenEndpointTypeExternal:
+		default:
+			// HTTP and exec endpoints have no payment listing.
+			continue
+		}
+		for _, r := range routes {
+			if r.walletID != "" && len(b.Endpoint.Wallets) > 0 {
+				if _, ok := b.Endpoint.findWallet(r.walletID); !ok {
+					continue
+				}
+			}
+			t := paymentWatchTarget{backend: b, accountIndex: r.accountIndex}
+			if r.walletID != "" {
+				t.walletID = proto.String(r.walletID)
+			}
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func tokenHasTicker(token TokenConfig, ticker string) bool {
+	for _, t := range token.Tickers {
+		if strings.EqualFold(t, ticker) {
+			return true
+		}
+	}
+	return false
+}
+
+// PollPayments asks every watched wallet for incoming transfers and returns
+// the ones to report: payments to issued addresses seen for the first time,
+// and payments that reached the confirmation threshold since the last poll.
+func (r *WalletResolver) PollPayments(ctx context.Context, cfg *Config, aliases AliasStore) []ReceivedPayment {
+	var out []ReceivedPayment
+	now := time.Now().UTC()
+	for _, token := range cfg.Tokens {
+		if len(token.Tickers) == 0 {
+			continue
+		}
+		for _, target := range paymentWatchTargets(cfg, aliases, token) {
+			key := target.key()
+			req := &cryptaliasv1.ListPaymentsRequest{
+				Ticker:    token.Tickers[0],
+				MinHeight: r.state.PaymentCursor(key),
+				WalletId:  target.walletID,
+			}
+			if target.accountIndex != 0 {
+				req.AccountIndex = proto.Uint64(target.accountIndex)
+			}
+			callCtx, cancel := context.WithTimeout(ctx, target.backend.Endpoint.TimeoutOrDefault())
+			resp, err := r.listPayments(callCtx, target.backend, req)
+			cancel()
+			if status.Code(err) == codes.Unimplemented {
+				slog.Debug("wallet backend does not list payments", "backend", target.backend.Name)
+				continue
+			}
+			if err != nil {
+				slog.Warn("payment poll failed", "backend", target.backend.Name, "error", err)
+				continue
+			}
+			for _, p := range resp.GetPayments() {
+				if rp, ok := r.observePayment(cfg, token, target.backend, p, now); ok {
+					out = append(out, rp)
+				}
+			}
+			// Re-read the confirmation window so pending payments are seen
+			// again once they confirm.
+			next := uint64(0)
+			if h := resp.GetHeight(); h > cfg.PaymentWatch.Confirmations {
+				next = h - cfg.PaymentWatch.Confirmations
+			}
+			if err := r.state.SetPaymentCursor(key, next); err != nil {
+				slog.Warn("payment cursor store failed", "backend", target.backend.Name, "error", err)
+			}
+		}
+	}
+	return out
+}
+
+func (r *WalletResolver) listPayments(ctx context.Context, backend TokenConfig, req *cryptaliasv1.ListPaymentsRequest) (*cryptaliasv1.ListPaymentsResponse, error) {
+	if backend.Endpoint.EndpointType == TokenEndpointTypeInternal {
+		client, err := r.internal.client(backend, req.WalletId)
+		if err != nil {
+			return nil, err
+		}
+		return client.ListPayments(ctx, req)
+	}
+	return r.grpc.ListPayments(ctx, backend.Endpoint, req)
+}
+
+// observePayment correlates a transfer with the alias that issued its payment
+// ID or address. Transfers Cryptalias did not issue are ignored.
+func (r *WalletResolver) observePayment(cfg *Config, token TokenConfig, backend TokenConfig, p *cryptaliasv1.Payment, now time.Time) (ReceivedPayment, bool) {
+	var (
+		rec issuedRecord
+		ok  bool
+	)
+	for _, ticker := range token.Tickers {
+		if p.PaymentId != nil {
+			rec, ok = r.state.LookupPaymentID(ticker, p.GetPaymentId())
+		} else {
+			rec, ok = r.state.LookupAddress(ticker, p.GetAddress())
+		}
+		if ok {
+			break
+		}
+	}
+	if !ok {
+		return ReceivedPayment{}, false
+	}
+	// Any transfer, even unconfirmed, retires the address.
+	if err := r.state.MarkUsed(rec.Ticker, rec.Address, now); err != nil {
+		slog.Warn("address usage record failed", "ticker", rec.Ticker, "error", err)
+	}
+
+	confirmed := p.GetConfirmations() >= cfg.PaymentWatch.Confirmations
+	key := strings.Join([]string{rec.Ticker, p.GetTxid(), rec.Address, p.GetPaymentId()}, "|")
+	report, err := r.state.ObservePayment(key, confirmed, now)
+	if err != nil {
+		slog.Warn("payment record failed", "ticker", rec.Ticker, "txid", p.GetTxid(), "error", err)
+	}
+	if !report {
+		return ReceivedPayment{}, false
+	}
+	rp := ReceivedPayment{
+		Ticker:        rec.Ticker,
+		Token:         token.Name,
+		Domain:        rec.Domain,
+		Alias:         rec.Alias,
+		Tag:           rec.Tag,
+		Address:       rec.Address,
+		PaymentID:     p.GetPaymentId(),
+		Reference:     rec.Reference,
+		TxID:          p.GetTxid(),
+		Amount:        p.GetAmount(),
+		Confirmations: p.GetConfirmations(),
+		Height:        p.GetHeight(),
+		Confirmed:     confirmed,
+		Timestamp:     time.Unix(p.GetTimestamp(), 0).UTC(),
+	}
+	if len(token.Endpoints) > 0 {
+		rp.Backend = backend.Endpoint.Name
+	}
+	return rp, true
+}
+
+// paymentWatcher polls wallet services for incoming payments in the
+// background, re-reading the interval from config so reloads apply.
+type paymentWatcher struct {
+	store     *ConfigStore
+	resolver  *WalletResolver
+	onPayment func(ReceivedPayment)
+}
+
+func newPaymentWatcher(store *ConfigStore, resolver *WalletResolver, onPayment func(ReceivedPayment)) *paymentWatcher {
+	return &paymentWatcher{store: store, resolver: resolver, onPayment: onPayment}
+}
+
+func (w *paymentWatcher) Start(ctx context.Context) {
+	if w == nil || w.store == nil || w.resolver == nil {
+		return
+	}
+	go func() {
+		for {
+			cfg := w.store.Get()
+			if cfg.PaymentWatch.Enabled {
+				for _, p := range w.resolver.PollPayments(ctx, cfg, w.store.Aliases()) {
+					logReceivedPayment(p)
+					if w.onPayment != nil {
+						w.onPayment(p)
+					}
+				}
+			}
+			interval := time.Duration(cfg.PaymentWatch.IntervalSeconds) * time.Second
+			if interval <= 0 {
+				interval = 30 * time.Second
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
+
+func logReceivedPayment(p ReceivedPayment) {
+	msg := "payment received"
+	if p.Confirmed {
+		msg = "payment confirmed"
+	}
+	slog.Info(msg, "ticker", p.Ticker, "domain", p.Domain, "alias", p.Alias, "tag", p.Tag, "amount", p.Amount, "txid", p.TxID, "confirmations", p.Confirmations)
+}
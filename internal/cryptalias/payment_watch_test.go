@@ -0,0 +1,63 @@
+package cryptalias
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+)
+
+func TestPollPaymentsCorrelatesIssuedAddresses(t *testing.T) {
+	issued := testMoneroAddress(t, 1)
+	rpc := &fakeMoneroWalletRPC{calls: map[string]int{}, height: 100}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		return &cryptaliasv1.WalletAddressResponse{Address: issued}, nil
+	}
+	resolver := newBreakerTestResolver(t, internalFn)
+	cfg := &Config{
+		PaymentWatch: PaymentWatchConfig{Enabled: true},
+		Tokens: []TokenConfig{{
+			Name:     "Monero",
+			Tickers:  []string{"xmr"},
+			Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: srv.URL},
+		}},
+	}
+	cfg.Normalize("")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "shop", Tag: "donate", Domain: "example.com"}
+	if _, err := resolver.Resolve(context.Background(), cfg, in); err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+
+	rpc.transfers = []map[string]any{
+		{"txid": "aa", "address": issued, "amount": 1500000000, "confirmations": 2, "height": 98, "payment_id": "0000000000000000"},
+		{"txid": "bb", "address": testMoneroAddress(t, 2), "amount": 1, "confirmations": 2, "height": 98},
+	}
+	aliases := NewConfigStore("", cfg)
+	got := resolver.PollPayments(context.Background(), cfg, aliases)
+	if len(got) != 1 {
+		t.Fatalf("expected only the issued address to be reported, got %+v", got)
+	}
+	p := got[0]
+	if p.Domain != "example.com" || p.Alias != "shop" || p.Tag != "donate" || p.TxID != "aa" || p.Amount != "0.0015" || p.PaymentID != "" || p.Confirmed {
+		t.Fatalf("unexpected payment %+v", p)
+	}
+	if cursor := resolver.state.PaymentCursor(paymentWatchTargets(cfg, aliases, cfg.Tokens[0])[0].key()); cursor != 90 {
+		t.Fatalf("expected cursor to keep the confirmation window, got %d", cursor)
+	}
+	if again := resolver.PollPayments(context.Background(), cfg, aliases); len(again) != 0 {
+		t.Fatalf("expected a seen payment not to be reported twice, got %+v", again)
+	}
+
+	rpc.transfers[0]["confirmations"] = 10
+	got = resolver.PollPayments(context.Background(), cfg, aliases)
+	if len(got) != 1 || !got[0].Confirmed {
+		t.Fatalf("expected the payment to be reported once confirmed, got %+v", got)
+	}
+	if again := resolver.PollPayments(context.Background(), cfg, aliases); len(again) != 0 {
+		t.Fatalf("expected a confirmed payment not to be reported again, got %+v", again)
+	}
+}
@@ -23,6 +23,9 @@ type rateLimitSnapshot struct {
 	burst    int
 	strategy ClientIdentityStrategy
 	header   string
+
+	uncachedRPM   int
+	uncachedBurst int
 }
 
 // rateLimiter maintains per-client token buckets and refreshes itself from the
@@ -35,6 +38,13 @@ type rateLimiter struct {
 	identity *clientIdentity
 	current  rateLimitSnapshot
 	entries  map[string]*limiterEntry
+
+	// Requests carrying any of uncached's query parameters also draw from a
+	// second, stricter bucket per client.
+	uncached      []string
+	uncachedLimit rate.Limit
+	uncachedBurst int
+	uncachedUsed  map[string]*limiterEntry
 }
 
 func newRateLimiter(store *ConfigStore) *rateLimiter {
@@ -42,9 +52,29 @@ func newRateLimiter(store *ConfigStore) *rateLimiter {
 		return nil
 	}
 	return &rateLimiter{
-		store:   store,
-		entries: map[string]*limiterEntry{},
+		store:        store,
+		entries:      map[string]*limiterEntry{},
+		uncachedUsed: map[string]*limiterEntry{},
+	}
+}
+
+// uncachedParams names the query parameters that make a request bypass the
+// address cache, so it is also held to rate_limit.uncached_requests_per_minute.
+func (rl *rateLimiter) uncachedParams(names ...string) *rateLimiter {
+	if rl != nil {
+		rl.uncached = names
+	}
+	return rl
+}
+
+func (rl *rateLimiter) isUncached(r *http.Request) bool {
+	query := r.URL.Query()
+	for _, name := range rl.uncached {
+		if query.Get(name) != "" {
+			return true
+		}
 	}
+	return false
 }
 
 func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
@@ -61,7 +91,7 @@ func (rl *rateLimiter) middleware(next http.Handler) http.Handler {
 		rl.refreshIfNeeded(snap)
 
 		client := rl.identity.Key(r)
-		if !rl.allow(client) {
+		if !rl.allow(client) || (rl.isUncached(r) && !rl.allowUncached(client)) {
 			slog.Warn("rate limit exceeded", "client", client, "path", r.URL.Path)
 			w.WriteHeader(http.StatusTooManyRequests)
 			fmt.Fprint(w, "429 too many requests")
@@ -79,6 +109,9 @@ func snapshotFromConfig(cfg *Config) rateLimitSnapshot {
 		burst:    cfg.RateLimit.Burst,
 		strategy: ci.Strategy,
 		header:   ci.Header,
+
+		uncachedRPM:   cfg.RateLimit.UncachedRequestsPerMinute,
+		uncachedBurst: cfg.RateLimit.UncachedBurst,
 	}
 }
 
@@ -91,32 +124,48 @@ func (rl *rateLimiter) refreshIfNeeded(next rateLimitSnapshot) {
 	perSecond := float64(next.rpm) / 60.0
 	rl.limit = rate.Limit(perSecond)
 	rl.burst = next.burst
+	rl.uncachedLimit = rate.Limit(float64(next.uncachedRPM) / 60.0)
+	rl.uncachedBurst = next.uncachedBurst
 	rl.identity = newClientIdentity(ClientIdentityConfig{Strategy: next.strategy, Header: next.header})
 	rl.current = next
 	// Limits or identity changed; reset per-client state to avoid drift.
 	rl.entries = map[string]*limiterEntry{}
+	rl.uncachedUsed = map[string]*limiterEntry{}
 	slog.Info("rate limiter configuration updated", "rpm", next.rpm, "burst", next.burst, "strategy", next.strategy)
 }
 
 func (rl *rateLimiter) allow(client string) bool {
+	return rl.take(false, client)
+}
+
+func (rl *rateLimiter) allowUncached(client string) bool {
+	return rl.take(true, client)
+}
+
+func (rl *rateLimiter) take(uncached bool, client string) bool {
 	now := time.Now().UTC()
 
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	entry, ok := rl.entries[client]
+	entries, limit, burst := rl.entries, rl.limit, rl.burst
+	if uncached {
+		entries, limit, burst = rl.uncachedUsed, rl.uncachedLimit, rl.uncachedBurst
+	}
+
+	entry, ok := entries[client]
 	if !ok {
-		entry = &limiterEntry{limiter: rate.NewLimiter(rl.limit, rl.burst)}
-		rl.entries[client] = entry
+		entry = &limiterEntry{limiter: rate.NewLimiter(limit, burst)}
+		entries[client] = entry
 	}
 	entry.lastSeen = now
 
 	// Lazy cleanup to avoid unbounded growth without a background timer.
-	if len(rl.entries) > 4096 {
+	if len(entries) > 4096 {
 		cutoff := now.Add(-10 * time.Minute)
-		for k, v := range rl.entries {
+		for k, v := range entries {
 			if v.lastSeen.Before(cutoff) {
-				delete(rl.entries, k)
+				delete(entries, k)
 			}
 		}
 	}
@@ -0,0 +1,35 @@
+package cryptalias
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiterLimitsUncachedRequests(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.RateLimit.RequestsPerMinute, cfg.RateLimit.Burst = 60, 10
+	cfg.RateLimit.UncachedRequestsPerMinute, cfg.RateLimit.UncachedBurst = 1, 2
+	handler := newRateLimiter(NewConfigStore("", cfg)).uncachedParams("amount", "memo").middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	get := func(query string) int {
+		req := httptest.NewRequest(http.MethodGet, "/_cryptalias/resolve/xmr/demo$127.0.0.1"+query, nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for i, query := range []string{"?memo=a", "?amount=1", "?memo=b"} {
+		want := http.StatusOK
+		if i == 2 {
+			want = http.StatusTooManyRequests
+		}
+		if code := get(query); code != want {
+			t.Fatalf("request %d: expected %d, got %d", i, want, code)
+		}
+	}
+	if code := get(""); code != http.StatusOK {
+		t.Fatalf("expected plain requests to stay under the general limit, got %d", code)
+	}
+}
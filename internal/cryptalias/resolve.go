@@ -22,8 +22,21 @@ type wellKnownConfig struct {
 }
 
 type resolvedPayload struct {
-	Address string `json:"address"`
-	Expires string `json:"expires"`
+	Address        string  `json:"address"`
+	Memo           string  `json:"memo,omitempty"`
+	DestinationTag *uint32 `json:"destination_tag,omitempty"`
+	PaymentID      string  `json:"payment_id,omitempty"`
+	Expires        string  `json:"expires"`
+}
+
+// ResolvedDestination is a verified resolution result. Memo, DestinationTag
+// and PaymentID are set only when the chain requires them, and callers MUST
+// include them in the payment when present.
+type ResolvedDestination struct {
+	Address        string
+	Memo           string
+	DestinationTag *uint32
+	PaymentID      string
 }
 
 type jwkKey struct {
@@ -34,53 +47,68 @@ type jwkKey struct {
 
 // ResolveAddress resolves alias$domain into a wallet address and verifies the JWS signature.
 func ResolveAddress(ctx context.Context, ticker, alias string) (string, error) {
+	dest, err := ResolveDestination(ctx, ticker, alias)
+	if err != nil {
+		return "", err
+	}
+	return dest.Address, nil
+}
+
+// ResolveDestination resolves alias$domain like ResolveAddress but also
+// returns the signed chain-specific destination fields.
+func ResolveDestination(ctx context.Context, ticker, alias string) (ResolvedDestination, error) {
 	if ticker == "" || alias == "" {
-		return "", errors.New("ticker and alias are required")
+		return ResolvedDestination{}, errors.New("ticker and alias are required")
 	}
 	tickerClean := strings.ToLower(strings.TrimSpace(ticker))
 	if tickerClean == "" {
-		return "", errors.New("ticker and alias are required")
+		return ResolvedDestination{}, errors.New("ticker and alias are required")
 	}
 	prefixTicker, _, _, domain, err := parseAliasParts(alias)
 	if err != nil {
-		return "", err
+		return ResolvedDestination{}, err
 	}
 	if prefixTicker != "" && prefixTicker != tickerClean {
-		return "", fmt.Errorf("ticker prefix %q does not match %q", prefixTicker, tickerClean)
+		return ResolvedDestination{}, fmt.Errorf("ticker prefix %q does not match %q", prefixTicker, tickerClean)
 	}
 
 	cfgURL := fmt.Sprintf("https://%s/.well-known/cryptalias/configuration", domain)
 	cfgBody, err := httpGet(ctx, cfgURL, "application/json")
 	if err != nil {
-		return "", err
+		return ResolvedDestination{}, err
 	}
 
 	var cfg wellKnownConfig
 	if err := json.Unmarshal(cfgBody, &cfg); err != nil {
-		return "", err
+		return ResolvedDestination{}, err
 	}
 	resolver := strings.TrimRight(cfg.Resolver.ResolverEndpoint, "/")
 	if resolver == "" {
-		return "", errors.New("missing resolver_endpoint in configuration")
+		return ResolvedDestination{}, errors.New("missing resolver_endpoint in configuration")
 	}
 	if cfg.Key.X == "" {
-		return "", errors.New("missing key in configuration")
+		return ResolvedDestination{}, errors.New("missing key in configuration")
 	}
 
 	resolveURL := fmt.Sprintf("%s/_cryptalias/resolve/%s/%s", resolver, url.PathEscape(tickerClean), url.PathEscape(alias))
 	jws, err := httpGet(ctx, resolveURL, "application/jose")
 	if err != nil {
-		return "", err
+		return ResolvedDestination{}, err
 	}
 
 	payload, err := verifyJwsAndDecodePayload(string(jws), cfg.Key)
 	if err != nil {
-		return "", err
+		return ResolvedDestination{}, err
 	}
 	if err := enforceExpires(payload.Expires); err != nil {
-		return "", err
-	}
-	return payload.Address, nil
+		return ResolvedDestination{}, err
+	}
+	return ResolvedDestination{
+		Address:        payload.Address,
+		Memo:           payload.Memo,
+		DestinationTag: payload.DestinationTag,
+		PaymentID:      payload.PaymentID,
+	}, nil
 }
 
 func httpGet(ctx context.Context, urlStr, accept string) ([]byte, error) {
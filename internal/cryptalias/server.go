@@ -21,6 +21,9 @@ var defaultConfig = &Config{
 		Enabled:           boolPtr(true),
 		RequestsPerMinute: 60,
 		Burst:             10,
+
+		UncachedRequestsPerMinute: 6,
+		UncachedBurst:             2,
 	},
 	Resolution: ResolutionConfig{
 		TTLSeconds: 60,
@@ -67,12 +70,27 @@ func Run(configPath string) error {
 	}
 
 	store := NewConfigStore(configPath, cfg)
+	aliases, err := openAliasStore(store)
+	if err != nil {
+		slog.Error("alias store failed to open", "error", err)
+		return err
+	}
+	if aliases != nil {
+		defer aliases.Close()
+	}
 	statuses := NewDomainStatusStore(cfg)
 	resolver, err := NewWalletResolver(configPath)
 	if err != nil {
 		slog.Error("wallet resolver failed to start", "error", err)
 		return err
 	}
+	webhooks, err := NewWebhookDispatcher(configPath, store)
+	if err != nil {
+		slog.Error("webhook dispatcher failed to start", "error", err)
+		return err
+	}
+	resolver.SetWebhooks(webhooks)
+	resolver.SetConfigStore(store)
 	if _, err := WatchConfigFile(configPath, store); err != nil {
 		slog.Error("config watcher failed to start", "path", configPath, "error", err)
 		return err
@@ -81,22 +99,33 @@ func Run(configPath string) error {
 
 	// Public endpoints only.
 	publicMux := http.NewServeMux()
-	publicMux.HandleFunc("GET /healthz", HealthHandler(statuses))
+	publicMux.HandleFunc("GET /healthz", HealthHandler(statuses, resolver))
 	wellKnownHandler := corsMiddleware(WellKnownHandler(store))
-	wellKnownStatusHandler := corsMiddleware(WellKnownStatusHandler(store, statuses))
+	wellKnownStatusHandler := corsMiddleware(WellKnownStatusHandler(store, statuses, resolver))
 	publicMux.Handle("GET /.well-known/cryptalias/configuration", wellKnownHandler)
 	publicMux.Handle("OPTIONS /.well-known/cryptalias/configuration", wellKnownHandler)
 	publicMux.Handle("GET /.well-known/cryptalias/status", wellKnownStatusHandler)
 	publicMux.Handle("OPTIONS /.well-known/cryptalias/status", wellKnownStatusHandler)
 
-	resolveHandler := http.Handler(AliasResolverHandler(store, resolver, statuses))
-	resolveHandler = newRateLimiter(store).middleware(resolveHandler)
+	resolveHandler := http.Handler(AliasResolverHandler(store, resolver, statuses, webhooks))
+	resolveHandler = newRateLimiter(store).uncachedParams("amount", "memo").middleware(resolveHandler)
 	resolveHandler = corsMiddleware(resolveHandler)
 	publicMux.Handle("GET /_cryptalias/resolve/{ticker}/{alias}", resolveHandler)
 	publicMux.Handle("OPTIONS /_cryptalias/resolve/{ticker}/{alias}", resolveHandler)
 	publicMux.Handle("GET /_cryptalias/resolve/{alias}", resolveHandler)
 	publicMux.Handle("OPTIONS /_cryptalias/resolve/{alias}", resolveHandler)
 
+	// Lightning Address (LNURL-pay) discovery lives on the alias domain; the
+	// invoice callback lives on the Cryptalias host like the resolver.
+	lnurlPayHandler := corsMiddleware(LNURLPayHandler(store, statuses))
+	publicMux.Handle("GET /.well-known/lnurlp/{user}", lnurlPayHandler)
+	publicMux.Handle("OPTIONS /.well-known/lnurlp/{user}", lnurlPayHandler)
+	lnurlCallbackHandler := http.Handler(LNURLCallbackHandler(store, resolver, statuses))
+	lnurlCallbackHandler = newRateLimiter(store).middleware(lnurlCallbackHandler)
+	lnurlCallbackHandler = corsMiddleware(lnurlCallbackHandler)
+	publicMux.Handle("GET /_cryptalias/lnurlp/{domain}/{user}/callback", lnurlCallbackHandler)
+	publicMux.Handle("OPTIONS /_cryptalias/lnurlp/{domain}/{user}/callback", lnurlCallbackHandler)
+
 	publicAddr := fmt.Sprintf(":%d", cfg.PublicPort)
 	publicServer := &http.Server{Handler: publicMux}
 
@@ -108,15 +137,36 @@ func Run(configPath string) error {
 
 	slog.Info("public server listening", "addr", publicAddr, "base_url", cfg.BaseURL)
 
-	errCh := make(chan error, 1)
+	errCh := make(chan error, 2)
 	go func() {
 		errCh <- publicServer.Serve(ln)
 	}()
 
+	// The admin API gets its own listener so it can stay off the public port.
+	if cfg.Admin.Listen != "" {
+		adminLn, err := net.Listen("tcp", cfg.Admin.Listen)
+		if err != nil {
+			slog.Error("admin listen failed", "addr", cfg.Admin.Listen, "error", err)
+			return err
+		}
+		adminServer := &http.Server{Handler: AdminHandler(store)}
+		slog.Info("admin server listening", "addr", cfg.Admin.Listen)
+		go func() {
+			errCh <- adminServer.Serve(adminLn)
+		}()
+	}
+
 	// Start verification only after the server is actually serving.
-	verifier := newDomainVerifier(store, statuses, verifyInterval)
+	webhooks.Start(context.Background())
+	verifier := newDomainVerifier(store, statuses, webhooks, verifyInterval)
 	verifier.Start(context.Background())
 	slog.Info("domain verifier started", "interval", verifyInterval.String())
+	newWalletHealthChecker(store, resolver).Start(context.Background())
+	slog.Info("wallet health checker started", "interval_seconds", cfg.WalletHealth.IntervalSeconds)
+	newPaymentWatcher(store, resolver, webhooks.EmitPayment).Start(context.Background())
+	if cfg.PaymentWatch.Enabled {
+		slog.Info("payment watcher started", "interval_seconds", cfg.PaymentWatch.IntervalSeconds)
+	}
 
 	if err := <-errCh; err != nil {
 		slog.Error("server exited", "error", err)
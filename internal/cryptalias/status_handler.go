@@ -12,10 +12,14 @@ type domainStatusResponse struct {
 	CheckedAt time.Time    `json:"checked_at"`
 	Healthy   bool         `json:"healthy"`
 	Domain    DomainStatus `json:"domain"`
+	// Backends reports wallet backend circuit breaker state.
+	Backends []BackendStatus `json:"backends,omitempty"`
+	// Wallets reports the active wallet backend health checks.
+	Wallets []WalletHealth `json:"wallets,omitempty"`
 }
 
 // WellKnownStatusHandler exposes verifier state for the resolved domain only.
-func WellKnownStatusHandler(store *ConfigStore, statuses *DomainStatusStore) http.HandlerFunc {
+func WellKnownStatusHandler(store *ConfigStore, statuses *DomainStatusStore, wallets walletStatusSource) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		cfg := store.Get()
 		if statuses != nil {
@@ -41,7 +45,7 @@ func WellKnownStatusHandler(store *ConfigStore, statuses *DomainStatusStore) htt
 			}
 		}
 		checkedAt := status.LastChecked
-		resp := domainStatusResponse{Version: VERSION, CheckedAt: checkedAt, Healthy: status.Healthy, Domain: status}
+		resp := domainStatusResponse{Version: VERSION, CheckedAt: checkedAt, Healthy: status.Healthy, Domain: status, Backends: listBackendStatuses(wallets), Wallets: listWalletHealth(wallets)}
 
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
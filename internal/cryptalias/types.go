@@ -18,12 +18,40 @@ type WalletAddress struct {
 	AccountIndex *uint64 `json:"account_index,omitempty" yaml:"account_index,omitempty"`
 	AccountID    *string `json:"account_id,omitempty" yaml:"account_id,omitempty"`
 	WalletID     *string `json:"wallet_id,omitempty" yaml:"wallet_id,omitempty"`
+	// AddressMode overrides the endpoint's address mode for this alias, e.g.
+	// "integrated" to issue Monero integrated addresses with a payment ID.
+	AddressMode *string `json:"address_mode,omitempty" yaml:"address_mode,omitempty"`
+	// Optional chain-specific destination fields that payers must include,
+	// e.g. an XLM/ATOM memo, an XRP destination tag or a Monero payment ID.
+	Memo           *string `json:"memo,omitempty" yaml:"memo,omitempty"`
+	DestinationTag *uint32 `json:"destination_tag,omitempty" yaml:"destination_tag,omitempty"`
+	PaymentID      *string `json:"payment_id,omitempty" yaml:"payment_id,omitempty"`
+	// ExpiresAt is set for dynamic addresses the wallet service gave an
+	// expiry hint for; the signed expires never exceeds it.
+	ExpiresAt time.Time `json:"-" yaml:"-"`
 }
 
 type WalletAlias struct {
 	Alias  string        `json:"alias" yaml:"alias"`
 	Wallet WalletAddress `json:"wallet" yaml:"wallet"`
 	Tags   []WalletTag   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Lightning enables a Lightning Address (LNURL-pay) for alias@domain.
+	Lightning *LightningConfig `json:"lightning,omitempty" yaml:"lightning,omitempty"`
+}
+
+// LightningConfig describes how LNURL-pay requests for an alias are served.
+// Invoices are issued by the wallet service configured for Ticker.
+type LightningConfig struct {
+	Ticker          string `json:"ticker,omitempty" yaml:"ticker,omitempty"`
+	MinSendableMsat uint64 `json:"min_sendable_msat,omitempty" yaml:"min_sendable_msat,omitempty"`
+	MaxSendableMsat uint64 `json:"max_sendable_msat,omitempty" yaml:"max_sendable_msat,omitempty"`
+	// Description is shown to payers and committed to via the metadata hash.
+	Description    string `json:"description,omitempty" yaml:"description,omitempty"`
+	CommentAllowed int    `json:"comment_allowed,omitempty" yaml:"comment_allowed,omitempty"`
+	// Optional routing hints forwarded to CreateInvoice.
+	AccountIndex *uint64 `json:"account_index,omitempty" yaml:"account_index,omitempty"`
+	AccountID    *string `json:"account_id,omitempty" yaml:"account_id,omitempty"`
+	WalletID     *string `json:"wallet_id,omitempty" yaml:"wallet_id,omitempty"`
 }
 
 type WalletTag struct {
@@ -43,6 +71,11 @@ type ResolvedAddress struct {
 	Version uint      `json:"version"`
 	Ticker  string    `json:"ticker"`
 	Address string    `json:"address"`
-	Expires time.Time `json:"expires"`
-	Nonce   string    `json:"nonce"`
+	// Destination fields are signed with the address so they cannot be
+	// stripped or altered in transit.
+	Memo           string    `json:"memo,omitempty"`
+	DestinationTag *uint32   `json:"destination_tag,omitempty"`
+	PaymentID      string    `json:"payment_id,omitempty"`
+	Expires        time.Time `json:"expires"`
+	Nonce          string    `json:"nonce"`
 }
@@ -0,0 +1,90 @@
+package cryptalias
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"sort"
+)
+
+// orderBackends sorts a token's backends for one request: ascending priority,
+// and within a priority a weighted shuffle. Sticky tokens derive the shuffle
+// from the client key (weighted rendezvous hashing), so a client keeps the
+// same backend for as long as it is healthy.
+func orderBackends(backends []TokenConfig, sticky bool, clientKey string) []TokenConfig {
+	if len(backends) < 2 {
+		return backends
+	}
+	type ranked struct {
+		backend TokenConfig
+		score   float64
+	}
+	list := make([]ranked, len(backends))
+	for i, b := range backends {
+		u := rand.Float64()
+		if sticky {
+			h := fnv.New64a()
+			_, _ = h.Write([]byte(clientKey + "\x00" + b.Endpoint.Name))
+			u = float64(h.Sum64()>>11) / (1 << 53)
+		}
+		if u == 0 {
+			u = math.SmallestNonzeroFloat64
+		}
+		// Efraimidis-Spirakis: u^(1/w) orders items proportionally to weight.
+		list[i] = ranked{backend: b, score: math.Pow(u, 1/float64(b.Endpoint.weightOrDefault()))}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].backend.Endpoint.Priority != list[j].backend.Endpoint.Priority {
+			return list[i].backend.Endpoint.Priority < list[j].backend.Endpoint.Priority
+		}
+		return list[i].score > list[j].score
+	})
+	out := make([]TokenConfig, len(list))
+	for i, r := range list {
+		out[i] = r.backend
+	}
+	return out
+}
+
+// eachBackend calls fn with the token's backends in selection order until one
+// succeeds. Unhealthy backends are tried last, and backends whose breaker is
+// open fail fast and are skipped; when all of them are open the soonest
+// Retry-After is returned. Only errors showing the call never reached the
+// wallet move on to the next backend: after a timeout or an application error
+// the wallet may already have issued an address, so that error is returned.
+func (r *WalletResolver) eachBackend(ctx context.Context, token TokenConfig, clientKey string, fn func(context.Context, TokenConfig) error) error {
+	backends := orderBackends(token.backends(), token.Sticky, clientKey)
+	sort.SliceStable(backends, func(i, j int) bool {
+		return r.health.Healthy(backends[i].Name) && !r.health.Healthy(backends[j].Name)
+	})
+	var (
+		err     error
+		soonest *BackendUnavailableError
+		allOpen = true
+	)
+	for i, b := range backends {
+		if err = fn(ctx, b); err == nil || ctx.Err() != nil {
+			return err
+		}
+		var unavailable *BackendUnavailableError
+		if errors.As(err, &unavailable) {
+			if soonest == nil || unavailable.RetryAfter < soonest.RetryAfter {
+				soonest = unavailable
+			}
+		} else if retryableBackendError(err) {
+			allOpen = false
+		} else {
+			return err
+		}
+		if i < len(backends)-1 {
+			slog.Warn("wallet backend failed, trying next", "token", token.Name, "backend", b.Name, "error", err)
+		}
+	}
+	if allOpen && soonest != nil && len(backends) > 1 {
+		return &BackendUnavailableError{Token: token.Name, RetryAfter: soonest.RetryAfter}
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package cryptalias
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func multiBackendToken(sticky bool) TokenConfig {
+	return TokenConfig{
+		Name:    "Monero",
+		Tickers: []string{"xmr"},
+		Sticky:  sticky,
+		Endpoints: []TokenEndpointConfig{
+			{Name: "primary", EndpointType: TokenEndpointTypeInternal, EndpointAddress: "http://wallet-a:18083", Priority: 0},
+			{Name: "backup", EndpointType: TokenEndpointTypeInternal, EndpointAddress: "http://wallet-b:18083", Priority: 1},
+		},
+	}
+}
+
+func TestOrderBackendsByPriorityAndStickyWeight(t *testing.T) {
+	token := multiBackendToken(false)
+	for i := 0; i < 20; i++ {
+		if got := orderBackends(token.backends(), false, "")[0].Name; got != "Monero/primary" {
+			t.Fatalf("expected the lowest priority first, got %s", got)
+		}
+	}
+
+	token.Endpoints[1].Priority = 0
+	token.Endpoints[1].Weight = 3
+	seen := map[string]bool{}
+	for _, client := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		first := orderBackends(token.backends(), true, client)[0].Name
+		for i := 0; i < 5; i++ {
+			if again := orderBackends(token.backends(), true, client)[0].Name; again != first {
+				t.Fatalf("sticky client %s moved from %s to %s", client, first, again)
+			}
+		}
+		seen[first] = true
+	}
+	if len(seen) != 2 {
+		t.Fatalf("expected sticky clients to spread over both backends, got %v", seen)
+	}
+}
+
+func TestWalletResolverFailsOverAndKeepsCache(t *testing.T) {
+	var calls []string
+	primaryDown := true
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls = append(calls, token.Name)
+		if token.Endpoint.Name == "primary" && primaryDown {
+			return nil, status.Error(codes.Unavailable, "connection refused")
+		}
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, byte(len(calls)))}, nil
+	}
+	resolver := newBreakerTestResolver(t, internalFn)
+	noRetries := 0
+	cfg := &Config{Tokens: []TokenConfig{multiBackendToken(false)}}
+	cfg.Tokens[0].Endpoints[0].Retries = &noRetries
+	cfg.Normalize("")
+	ctx := withClientKey(context.Background(), "client-a")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	first, err := resolver.Resolve(ctx, cfg, in)
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got %v", err)
+	}
+	if strings.Join(calls, ",") != "Monero/primary,Monero/backup" {
+		t.Fatalf("unexpected call order %v", calls)
+	}
+
+	// The primary recovers, but the client's cached address stays put.
+	primaryDown = false
+	second, err := resolver.Resolve(ctx, cfg, in)
+	if err != nil {
+		t.Fatalf("second resolve: %v", err)
+	}
+	if second.Address != first.Address || len(calls) != 2 {
+		t.Fatalf("expected cached address from the backup, got %q after %v", second.Address, calls)
+	}
+}
+
+func TestWalletResolverDoesNotFailOverAfterReachingWallet(t *testing.T) {
+	for _, primaryErr := range []error{
+		status.Error(codes.InvalidArgument, "unknown wallet_id"),
+		status.Error(codes.DeadlineExceeded, "deadline exceeded"),
+		errBackendTimeout,
+	} {
+		var calls []string
+		internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+			calls = append(calls, token.Name)
+			if token.Endpoint.Name == "primary" {
+				return nil, primaryErr
+			}
+			return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, 1)}, nil
+		}
+		resolver := newBreakerTestResolver(t, internalFn)
+		cfg := &Config{Tokens: []TokenConfig{multiBackendToken(false)}}
+		cfg.Normalize("")
+
+		if _, err := resolver.Resolve(context.Background(), cfg, dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}); err == nil {
+			t.Fatalf("%v: expected the primary's error, got an address from %v", primaryErr, calls)
+		}
+		if strings.Join(calls, ",") != "Monero/primary" {
+			t.Fatalf("%v: expected no failover, got calls %v", primaryErr, calls)
+		}
+	}
+}
+
+func TestValidateTokenEndpoints(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.Tokens[0].Endpoints = []TokenEndpointConfig{{EndpointType: TokenEndpointTypeExternal, EndpointAddress: "a:1"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "either endpoint or endpoints") {
+		t.Fatalf("expected endpoint/endpoints conflict, got %v", err)
+	}
+
+	cfg.Tokens[0].Endpoint = TokenEndpointConfig{}
+	cfg.Tokens[0].Endpoints = append(cfg.Tokens[0].Endpoints, TokenEndpointConfig{EndpointType: "grpc", EndpointAddress: "b:1"})
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tokens[0].endpoints[1].type") {
+		t.Fatalf("expected indexed endpoint error, got %v", err)
+	}
+
+	cfg.Tokens[0].Endpoints[1].EndpointType = TokenEndpointTypeExternal
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected valid endpoints, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package cryptalias
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+)
+
+const (
+	HealthCheckCryptalias = "cryptalias"
+	HealthCheckGRPC       = "grpc"
+	HealthCheckNone       = "none"
+)
+
+// healthCheckFor returns the active health check used for an endpoint.
+func healthCheckFor(e TokenEndpointConfig) string {
+	if e.HealthCheck != "" {
+		return e.HealthCheck
+	}
+	switch e.EndpointType {
+	case TokenEndpointTypeInternal, TokenEndpointTypeExternal:
+		return HealthCheckCryptalias
+	}
+	return HealthCheckNone
+}
+
+// WalletHealth is the result of the last active health check of one token
+// backend. Backend is only set for tokens with an endpoints list.
+type WalletHealth struct {
+	Token       string    `json:"token"`
+	Backend     string    `json:"backend,omitempty"`
+	Check       string    `json:"check"`
+	Healthy     bool      `json:"healthy"`
+	Message     string    `json:"message,omitempty"`
+	LastChecked time.Time `json:"last_checked"`
+}
+
+// WalletHealthStore holds backend health separately from config, like
+// DomainStatusStore. Entries are keyed by backend name (see TokenConfig.backends).
+type WalletHealthStore struct {
+	mu       sync.RWMutex
+	statuses map[string]WalletHealth
+}
+
+func NewWalletHealthStore() *WalletHealthStore {
+	return &WalletHealthStore{statuses: map[string]WalletHealth{}}
+}
+
+func (s *WalletHealthStore) Update(backend string, status WalletHealth) {
+	s.mu.Lock()
+	s.statuses[backend] = status
+	s.mu.Unlock()
+}
+
+func (s *WalletHealthStore) Get(backend string) (WalletHealth, bool) {
+	s.mu.RLock()
+	status, ok := s.statuses[backend]
+	s.mu.RUnlock()
+	return status, ok
+}
+
+// Reconcile drops backends that are no longer configured or checked.
+func (s *WalletHealthStore) Reconcile(cfg *Config) {
+	keep := map[string]bool{}
+	if cfg != nil && !cfg.WalletHealth.Disabled {
+		for _, t := range cfg.Tokens {
+			for _, b := range t.backends() {
+				if healthCheckFor(b.Endpoint) != HealthCheckNone {
+					keep[b.Name] = true
+				}
+			}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for backend := range s.statuses {
+		if !keep[backend] {
+			delete(s.statuses, backend)
+		}
+	}
+}
+
+// Healthy reports whether a backend passed its last check. Unchecked
+// backends count as healthy.
+func (s *WalletHealthStore) Healthy(backend string) bool {
+	status, ok := s.Get(backend)
+	return !ok || status.Healthy
+}
+
+// TokenHealthy reports whether at least one backend of the token is healthy.
+func (s *WalletHealthStore) TokenHealthy(token TokenConfig) bool {
+	for _, b := range token.backends() {
+		if s.Healthy(b.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns a sorted snapshot for the status endpoints.
+func (s *WalletHealthStore) List() []WalletHealth {
+	s.mu.RLock()
+	out := make([]WalletHealth, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		out = append(out, status)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Token != out[j].Token {
+			return out[i].Token < out[j].Token
+		}
+		return out[i].Backend < out[j].Backend
+	})
+	return out
+}
+
+// CheckHealth runs the active health check of every token backend and records
+// the results.
+func (r *WalletResolver) CheckHealth(ctx context.Context, cfg *Config) {
+	r.health.Reconcile(cfg)
+	if cfg.WalletHealth.Disabled {
+		return
+	}
+	for _, token := range cfg.Tokens {
+		for _, b := range token.backends() {
+			check := healthCheckFor(b.Endpoint)
+			if check == HealthCheckNone {
+				continue
+			}
+			callCtx, cancel := context.WithTimeout(ctx, b.Endpoint.TimeoutOrDefault())
+			ok, msg, err := r.checkBackendHealth(callCtx, b, check)
+			cancel()
+			if err != nil {
+				ok, msg = false, err.Error()
+			}
+			status := WalletHealth{Token: token.Name, Check: check, Healthy: ok, Message: msg, LastChecked: time.Now().UTC()}
+			if len(token.Endpoints) > 0 {
+				status.Backend = b.Endpoint.Name
+			}
+			if prev, known := r.health.Get(b.Name); !known || prev.Healthy != ok {
+				if ok {
+					slog.Info("wallet backend healthy", "backend", b.Name, "check", check, "message", msg)
+				} else {
+					slog.Error("wallet backend unhealthy", "backend", b.Name, "check", check, "message", msg)
+				}
+			}
+			r.health.Update(b.Name, status)
+		}
+	}
+}
+
+func (r *WalletResolver) checkBackendHealth(ctx context.Context, backend TokenConfig, check string) (bool, string, error) {
+	if check == HealthCheckGRPC {
+		return r.grpc.StandardHealth(ctx, backend.Endpoint)
+	}
+	if backend.Endpoint.EndpointType == TokenEndpointTypeInternal {
+		client, err := r.internal.client(backend, nil)
+		if err != nil {
+			return false, "", err
+		}
+		resp, err := client.Health(ctx, &cryptaliasv1.HealthRequest{})
+		if err != nil {
+			return false, "", err
+		}
+		return resp.GetOk(), resp.GetMessage(), nil
+	}
+	return r.grpc.Health(ctx, backend.Endpoint)
+}
+
+// WalletHealth reports the last active health check of every wallet backend.
+func (r *WalletResolver) WalletHealth() []WalletHealth {
+	return r.health.List()
+}
+
+// walletStatusSource is implemented by WalletResolver for the status endpoints.
+type walletStatusSource interface {
+	BackendStatuses() []BackendStatus
+	WalletHealth() []WalletHealth
+}
+
+func listBackendStatuses(src walletStatusSource) []BackendStatus {
+	if src == nil {
+		return nil
+	}
+	return src.BackendStatuses()
+}
+
+func listWalletHealth(src walletStatusSource) []WalletHealth {
+	if src == nil {
+		return nil
+	}
+	return src.WalletHealth()
+}
+
+// walletHealthChecker polls wallet backends in the background, re-reading the
+// interval from config so reloads apply.
+type walletHealthChecker struct {
+	store    *ConfigStore
+	resolver *WalletResolver
+}
+
+func newWalletHealthChecker(store *ConfigStore, resolver *WalletResolver) *walletHealthChecker {
+	return &walletHealthChecker{store: store, resolver: resolver}
+}
+
+func (c *walletHealthChecker) Start(ctx context.Context) {
+	if c == nil || c.store == nil || c.resolver == nil {
+		return
+	}
+	go func() {
+		for {
+			cfg := c.store.Get()
+			c.resolver.CheckHealth(ctx, cfg)
+			interval := time.Duration(cfg.WalletHealth.IntervalSeconds) * time.Second
+			if interval <= 0 {
+				interval = time.Minute
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+}
@@ -0,0 +1,104 @@
+package cryptalias
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestMoneroHealthChecksVersionAndHeight(t *testing.T) {
+	rpc := &fakeMoneroWalletRPC{calls: map[string]int{}}
+	srv := httptest.NewServer(rpc)
+	defer srv.Close()
+
+	// Without wallet_file the RPC serves its own wallet, so height is checked.
+	svc := newMoneroWalletService(TokenEndpointConfig{EndpointAddress: srv.URL})
+	resp, err := svc.Health(context.Background(), &cryptaliasv1.HealthRequest{})
+	if err != nil || !resp.GetOk() {
+		t.Fatalf("expected healthy wallet rpc, got %v %v", resp, err)
+	}
+	if rpc.count("get_version") != 1 || rpc.count("get_height") != 1 {
+		t.Fatalf("expected get_version and get_height, got %v", rpc.calls)
+	}
+
+	// Per-request wallets are closed between requests; only the RPC is checked.
+	svc.SetEndpoint(TokenEndpointConfig{EndpointAddress: srv.URL, WalletFile: "main"})
+	if _, err := svc.Health(context.Background(), &cryptaliasv1.HealthRequest{}); err != nil {
+		t.Fatalf("health: %v", err)
+	}
+	if rpc.count("get_height") != 1 {
+		t.Fatalf("expected no get_height without an open wallet")
+	}
+
+	srv.Close()
+	resp, _ = svc.Health(context.Background(), &cryptaliasv1.HealthRequest{})
+	if resp.GetOk() {
+		t.Fatalf("expected unreachable wallet rpc to be unhealthy")
+	}
+}
+
+func TestCheckHealthGatesResolution(t *testing.T) {
+	hs := health.NewServer()
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go func() { _ = s.Serve(lis) }()
+	defer s.Stop()
+
+	calls := 0
+	externalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, 1)}, nil
+	}
+	resolver := newBreakerTestResolver(t, nil)
+	resolver.externalFn = externalFn
+	cfg := &Config{
+		WalletHealth: WalletHealthConfig{GateResolution: true},
+		Tokens: []TokenConfig{{
+			Name:     "Monero",
+			Tickers:  []string{"xmr"},
+			Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeExternal, EndpointAddress: lis.Addr().String(), HealthCheck: HealthCheckGRPC},
+		}},
+	}
+	cfg.Normalize("")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	resolver.CheckHealth(context.Background(), cfg)
+	if h := resolver.WalletHealth(); len(h) != 1 || h[0].Healthy {
+		t.Fatalf("expected one unhealthy backend, got %+v", h)
+	}
+	_, err = resolver.Resolve(context.Background(), cfg, in)
+	var unavailable *BackendUnavailableError
+	if !errors.As(err, &unavailable) || calls != 0 {
+		t.Fatalf("expected gated resolve, got %v after %d calls", err, calls)
+	}
+
+	rr := httptest.NewRecorder()
+	HealthHandler(nil, resolver).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var resp healthResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.OverallOK || resp.UnhealthyTokens != 1 {
+		t.Fatalf("expected unhealthy token in /healthz, got %+v", resp)
+	}
+
+	hs.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	resolver.CheckHealth(context.Background(), cfg)
+	if _, err := resolver.Resolve(context.Background(), cfg, in); err != nil {
+		t.Fatalf("expected resolve after recovery, got %v", err)
+	}
+}
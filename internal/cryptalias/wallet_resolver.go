@@ -2,23 +2,48 @@ package cryptalias
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	cryptaliasv2 "github.com/kaigoh/cryptalias/proto/cryptalias/v2"
 	"google.golang.org/protobuf/proto"
 )
 
 type WalletResolver struct {
 	state      *AddressStore
 	grpc       *grpcWalletClient
+	http       *httpWalletClient
+	exec       *execWalletClient
 	internal   *internalWallets
-	internalFn func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (string, error)
-	externalFn func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (string, error)
+	internalFn walletBackendFunc
+	externalFn walletBackendFunc
+	httpFn     walletBackendFunc
+	execFn     walletBackendFunc
+
+	limitsMu sync.Mutex
+	// limits are per-token semaphores enforcing endpoint max_concurrency.
+	limits map[string]chan struct{}
+	// breakers fail fast per token while a backend keeps failing.
+	breakers *BackendBreakers
+	// health holds the results of the active backend health checks.
+	health *WalletHealthStore
+	// webhooks receives address events; nil disables them.
+	webhooks *WebhookDispatcher
+	// aliases looks up aliases declared by domain alias providers.
+	aliases *aliasProviderClient
 }
 
+// walletBackendFunc issues an address for a dynamic alias. Every endpoint type
+// answers with the protobuf response so optional fields flow through uniformly.
+type walletBackendFunc func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error)
+
 // NewWalletResolver wires together state persistence, external gRPC clients,
 // and internal wallet integrations.
 func NewWalletResolver(configPath string) (*WalletResolver, error) {
@@ -29,12 +54,20 @@ func NewWalletResolver(configPath string) (*WalletResolver, error) {
 	return newWalletResolverWithDeps(state, nil, nil), nil
 }
 
-func newWalletResolverWithDeps(state *AddressStore, internalFn func(context.Context, TokenConfig, dynamicAliasInput) (string, error), externalFn func(context.Context, TokenConfig, dynamicAliasInput) (string, error)) *WalletResolver {
+func newWalletResolverWithDeps(state *AddressStore, internalFn, externalFn walletBackendFunc) *WalletResolver {
 	r := &WalletResolver{
 		state:    state,
 		grpc:     newGRPCWalletClient(),
+		http:     newHTTPWalletClient(),
+		exec:     newExecWalletClient(),
 		internal: newInternalWallets(),
+		limits:   map[string]chan struct{}{},
+		breakers: NewBackendBreakers(),
+		health:   NewWalletHealthStore(),
 	}
+	r.aliases = newAliasProviderClient(r.grpc)
+	r.httpFn = r.resolveHTTP
+	r.execFn = r.resolveExec
 	if internalFn != nil {
 		r.internalFn = internalFn
 	} else {
@@ -57,6 +90,64 @@ type dynamicAliasInput struct {
 	AccountIndex *uint64
 	AccountID    *string
 	WalletID     *string
+	AddressMode  *string
+	// Amount is an optional payer-requested amount in the ticker's units.
+	Amount string
+	// Memo is an optional payer-requested memo; only v2 wallet services see it.
+	Memo string
+
+	// Request context for v2 wallet services, set by Resolve.
+	RequestID     string
+	ClientKeyHash string
+	Cache         cachePolicy
+}
+
+// cachePolicy tells v2 wallet services how an issued address will be cached.
+type cachePolicy struct {
+	TTLSeconds  int
+	RetireUsed  bool
+	ReuseUnused bool
+}
+
+type requestAmountContextKey struct{}
+
+type requestMemoContextKey struct{}
+
+var amountPattern = regexp.MustCompile(`^[0-9]{1,20}(\.[0-9]{1,18})?$`)
+
+// validAmount accepts positive plain decimal amounts such as "0.0015".
+func validAmount(amount string) bool {
+	return amountPattern.MatchString(amount) && strings.Trim(amount, "0.") != ""
+}
+
+// withRequestAmount carries the payer's requested amount from the HTTP layer
+// to the wallet service, like the client key.
+func withRequestAmount(ctx context.Context, amount string) context.Context {
+	return context.WithValue(ctx, requestAmountContextKey{}, amount)
+}
+
+func requestAmountFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(requestAmountContextKey{}).(string); ok {
+		return v
+	}
+	return ""
+}
+
+// validRequestMemo accepts payer memos that fit the signed memo limit.
+func validRequestMemo(memo string) bool {
+	return len(memo) <= 512 && utf8.ValidString(memo)
+}
+
+// withRequestMemo carries the payer's requested memo to v2 wallet services.
+func withRequestMemo(ctx context.Context, memo string) context.Context {
+	return context.WithValue(ctx, requestMemoContextKey{}, memo)
+}
+
+func requestMemoFromContext(ctx context.Context) string {
+	if v, ok := ctx.Value(requestMemoContextKey{}).(string); ok {
+		return v
+	}
+	return ""
 }
 
 // Resolve performs dynamic resolution via the configured endpoint type and
@@ -67,72 +158,387 @@ func (r *WalletResolver) Resolve(ctx context.Context, cfg *Config, in dynamicAli
 		return WalletAddress{}, err
 	}
 
+	if in.AddressMode == nil && token.Endpoint.AddressMode != "" {
+		mode := token.Endpoint.AddressMode
+		in.AddressMode = &mode
+	}
+
 	clientKey := clientKeyFromContext(ctx)
 	now := time.Now().UTC()
+	// The backend is not part of the key: a client keeps its cached address
+	// even when a different backend of the token would answer now.
 	cacheKey := aliasKey(in.Ticker, in.Domain, in.Alias, in.Tag, accountKey(in), clientKey)
-	if addr, ok := r.state.Get(cacheKey, now); ok {
+	// A payer-supplied amount or memo asks the backend for an address of its
+	// own. Such addresses are neither cached nor reused, so the payer's values
+	// never widen the cache key; the resolve handler rate limits these
+	// requests separately (rate_limit.uncached_requests_per_minute).
+	dedicated := in.Amount != "" || in.Memo != ""
+	if dedicated {
+		cacheKey = ""
+	} else if entry, ok := r.state.Get(cacheKey, now); ok && !r.retireUsed(cfg, cacheKey, in.Ticker, entry) {
 		slog.Debug("dynamic resolve cache hit", "ticker", in.Ticker, "domain", in.Domain, "client", clientKey)
-		return WalletAddress{Ticker: in.Ticker, Address: addr}, nil
+		r.webhooks.Emit(newAddressEvent(EventAddressCacheHit, in, entry))
+		return entry.walletAddress(in.Ticker), nil
 	}
 
-	slog.Debug("dynamic resolve start", "ticker", in.Ticker, "domain", in.Domain, "endpoint_type", token.Endpoint.EndpointType, "client", clientKey)
+	if cfg.WalletHealth.GateResolution && !cfg.WalletHealth.Disabled && !r.health.TokenHealthy(token) {
+		slog.Warn("resolve gated unhealthy ticker", "ticker", in.Ticker, "token", token.Name)
+		return WalletAddress{}, &BackendUnavailableError{Token: token.Name, RetryAfter: time.Duration(cfg.WalletHealth.IntervalSeconds) * time.Second}
+	}
 
-	var address string
-	switch token.Endpoint.EndpointType {
-	case TokenEndpointTypeInternal:
-		address, err = r.internalFn(ctx, token, in)
-	case TokenEndpointTypeExternal:
-		address, err = r.externalFn(ctx, token, in)
-	default:
-		return WalletAddress{}, fmt.Errorf("unsupported endpoint type %q", token.Endpoint.EndpointType)
+	in.ClientKeyHash = r.state.ClientKeyHash(clientKey)
+	in.Cache = cachePolicy{
+		TTLSeconds:  cfg.Resolution.TTLSeconds,
+		RetireUsed:  cfg.Resolution.RetireUsedOrDefault(),
+		ReuseUnused: cfg.Resolution.ReuseUnused,
 	}
-	if err != nil {
-		return WalletAddress{}, err
+
+	var (
+		entry  addressEntry
+		reused bool
+	)
+	if cfg.Resolution.ReuseUnused && !dedicated {
+		entry, reused = r.reuseUnused(ctx, token, in, clientKey, now)
 	}
-	if address == "" {
-		return WalletAddress{}, fmt.Errorf("wallet resolver returned empty address")
+	if !reused {
+		if entry, err = r.issueUnused(ctx, cfg, token, in, clientKey); err != nil {
+			return WalletAddress{}, err
+		}
 	}
 	ttl := time.Duration(cfg.Resolution.TTLSeconds) * time.Second
-	if err := r.state.Put(cacheKey, address, clientKey, now, ttl); err != nil {
-		slog.Warn("dynamic resolve cache store failed", "error", err)
+	// Cache the address and remember which alias issued it (or the payment
+	// ID, which identifies the payer on a shared address) so incoming
+	// transfers can be matched back.
+	if err := r.state.RecordIssued(cacheKey, in, entry, now, ttl, cfg.Resolution.IssuedRetention()); err != nil {
+		slog.Warn("dynamic resolve state store failed", "ticker", in.Ticker, "error", err)
 	}
-	return WalletAddress{Ticker: in.Ticker, Address: address}, nil
+	r.webhooks.Emit(newAddressEvent(EventAddressIssued, in, entry))
+	return entry.walletAddress(in.Ticker), nil
 }
 
-func (r *WalletResolver) resolveInternal(ctx context.Context, token TokenConfig, in dynamicAliasInput) (string, error) {
-	switch in.Ticker {
-	case "xmr":
-		client, err := r.internal.moneroClient(token.Endpoint)
+// issue asks the token's backends for a new address and validates it.
+func (r *WalletResolver) issue(ctx context.Context, token TokenConfig, in dynamicAliasInput, clientKey string) (addressEntry, error) {
+	slog.Debug("dynamic resolve start", "ticker", in.Ticker, "domain", in.Domain, "backends", len(token.backends()), "client", clientKey, "request_id", in.RequestID)
+
+	var (
+		resp   *cryptaliasv1.WalletAddressResponse
+		issuer string
+	)
+	err := r.eachBackend(ctx, token, clientKey, func(ctx context.Context, backend TokenConfig) error {
+		fn, err := r.backendFunc(backend.Endpoint.EndpointType)
 		if err != nil {
-			return "", err
+			return err
+		}
+		req := in
+		if req.AddressMode == nil && backend.Endpoint.AddressMode != "" {
+			mode := backend.Endpoint.AddressMode
+			req.AddressMode = &mode
+		}
+		return r.callBackend(ctx, backend, func(ctx context.Context) error {
+			var err error
+			resp, err = fn(ctx, backend, req)
+			issuer = backend.Name
+			return err
+		})
+	})
+	if err != nil {
+		return addressEntry{}, err
+	}
+	if resp.GetAddress() == "" {
+		return addressEntry{}, fmt.Errorf("wallet resolver returned empty address")
+	}
+	// Never sign or cache an address that is malformed for the ticker/network.
+	if err := validateTokenAddress(token, in.Ticker, resp.GetAddress()); err != nil {
+		slog.Error("dynamic resolve rejected invalid address", "ticker", in.Ticker, "domain", in.Domain, "error", err)
+		return addressEntry{}, fmt.Errorf("wallet resolver returned invalid %s address: %w", in.Ticker, err)
+	}
+	entry := newAddressEntry(resp, clientKey, time.Now().UTC())
+	entry.Backend = issuer
+	if err := validateDestinationFields(entry.walletAddress(in.Ticker)); err != nil {
+		return addressEntry{}, fmt.Errorf("wallet resolver returned invalid destination: %w", err)
+	}
+	return entry, nil
+}
+
+// LookupAlias asks the domain's alias provider for an alias that is not in
+// config.
+func (r *WalletResolver) LookupAlias(ctx context.Context, cfg *Config, domainCfg AliasDomainConfig, aliasName, tag, ticker string) (WalletAddress, error) {
+	return r.aliases.Lookup(ctx, cfg, domainCfg, aliasName, tag, ticker)
+}
+
+// ListProvidedAliases lists the aliases declared by the domain's alias
+// provider, if it has one.
+func (r *WalletResolver) ListProvidedAliases(ctx context.Context, domainCfg AliasDomainConfig) ([]*cryptaliasv1.ProvidedAlias, error) {
+	return r.aliases.List(ctx, domainCfg)
+}
+
+// SetConfigStore lets the resolver notice config reloads, e.g. to re-read
+// rotated TLS certificate files.
+func (r *WalletResolver) SetConfigStore(store *ConfigStore) {
+	r.grpc.revision = store.Revision
+}
+
+// SetWebhooks routes address events to a webhook dispatcher.
+func (r *WalletResolver) SetWebhooks(d *WebhookDispatcher) {
+	r.webhooks = d
+}
+
+// addressEventData is the payload of address.issued and address.cache_hit.
+type addressEventData struct {
+	Ticker    string `json:"ticker"`
+	Address   string `json:"address"`
+	PaymentID string `json:"payment_id,omitempty"`
+	Backend   string `json:"backend,omitempty"`
+
+	// Wallet-provided metadata, if any.
+	Reference string            `json:"reference,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Index     *addressIndex     `json:"index,omitempty"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+}
+
+func newAddressEvent(eventType string, in dynamicAliasInput, entry addressEntry) WebhookEvent {
+	data := addressEventData{
+		Ticker:    in.Ticker,
+		Address:   entry.Address,
+		PaymentID: entry.PaymentID,
+		Backend:   entry.Backend,
+		Reference: entry.Reference,
+		Labels:    entry.Labels,
+		Index:     entry.Index,
+	}
+	if entry.ValidUntil > 0 {
+		t := time.Unix(entry.ValidUntil, 0).UTC()
+		data.ExpiresAt = &t
+	}
+	return newWebhookEvent(eventType, in.Domain, in.Alias, in.Tag, data)
+}
+
+// lightningInvoiceInput carries the LNURL-pay specific parts of an invoice request.
+type lightningInvoiceInput struct {
+	AmountMsat      uint64
+	DescriptionHash []byte
+	Comment         string
+}
+
+// CreateInvoice requests a Lightning invoice from the wallet service configured
+// for the ticker. Invoices are single-use, so nothing is cached.
+func (r *WalletResolver) CreateInvoice(ctx context.Context, cfg *Config, in dynamicAliasInput, invoice lightningInvoiceInput) (string, error) {
+	token, err := findTokenConfig(cfg, in.Ticker)
+	if err != nil {
+		return "", err
+	}
+
+	slog.Debug("invoice request start", "ticker", in.Ticker, "domain", in.Domain, "amount_msat", invoice.AmountMsat, "backends", len(token.backends()))
+
+	var pr string
+	err = r.eachBackend(ctx, token, clientKeyFromContext(ctx), func(ctx context.Context, backend TokenConfig) error {
+		if backend.Endpoint.EndpointType != TokenEndpointTypeExternal {
+			return fmt.Errorf("endpoint type %q does not support lightning invoices", backend.Endpoint.EndpointType)
 		}
-		req := &cryptaliasv1.WalletAddressRequest{
-			Ticker: in.Ticker,
-			Alias:  in.Alias,
-			Tag:    in.Tag,
-			Domain: in.Domain,
+		return r.callBackend(ctx, backend, func(ctx context.Context) error {
+			var err error
+			pr, err = r.grpc.CreateInvoice(ctx, backend.Endpoint, in, invoice)
+			return err
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := checkInvoice(pr, invoice); err != nil {
+		slog.Warn("invoice rejected", "ticker", in.Ticker, "domain", in.Domain, "error", err)
+		return "", err
+	}
+	return pr, nil
+}
+
+func newInvoiceRequest(in dynamicAliasInput, invoice lightningInvoiceInput) *cryptaliasv1.InvoiceRequest {
+	req := &cryptaliasv1.InvoiceRequest{
+		Ticker:          in.Ticker,
+		Alias:           in.Alias,
+		Tag:             in.Tag,
+		Domain:          in.Domain,
+		AmountMsat:      invoice.AmountMsat,
+		DescriptionHash: invoice.DescriptionHash,
+		Comment:         invoice.Comment,
+	}
+	if in.AccountIndex != nil {
+		req.AccountIndex = proto.Uint64(*in.AccountIndex)
+	}
+	if in.AccountID != nil {
+		req.AccountId = proto.String(*in.AccountID)
+	}
+	if in.WalletID != nil {
+		req.WalletId = proto.String(*in.WalletID)
+	}
+	return req
+}
+
+func (r *WalletResolver) resolveInternal(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	client, err := r.internal.client(token, in.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	return client.GetAddress(ctx, newWalletAddressRequest(in))
+}
+
+func (r *WalletResolver) resolveExternal(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	return r.grpc.GetAddress(ctx, token.Endpoint, in)
+}
+
+// acquire waits for a free backend slot when the token limits concurrency.
+// The returned func releases the slot.
+func (r *WalletResolver) acquire(ctx context.Context, token TokenConfig) (func(), error) {
+	limit := token.Endpoint.MaxConcurrencyOrDefault()
+	if limit <= 0 {
+		return func() {}, nil
+	}
+	// The limit is part of the key so a reload with a new limit takes effect.
+	key := fmt.Sprintf("%s|%d", token.Name, limit)
+	r.limitsMu.Lock()
+	sem, ok := r.limits[key]
+	if !ok {
+		sem = make(chan struct{}, limit)
+		r.limits[key] = sem
+	}
+	r.limitsMu.Unlock()
+
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wallet backend for %s is busy: %w", token.Name, ctx.Err())
+	}
+}
+
+// backendFunc returns the address backend for an endpoint type.
+func (r *WalletResolver) backendFunc(endpointType TokenEndpointType) (walletBackendFunc, error) {
+	switch endpointType {
+	case TokenEndpointTypeInternal:
+		return r.internalFn, nil
+	case TokenEndpointTypeExternal:
+		return r.externalFn, nil
+	case TokenEndpointTypeHTTP:
+		return r.httpFn, nil
+	case TokenEndpointTypeExec:
+		return r.execFn, nil
+	}
+	return nil, fmt.Errorf("unsupported endpoint type %q", endpointType)
+}
+
+// callBackend runs fn under the token's concurrency limit, circuit breaker,
+// per-call timeout and retry policy. Only failures where the request never
+// reached the wallet are retried, so a retry cannot skip an address.
+func (r *WalletResolver) callBackend(ctx context.Context, token TokenConfig, fn func(context.Context) error) error {
+	release, err := r.acquire(ctx, token)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := r.breakers.allow(token, time.Now()); err != nil {
+		var unavailable *BackendUnavailableError
+		if errors.As(err, &unavailable) {
+			slog.Warn("wallet backend breaker open", "token", token.Name, "retry_after", unavailable.RetryAfter)
 		}
-		if in.AccountIndex != nil {
-			req.AccountIndex = proto.Uint64(*in.AccountIndex)
+		return err
+	}
+
+	timeout := token.Endpoint.TimeoutOrDefault()
+	retries := token.Endpoint.RetriesOrDefault()
+	for attempt := 0; ; attempt++ {
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		err = fn(callCtx)
+		timedOut := errors.Is(callCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil
+		cancel()
+		if err != nil && timedOut {
+			err = fmt.Errorf("%w after %s: %v", errBackendTimeout, timeout, err)
 		}
-		if in.AccountID != nil {
-			req.AccountId = proto.String(*in.AccountID)
+		if err == nil || attempt >= retries || !retryableBackendError(err) || ctx.Err() != nil {
+			break
 		}
-		if in.WalletID != nil {
-			req.WalletId = proto.String(*in.WalletID)
+		backoff := token.Endpoint.retryBackoff(attempt)
+		slog.Warn("wallet backend call failed, retrying", "token", token.Name, "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
 		}
-		resp, err := client.GetAddress(ctx, req)
-		if err != nil {
-			return "", err
+		if ctx.Err() != nil {
+			break
 		}
-		return resp.GetAddress(), nil
-	default:
-		return "", fmt.Errorf("no internal resolver for ticker %q", in.Ticker)
 	}
+
+	if err != nil && ctx.Err() != nil {
+		// The caller went away; that says nothing about the backend.
+		r.breakers.abort(token)
+		return err
+	}
+	r.breakers.record(token, err, time.Now())
+	return err
 }
 
-func (r *WalletResolver) resolveExternal(ctx context.Context, token TokenConfig, in dynamicAliasInput) (string, error) {
-	return r.grpc.GetAddress(ctx, token.Endpoint, in)
+// BackendStatuses reports the circuit breaker state of every wallet backend.
+func (r *WalletResolver) BackendStatuses() []BackendStatus {
+	return r.breakers.List()
+}
+
+func (r *WalletResolver) resolveExec(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	return r.exec.GetAddress(ctx, token.Name, token.Endpoint, in)
+}
+
+func (r *WalletResolver) resolveHTTP(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+	return r.http.GetAddress(ctx, token.Endpoint, in)
+}
+
+func newWalletAddressRequest(in dynamicAliasInput) *cryptaliasv1.WalletAddressRequest {
+	req := &cryptaliasv1.WalletAddressRequest{
+		Ticker: in.Ticker,
+		Alias:  in.Alias,
+		Tag:    in.Tag,
+		Domain: in.Domain,
+	}
+	if in.AccountIndex != nil {
+		req.AccountIndex = proto.Uint64(*in.AccountIndex)
+	}
+	if in.AccountID != nil {
+		req.AccountId = proto.String(*in.AccountID)
+	}
+	if in.WalletID != nil {
+		req.WalletId = proto.String(*in.WalletID)
+	}
+	if in.AddressMode != nil && *in.AddressMode != "" {
+		req.AddressMode = proto.String(*in.AddressMode)
+	}
+	if in.Amount != "" {
+		req.Amount = proto.String(in.Amount)
+	}
+	return req
+}
+
+// newWalletAddressRequestV2 adds the request context to the v1 fields.
+func newWalletAddressRequestV2(in dynamicAliasInput) *cryptaliasv2.WalletAddressRequest {
+	v1 := newWalletAddressRequest(in)
+	req := &cryptaliasv2.WalletAddressRequest{
+		Ticker:        v1.Ticker,
+		Alias:         v1.Alias,
+		Tag:           v1.Tag,
+		Domain:        v1.Domain,
+		AccountIndex:  v1.AccountIndex,
+		AccountId:     v1.AccountId,
+		WalletId:      v1.WalletId,
+		AddressMode:   v1.AddressMode,
+		Amount:        v1.Amount,
+		RequestId:     in.RequestID,
+		ClientKeyHash: in.ClientKeyHash,
+		CachePolicy: &cryptaliasv2.CachePolicy{
+			TtlSeconds:  uint32(max(in.Cache.TTLSeconds, 0)),
+			RetireUsed:  in.Cache.RetireUsed,
+			ReuseUnused: in.Cache.ReuseUnused,
+		},
+	}
+	if in.Memo != "" {
+		req.Memo = proto.String(in.Memo)
+	}
+	return req
 }
 
 func findTokenConfig(cfg *Config, ticker string) (TokenConfig, error) {
@@ -158,6 +564,9 @@ func accountKey(in dynamicAliasInput) string {
 	if in.WalletID != nil && *in.WalletID != "" {
 		parts = append(parts, "wid="+*in.WalletID)
 	}
+	if in.AddressMode != nil && *in.AddressMode != "" {
+		parts = append(parts, "am="+*in.AddressMode)
+	}
 	// Empty means "no routing hints" and still participates in the cache key.
 	return strings.Join(parts, "|")
 }
@@ -2,9 +2,12 @@ package cryptalias
 
 import (
 	"context"
-	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
+
+	cryptaliasv1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestWalletResolverCachesPerClient(t *testing.T) {
@@ -15,9 +18,9 @@ func TestWalletResolverCachesPerClient(t *testing.T) {
 	}
 
 	calls := 0
-	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (string, error) {
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
 		calls++
-		return fmt.Sprintf("addr-%d", calls), nil
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, byte(calls))}, nil
 	}
 	resolver := newWalletResolverWithDeps(state, internalFn, nil)
 
@@ -65,3 +68,291 @@ func TestWalletResolverCachesPerClient(t *testing.T) {
 		t.Fatalf("expected 2 internal calls after different client, got %d", calls)
 	}
 }
+
+func TestWalletResolverDoesNotCacheMemoOrAmount(t *testing.T) {
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	calls := 0
+	var last dynamicAliasInput
+	internalFn := func(ctx context.Context, token TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		last = in
+		return &cryptaliasv1.WalletAddressResponse{Address: testMoneroAddress(t, byte(calls))}, nil
+	}
+	resolver := newWalletResolverWithDeps(state, internalFn, nil)
+	cfg := &Config{Tokens: []TokenConfig{{
+		Name:     "Monero",
+		Tickers:  []string{"xmr"},
+		Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: "internal"},
+	}}}
+	cfg.Normalize("")
+	cfg.Resolution.ReuseUnused = true
+	ctx := withClientKey(context.Background(), "client-a")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+
+	plain, err := resolver.Resolve(ctx, cfg, in)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	// Repeating a memo still reaches the backend: payer values are never
+	// part of the cache key.
+	for i, extra := range []dynamicAliasInput{{Memo: "order 1"}, {Memo: "order 1"}, {Amount: "0.5"}} {
+		req := in
+		req.Memo, req.Amount = extra.Memo, extra.Amount
+		got, err := resolver.Resolve(ctx, cfg, req)
+		if err != nil {
+			t.Fatalf("resolve %d: %v", i, err)
+		}
+		if got.Address == plain.Address || calls != i+2 || last.Memo != req.Memo || last.Amount != req.Amount {
+			t.Fatalf("expected request %d to reach the backend with its memo and amount, got %q after %d calls", i, got.Address, calls)
+		}
+		if _, ok := state.LookupAddress("xmr", got.Address); !ok {
+			t.Fatalf("expected the dedicated address to be recorded for payment matching")
+		}
+	}
+
+	// The plain request still hits its cache entry, and dedicated addresses
+	// are not handed to other clients.
+	if again, err := resolver.Resolve(ctx, cfg, in); err != nil || again.Address != plain.Address || calls != 4 {
+		t.Fatalf("expected the cached address, got %q after %d calls (%v)", again.Address, calls, err)
+	}
+	if recs := state.UnusedIssued(in, time.Now().UTC()); len(recs) != 0 {
+		t.Fatalf("expected no reusable addresses, got %+v", recs)
+	}
+}
+
+func TestWalletResolverRejectsInvalidAddress(t *testing.T) {
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	internalFn := func(context.Context, TokenConfig, dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		return &cryptaliasv1.WalletAddressResponse{Address: "not-a-monero-address"}, nil
+	}
+	resolver := newWalletResolverWithDeps(state, internalFn, nil)
+	cfg := &Config{
+		Tokens: []TokenConfig{{
+			Name:     "Monero",
+			Tickers:  []string{"xmr"},
+			Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: "internal"},
+		}},
+	}
+
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	if _, err := resolver.Resolve(context.Background(), cfg, in); err == nil {
+		t.Fatalf("expected invalid wallet address to be rejected")
+	}
+	if _, ok := state.Get(aliasKey("xmr", "example.com", "demo", "", "", "unknown"), time.Now().UTC()); ok {
+		t.Fatalf("expected invalid address not to be cached")
+	}
+}
+
+func TestWalletResolverKeepsDestinationFieldsOnCacheHit(t *testing.T) {
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	calls := 0
+	internalFn := func(context.Context, TokenConfig, dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		calls++
+		return &cryptaliasv1.WalletAddressResponse{
+			Address:        "rExampleDeposit",
+			Memo:           proto.String("invoice-42"),
+			DestinationTag: proto.Uint32(12345),
+		}, nil
+	}
+	resolver := newWalletResolverWithDeps(state, internalFn, nil)
+	cfg := &Config{
+		Tokens: []TokenConfig{{
+			Name:     "XRP",
+			Tickers:  []string{"xrp"},
+			Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: "internal"},
+		}},
+	}
+	cfg.Normalize("")
+
+	in := dynamicAliasInput{Ticker: "xrp", Alias: "demo", Domain: "example.com"}
+	for i := 0; i < 2; i++ {
+		got, err := resolver.Resolve(context.Background(), cfg, in)
+		if err != nil {
+			t.Fatalf("resolve %d: %v", i, err)
+		}
+		if got.Memo == nil || *got.Memo != "invoice-42" {
+			t.Fatalf("resolve %d: expected memo to be kept, got %v", i, got.Memo)
+		}
+		if got.DestinationTag == nil || *got.DestinationTag != 12345 {
+			t.Fatalf("resolve %d: expected destination tag to be kept, got %v", i, got.DestinationTag)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected second resolve to hit the cache, got %d calls", calls)
+	}
+}
+
+func TestWalletResolverRecordsIntegratedPaymentID(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yml")
+	state, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+
+	raw := []byte{19}
+	for i := 0; i < 72; i++ {
+		raw = append(raw, byte(i))
+	}
+	raw = append(raw, keccak256(raw)[:4]...)
+	integrated := moneroBase58Encode(raw)
+
+	var gotMode string
+	internalFn := func(_ context.Context, _ TokenConfig, in dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		gotMode = newWalletAddressRequest(in).GetAddressMode()
+		return &cryptaliasv1.WalletAddressResponse{
+			Address:   integrated,
+			PaymentId: proto.String("0011223344556677"),
+		}, nil
+	}
+	resolver := newWalletResolverWithDeps(state, internalFn, nil)
+	cfg := &Config{
+		Tokens: []TokenConfig{{
+			Name:    "Monero",
+			Tickers: []string{"xmr"},
+			Endpoint: TokenEndpointConfig{
+				EndpointType:    TokenEndpointTypeInternal,
+				EndpointAddress: "internal",
+				AddressMode:     "Integrated",
+			},
+		}},
+	}
+	cfg.Normalize("")
+
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Tag: "shop", Domain: "example.com"}
+	got, err := resolver.Resolve(context.Background(), cfg, in)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if gotMode != AddressModeIntegrated {
+		t.Fatalf("expected endpoint address_mode to reach the wallet, got %q", gotMode)
+	}
+	if got.PaymentID == nil || *got.PaymentID != "0011223344556677" {
+		t.Fatalf("expected payment id, got %v", got.PaymentID)
+	}
+
+	// The payment ID index must survive a restart.
+	reloaded, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("reload address store: %v", err)
+	}
+	rec, ok := reloaded.LookupPaymentID("xmr", "0011223344556677")
+	if !ok {
+		t.Fatalf("expected payment id to be recorded")
+	}
+	if rec.Domain != "example.com" || rec.Alias != "demo" || rec.Tag != "shop" || rec.Address != integrated {
+		t.Fatalf("unexpected payment id record: %+v", rec)
+	}
+}
+
+func TestAddressStorePaymentIDsIgnoreCase(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	state, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("address store: %v", err)
+	}
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	if err := state.RecordIssued("key", in, addressEntry{Address: "primary", PaymentID: "00AAbbCCddEEff11"}, time.Now(), time.Minute, 0); err != nil {
+		t.Fatalf("record issued: %v", err)
+	}
+	reloaded, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("reload address store: %v", err)
+	}
+	for _, pid := range []string{"00aabbccddeeff11", "00AABBCCDDEEFF11", "00AAbbCCddEEff11"} {
+		if rec, ok := reloaded.LookupPaymentID("xmr", pid); !ok || rec.Alias != "demo" {
+			t.Fatalf("expected payment id %s to match, got %+v %t", pid, rec, ok)
+		}
+	}
+}
+
+func TestAddressStorePrunesIssuedRecords(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	state, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("address store: %v", err)
+	}
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "demo", Domain: "example.com"}
+	retention := 90 * 24 * time.Hour
+	now := time.Now()
+	old := now.Add(-retention - time.Hour)
+	if err := state.RecordIssued("old", in, addressEntry{Address: "addr-old"}, old, time.Minute, retention); err != nil {
+		t.Fatalf("record old: %v", err)
+	}
+	if err := state.RecordIssued("new", in, addressEntry{Address: "addr-new"}, now, time.Minute, retention); err != nil {
+		t.Fatalf("record new: %v", err)
+	}
+
+	// One call both caches the address and records it.
+	reloaded, err := newAddressStore(configPath)
+	if err != nil {
+		t.Fatalf("reload address store: %v", err)
+	}
+	if _, ok := reloaded.Get("new", now); !ok {
+		t.Fatalf("expected the cache entry to be stored")
+	}
+	if _, ok := reloaded.LookupAddress("xmr", "addr-new"); !ok {
+		t.Fatalf("expected the new record to be kept")
+	}
+	if _, ok := reloaded.LookupAddress("xmr", "addr-old"); ok {
+		t.Fatalf("expected the record past retention to be pruned")
+	}
+}
+
+func TestWalletResolverHonoursExpiryHint(t *testing.T) {
+	state, err := newAddressStore(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new address store: %v", err)
+	}
+	internalFn := func(context.Context, TokenConfig, dynamicAliasInput) (*cryptaliasv1.WalletAddressResponse, error) {
+		return &cryptaliasv1.WalletAddressResponse{
+			Address:          testMoneroAddress(t, 1),
+			ExpiresInSeconds: proto.Uint32(10),
+			Reference:        proto.String("invoice-7"),
+			Labels:           map[string]string{"order": "42"},
+			Index:            &cryptaliasv1.AddressIndex{Account: 1, Address: 3},
+		}, nil
+	}
+	resolver := newWalletResolverWithDeps(state, internalFn, nil)
+	cfg := &Config{Tokens: []TokenConfig{{
+		Name:     "Monero",
+		Tickers:  []string{"xmr"},
+		Endpoint: TokenEndpointConfig{EndpointType: TokenEndpointTypeInternal, EndpointAddress: "internal"},
+	}}}
+	cfg.Normalize("")
+	in := dynamicAliasInput{Ticker: "xmr", Alias: "shop", Domain: "example.com"}
+
+	before := time.Now().UTC()
+	got, err := resolver.Resolve(withClientKey(context.Background(), "client-a"), cfg, in)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got.ExpiresAt.Before(before.Add(9*time.Second)) || got.ExpiresAt.After(before.Add(11*time.Second)) {
+		t.Fatalf("expected the address to expire with the wallet hint, got %v", got.ExpiresAt)
+	}
+
+	// The hint caps the 60s resolution TTL.
+	key := aliasKey("xmr", "example.com", "shop", "", accountKey(in), "client-a")
+	entry, ok := state.Get(key, time.Now())
+	if !ok || entry.Reference != "invoice-7" || entry.Labels["order"] != "42" || entry.Index == nil || entry.Index.Address != 3 {
+		t.Fatalf("expected wallet metadata to be cached, got %+v", entry)
+	}
+	if _, ok := state.Get(key, before.Add(11*time.Second)); ok {
+		t.Fatalf("expected the cache entry to expire with the wallet hint")
+	}
+	if rec, ok := state.LookupAddress("xmr", got.Address); !ok || rec.Reference != "invoice-7" {
+		t.Fatalf("expected the reference to be kept for payment correlation, got %+v", rec)
+	}
+	if unused := state.UnusedIssued(in, before.Add(11*time.Second)); len(unused) != 0 {
+		t.Fatalf("expected an expired address not to be reused, got %+v", unused)
+	}
+}
@@ -0,0 +1,538 @@
+package cryptalias
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventAliasResolved       = "alias.resolved"
+	EventAddressIssued       = "address.issued"
+	EventAddressCacheHit     = "address.cache_hit"
+	EventDomainHealthChanged = "domain.health_changed"
+	EventPaymentReceived     = "payment.received"
+	EventPaymentConfirmed    = "payment.confirmed"
+)
+
+var webhookEventTypes = []string{
+	EventAliasResolved,
+	EventAddressIssued,
+	EventAddressCacheHit,
+	EventDomainHealthChanged,
+	EventPaymentReceived,
+	EventPaymentConfirmed,
+}
+
+const (
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookInitialBackoff = 5
+	defaultWebhookMaxBackoff     = 3600
+	defaultWebhookTimeout        = 10
+	// maxWebhookOutbox bounds the pending deliveries; further ones go
+	// straight to the dead-letter log.
+	maxWebhookOutbox = 10000
+)
+
+// WebhookConfig subscribes a URL to events of a domain. Aliases limits alias
+// events to the listed aliases; domain events are always delivered.
+type WebhookConfig struct {
+	URL     string   `yaml:"url"`
+	Events  []string `yaml:"events,omitempty"`
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+func (w *WebhookConfig) normalize() {
+	w.URL = strings.TrimSpace(w.URL)
+	for i := range w.Events {
+		w.Events[i] = strings.ToLower(strings.TrimSpace(w.Events[i]))
+	}
+	for i := range w.Aliases {
+		w.Aliases[i] = strings.ToLower(strings.TrimSpace(w.Aliases[i]))
+	}
+}
+
+func (w WebhookConfig) validate(path string) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s.url must be an absolute http(s) URL", path)
+	}
+	for _, e := range w.Events {
+		known := false
+		for _, t := range webhookEventTypes {
+			known = known || e == t
+		}
+		if !known {
+			return fmt.Errorf("%s.events: unknown event %q (valid: %s)", path, e, strings.Join(webhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
+
+// wants reports whether the subscription receives an event.
+func (w WebhookConfig) wants(ev WebhookEvent) bool {
+	if len(w.Events) > 0 && !containsString(w.Events, ev.Type) {
+		return false
+	}
+	if ev.Alias != "" && len(w.Aliases) > 0 && !containsString(w.Aliases, ev.Alias) {
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneWebhooks(in []WebhookConfig) []WebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := make([]WebhookConfig, len(in))
+	for i, w := range in {
+		out[i] = WebhookConfig{
+			URL:     w.URL,
+			Events:  append([]string(nil), w.Events...),
+			Aliases: append([]string(nil), w.Aliases...),
+		}
+	}
+	return out
+}
+
+type WebhookDeliveryConfig struct {
+	// MaxAttempts before a delivery is moved to the dead-letter log.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// InitialBackoffSeconds doubles after every failed attempt, up to
+	// MaxBackoffSeconds.
+	InitialBackoffSeconds int `yaml:"initial_backoff_seconds,omitempty"`
+	MaxBackoffSeconds     int `yaml:"max_backoff_seconds,omitempty"`
+	TimeoutSeconds        int `yaml:"timeout_seconds,omitempty"`
+}
+
+func (c *WebhookDeliveryConfig) normalize() {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if c.InitialBackoffSeconds <= 0 {
+		c.InitialBackoffSeconds = defaultWebhookInitialBackoff
+	}
+	if c.MaxBackoffSeconds <= 0 {
+		c.MaxBackoffSeconds = defaultWebhookMaxBackoff
+	}
+	if c.TimeoutSeconds <= 0 {
+		c.TimeoutSeconds = defaultWebhookTimeout
+	}
+}
+
+func (c WebhookDeliveryConfig) validate() error {
+	if c.MaxAttempts <= 0 {
+		return fmt.Errorf("webhook_delivery.max_attempts must be > 0")
+	}
+	if c.InitialBackoffSeconds <= 0 || c.MaxBackoffSeconds < c.InitialBackoffSeconds {
+		return fmt.Errorf("webhook_delivery.max_backoff_seconds must be >= initial_backoff_seconds > 0")
+	}
+	if c.TimeoutSeconds <= 0 {
+		return fmt.Errorf("webhook_delivery.timeout_seconds must be > 0")
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt after attempts failures.
+func (c WebhookDeliveryConfig) backoff(attempts int) time.Duration {
+	d := time.Duration(c.InitialBackoffSeconds) * time.Second
+	limit := time.Duration(c.MaxBackoffSeconds) * time.Second
+	for i := 1; i < attempts && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// WebhookEvent is the JSON body POSTed to subscribers.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Domain    string          `json:"domain"`
+	Alias     string          `json:"alias,omitempty"`
+	Tag       string          `json:"tag,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+func newWebhookEvent(eventType, domain, alias, tag string, data any) WebhookEvent {
+	ev := WebhookEvent{Type: eventType, Domain: domain, Alias: alias, Tag: tag, CreatedAt: time.Now().UTC()}
+	if data != nil {
+		if b, err := json.Marshal(data); err == nil {
+			ev.Data = b
+		}
+	}
+	return ev
+}
+
+// webhookDelivery is one event queued for one subscriber URL.
+type webhookDelivery struct {
+	ID          string          `json:"id"`
+	URL         string          `json:"url"`
+	Domain      string          `json:"domain"`
+	Event       string          `json:"event"`
+	Body        json.RawMessage `json:"body"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt time.Time       `json:"next_attempt"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+type webhookOutboxFile struct {
+	Deliveries []*webhookDelivery `json:"deliveries"`
+}
+
+// WebhookDispatcher queues events in a durable outbox and delivers them to
+// the subscribed URLs with exponential backoff. Deliveries that exhaust
+// max_attempts, or do not fit in the outbox, are appended to the dead-letter
+// log. The outbox is written in the background so Emit never waits on disk.
+type WebhookDispatcher struct {
+	store      *ConfigStore
+	client     *http.Client
+	outboxPath string
+	deadPath   string
+	wake       chan struct{}
+	persist    chan struct{}
+
+	mu         sync.Mutex
+	deliveries map[string]*webhookDelivery
+	// dirty is set when deliveries changed since the outbox was written;
+	// dead holds deliveries waiting to be appended to the dead-letter log.
+	dirty bool
+	dead  []*webhookDelivery
+
+	// saveMu serializes flushes.
+	saveMu sync.Mutex
+}
+
+func NewWebhookDispatcher(configPath string, store *ConfigStore) (*WebhookDispatcher, error) {
+	d := &WebhookDispatcher{
+		store:      store,
+		client:     &http.Client{},
+		outboxPath: configPath + ".webhooks.json",
+		deadPath:   configPath + ".webhooks-dead.jsonl",
+		wake:       make(chan struct{}, 1),
+		persist:    make(chan struct{}, 1),
+		deliveries: map[string]*webhookDelivery{},
+	}
+	b, err := os.ReadFile(d.outboxPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var file webhookOutboxFile
+		if err := json.Unmarshal(b, &file); err != nil {
+			return nil, fmt.Errorf("read webhook outbox: %w", err)
+		}
+		for _, del := range file.Deliveries {
+			d.deliveries[del.ID] = del
+		}
+	}
+	return d, nil
+}
+
+// Emit queues an event for every subscription of its domain. A nil
+// dispatcher drops events, so callers need not check.
+func (d *WebhookDispatcher) Emit(ev WebhookEvent) {
+	if d == nil || d.store == nil {
+		return
+	}
+	cfg := d.store.Get()
+	var hooks []WebhookConfig
+	for _, dc := range cfg.Domains {
+		if strings.EqualFold(dc.Domain, ev.Domain) {
+			hooks = dc.Webhooks
+			break
+		}
+	}
+	if len(hooks) == 0 {
+		return
+	}
+	if ev.ID == "" {
+		id, err := NewNonce()
+		if err != nil {
+			slog.Error("webhook event id generation failed", "error", err)
+			return
+		}
+		ev.ID = id
+	}
+	body, err := json.Marshal(ev)
+	if err != nil {
+		slog.Error("webhook event marshal failed", "type", ev.Type, "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	d.mu.Lock()
+	queued, dropped := 0, 0
+	for i, hook := range hooks {
+		if !hook.wants(ev) {
+			continue
+		}
+		id := ev.ID + "-" + strconv.Itoa(i)
+		del := &webhookDelivery{ID: id, URL: hook.URL, Domain: ev.Domain, Event: ev.Type, Body: body, NextAttempt: now}
+		if len(d.deliveries) >= maxWebhookOutbox {
+			del.LastError = "webhook outbox full"
+			d.dead = append(d.dead, del)
+			dropped++
+			continue
+		}
+		d.deliveries[id] = del
+		queued++
+	}
+	d.dirty = d.dirty || queued > 0
+	d.mu.Unlock()
+
+	if dropped > 0 {
+		slog.Error("webhook outbox full, dead-lettered", "event", ev.Type, "domain", ev.Domain, "deliveries", dropped)
+	}
+	if queued+dropped > 0 {
+		signal(d.persist)
+	}
+	if queued > 0 {
+		signal(d.wake)
+	}
+}
+
+// signal wakes a loop without blocking when a wake-up is already pending.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// EmitPayment turns a correlated incoming payment into a payment event.
+func (d *WebhookDispatcher) EmitPayment(p ReceivedPayment) {
+	eventType := EventPaymentReceived
+	if p.Confirmed {
+		eventType = EventPaymentConfirmed
+	}
+	d.Emit(newWebhookEvent(eventType, p.Domain, p.Alias, p.Tag, p))
+}
+
+// Start runs the delivery and outbox persistence loops until ctx is done.
+func (d *WebhookDispatcher) Start(ctx context.Context) {
+	if d == nil {
+		return
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				d.flush()
+				return
+			case <-d.persist:
+				d.flush()
+			}
+		}
+	}()
+	go func() {
+		for {
+			next := d.deliverDue(ctx, time.Now().UTC())
+			wait := 30 * time.Second
+			if !next.IsZero() {
+				wait = min(wait, max(time.Until(next), 0))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-d.wake:
+			case <-time.After(wait):
+			}
+		}
+	}()
+}
+
+// deliverDue attempts every delivery due at now and returns when the next
+// pending one is due, or the zero time when the outbox is empty.
+func (d *WebhookDispatcher) deliverDue(ctx context.Context, now time.Time) time.Time {
+	d.mu.Lock()
+	var due []*webhookDelivery
+	for _, del := range d.deliveries {
+		if !del.NextAttempt.After(now) {
+			due = append(due, del)
+		}
+	}
+	d.mu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAttempt.Before(due[j].NextAttempt) })
+
+	cfg := d.store.Get()
+	for _, del := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		err := d.send(ctx, cfg, del)
+
+		d.mu.Lock()
+		del.Attempts++
+		switch {
+		case err == nil:
+			delete(d.deliveries, del.ID)
+			slog.Debug("webhook delivered", "url", del.URL, "event", del.Event, "attempts", del.Attempts)
+		case del.Attempts >= cfg.WebhookDelivery.MaxAttempts:
+			del.LastError = err.Error()
+			delete(d.deliveries, del.ID)
+			d.dead = append(d.dead, del)
+			slog.Error("webhook dead-lettered", "url", del.URL, "event", del.Event, "attempts", del.Attempts, "error", err)
+		default:
+			del.LastError = err.Error()
+			del.NextAttempt = now.Add(cfg.WebhookDelivery.backoff(del.Attempts))
+			slog.Warn("webhook delivery failed", "url", del.URL, "event", del.Event, "attempts", del.Attempts, "retry_at", del.NextAttempt, "error", err)
+		}
+		d.dirty = true
+		d.mu.Unlock()
+	}
+	if len(due) > 0 {
+		signal(d.persist)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var next time.Time
+	for _, del := range d.deliveries {
+		if next.IsZero() || del.NextAttempt.Before(next) {
+			next = del.NextAttempt
+		}
+	}
+	return next
+}
+
+// send POSTs one delivery, signed with the domain's current key.
+func (d *WebhookDispatcher) send(ctx context.Context, cfg *Config, del *webhookDelivery) error {
+	var key ed25519.PrivateKey
+	for _, dc := range cfg.Domains {
+		if dc.Domain == del.Domain {
+			key = ed25519.PrivateKey(dc.PrivateKey)
+		}
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("domain %q has no signing key", del.Domain)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.WebhookDelivery.TimeoutSeconds)*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(del.Body))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cryptalias-Event", del.Event)
+	req.Header.Set("Cryptalias-Delivery", del.ID)
+	req.Header.Set("Cryptalias-Timestamp", timestamp)
+	req.Header.Set("Cryptalias-Signature", signWebhook(key, timestamp, del.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// signWebhook signs "<timestamp>.<body>" with the domain key. Receivers verify
+// it with the public key published in the domain's DNS TXT record.
+func signWebhook(key ed25519.PrivateKey, timestamp string, body []byte) string {
+	msg := append([]byte(timestamp+"."), body...)
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(key, msg))
+}
+
+// Pending returns the number of deliveries waiting in the outbox.
+func (d *WebhookDispatcher) Pending() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.deliveries)
+}
+
+// flush appends pending dead letters, then rewrites the outbox if deliveries
+// changed since the last flush. Dead letters go first so a crash in between
+// delivers a dead-lettered event again rather than losing it.
+func (d *WebhookDispatcher) flush() {
+	d.saveMu.Lock()
+	defer d.saveMu.Unlock()
+
+	d.mu.Lock()
+	dead := d.dead
+	d.dead = nil
+	var b []byte
+	if d.dirty {
+		file := webhookOutboxFile{Deliveries: make([]*webhookDelivery, 0, len(d.deliveries))}
+		for _, del := range d.deliveries {
+			file.Deliveries = append(file.Deliveries, del)
+		}
+		sort.Slice(file.Deliveries, func(i, j int) bool { return file.Deliveries[i].ID < file.Deliveries[j].ID })
+		var err error
+		if b, err = json.MarshalIndent(file, "", "  "); err != nil {
+			slog.Warn("webhook outbox store failed", "error", err)
+		}
+		d.dirty = false
+	}
+	d.mu.Unlock()
+
+	if len(dead) > 0 {
+		if err := d.appendDeadLetters(dead); err != nil {
+			slog.Error("webhook dead-letter log failed", "path", d.deadPath, "error", err)
+		}
+	}
+	if b == nil {
+		return
+	}
+	if err := writeFileAtomic(d.outboxPath, b, 0o600); err != nil {
+		slog.Warn("webhook outbox store failed", "error", err)
+		d.mu.Lock()
+		d.dirty = true
+		d.mu.Unlock()
+	}
+}
+
+func (d *WebhookDispatcher) appendDeadLetters(dels []*webhookDelivery) error {
+	var buf bytes.Buffer
+	deadAt := time.Now().UTC()
+	for _, del := range dels {
+		line, err := json.Marshal(struct {
+			*webhookDelivery
+			DeadAt time.Time `json:"dead_at"`
+		}{del, deadAt})
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	f, err := os.OpenFile(d.deadPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
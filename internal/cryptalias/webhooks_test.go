@@ -0,0 +1,169 @@
+package cryptalias
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver records webhook requests and answers with status.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	status := r.status
+	r.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func newWebhookTestDispatcher(t *testing.T, hook WebhookConfig, delivery WebhookDeliveryConfig) (*ConfigStore, *WebhookDispatcher, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	cfg := testConfig(t)
+	cfg.Domains[0].Webhooks = []WebhookConfig{hook}
+	cfg.WebhookDelivery = delivery
+	cfg.Normalize("")
+	store := NewConfigStore(path, cfg)
+	d, err := NewWebhookDispatcher(path, store)
+	if err != nil {
+		t.Fatalf("new webhook dispatcher: %v", err)
+	}
+	return store, d, path
+}
+
+func TestWebhookDeliversSignedResolveEvent(t *testing.T) {
+	rcv := &webhookReceiver{status: http.StatusNoContent}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	store, d, _ := newWebhookTestDispatcher(t, WebhookConfig{URL: srv.URL, Events: []string{EventAliasResolved}, Aliases: []string{"demo"}}, WebhookDeliveryConfig{})
+	resolver, err := NewWalletResolver(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("new wallet resolver: %v", err)
+	}
+
+	// A health event is filtered out by the subscription's events list.
+	d.Emit(newWebhookEvent(EventDomainHealthChanged, "127.0.0.1", "", "", nil))
+	req := httptest.NewRequest(http.MethodGet, "/_cryptalias/resolve/xmr/demo$127.0.0.1", nil)
+	req.SetPathValue("ticker", "xmr")
+	req.SetPathValue("alias", "demo$127.0.0.1")
+	rr := httptest.NewRecorder()
+	AliasResolverHandler(store, resolver, nil, d).ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if d.Pending() != 1 {
+		t.Fatalf("expected one queued delivery, got %d", d.Pending())
+	}
+
+	if next := d.deliverDue(context.Background(), time.Now().UTC()); !next.IsZero() || d.Pending() != 0 {
+		t.Fatalf("expected the outbox to drain, %d pending", d.Pending())
+	}
+	if len(rcv.requests) != 1 {
+		t.Fatalf("expected one webhook request, got %d", len(rcv.requests))
+	}
+	got := rcv.requests[0]
+	sig, err := base64.StdEncoding.DecodeString(got.Header.Get("Cryptalias-Signature"))
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	msg := append([]byte(got.Header.Get("Cryptalias-Timestamp")+"."), rcv.bodies[0]...)
+	if !ed25519.Verify(ed25519.PublicKey(store.Get().Domains[0].PublicKey), msg, sig) {
+		t.Fatalf("webhook signature does not verify with the domain key")
+	}
+	var ev WebhookEvent
+	if err := json.Unmarshal(rcv.bodies[0], &ev); err != nil {
+		t.Fatalf("unmarshal event: %v", err)
+	}
+	if ev.Type != EventAliasResolved || ev.Alias != "demo" || !strings.Contains(string(ev.Data), testMoneroAddress(t, 1)) {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
+
+func TestWebhookRetriesFromOutboxThenDeadLetters(t *testing.T) {
+	rcv := &webhookReceiver{status: http.StatusInternalServerError}
+	srv := httptest.NewServer(rcv)
+	defer srv.Close()
+	store, d, path := newWebhookTestDispatcher(t, WebhookConfig{URL: srv.URL}, WebhookDeliveryConfig{MaxAttempts: 2})
+
+	d.Emit(newWebhookEvent(EventDomainHealthChanged, "127.0.0.1", "", "", DomainStatus{Domain: "127.0.0.1"}))
+	now := time.Now().UTC()
+	next := d.deliverDue(context.Background(), now)
+	if want := now.Add(5 * time.Second); !next.Equal(want) {
+		t.Fatalf("expected retry at %v, got %v", want, next)
+	}
+	d.flush()
+
+	// The pending delivery survives a restart.
+	d, err := NewWebhookDispatcher(path, store)
+	if err != nil {
+		t.Fatalf("reload dispatcher: %v", err)
+	}
+	d.deliverDue(context.Background(), now.Add(4*time.Second))
+	if len(rcv.requests) != 1 || d.Pending() != 1 {
+		t.Fatalf("expected no attempt before the backoff, got %d requests", len(rcv.requests))
+	}
+	d.deliverDue(context.Background(), now.Add(5*time.Second))
+	d.flush()
+	if len(rcv.requests) != 2 || d.Pending() != 0 {
+		t.Fatalf("expected a final attempt and an empty outbox, got %d requests, %d pending", len(rcv.requests), d.Pending())
+	}
+	dead, err := os.ReadFile(path + ".webhooks-dead.jsonl")
+	if err != nil {
+		t.Fatalf("read dead-letter log: %v", err)
+	}
+	if !strings.Contains(string(dead), EventDomainHealthChanged) || !strings.Contains(string(dead), "500") {
+		t.Fatalf("unexpected dead-letter log %s", dead)
+	}
+
+	cfg := store.Get().WebhookDelivery
+	if cfg.backoff(3) != 20*time.Second || cfg.backoff(20) != time.Hour {
+		t.Fatalf("unexpected backoff %v %v", cfg.backoff(3), cfg.backoff(20))
+	}
+}
+
+func TestWebhookEmitIsBoundedAndPersistedInBackground(t *testing.T) {
+	_, d, path := newWebhookTestDispatcher(t, WebhookConfig{URL: "http://127.0.0.1:1/hook"}, WebhookDeliveryConfig{})
+	for i := 0; i < maxWebhookOutbox+2; i++ {
+		d.Emit(newWebhookEvent(EventDomainHealthChanged, "127.0.0.1", "", "", nil))
+	}
+	if d.Pending() != maxWebhookOutbox {
+		t.Fatalf("expected the outbox to be capped at %d, got %d", maxWebhookOutbox, d.Pending())
+	}
+	if _, err := os.Stat(path + ".webhooks.json"); !os.IsNotExist(err) {
+		t.Fatalf("expected Emit not to write the outbox, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.Start(ctx)
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dead, _ := os.ReadFile(path + ".webhooks-dead.jsonl")
+		reloaded, err := NewWebhookDispatcher(path, d.store)
+		if err == nil && strings.Count(string(dead), "webhook outbox full") == 2 && reloaded.Pending() == maxWebhookOutbox {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the overflow dead-lettered and the outbox persisted, got %d dead-letter lines", strings.Count(string(dead), "\n"))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,503 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.1
+// source: proto/cryptalias/v1/alias_provider.proto
+
+package cryptaliasv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LookupAliasRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Parsed alias identifier components, lowercased.
+	Domain        string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Alias         string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	Tag           string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	Ticker        string `protobuf:"bytes,4,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupAliasRequest) Reset() {
+	*x = LookupAliasRequest{}
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupAliasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupAliasRequest) ProtoMessage() {}
+
+func (x *LookupAliasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupAliasRequest.ProtoReflect.Descriptor instead.
+func (*LookupAliasRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LookupAliasRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *LookupAliasRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *LookupAliasRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *LookupAliasRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+type LookupAliasResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False (or a NOT_FOUND status) means the alias does not exist.
+	Found bool           `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Alias *ProvidedAlias `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	// Optional cache lifetime for this answer; 0 uses the configured default.
+	CacheSeconds  uint32 `protobuf:"varint,3,opt,name=cache_seconds,json=cacheSeconds,proto3" json:"cache_seconds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupAliasResponse) Reset() {
+	*x = LookupAliasResponse{}
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupAliasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupAliasResponse) ProtoMessage() {}
+
+func (x *LookupAliasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupAliasResponse.ProtoReflect.Descriptor instead.
+func (*LookupAliasResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LookupAliasResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *LookupAliasResponse) GetAlias() *ProvidedAlias {
+	if x != nil {
+		return x.Alias
+	}
+	return nil
+}
+
+func (x *LookupAliasResponse) GetCacheSeconds() uint32 {
+	if x != nil {
+		return x.CacheSeconds
+	}
+	return 0
+}
+
+type ProvidedAlias struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Alias  string                 `protobuf:"bytes,1,opt,name=alias,proto3" json:"alias,omitempty"`
+	Tag    string                 `protobuf:"bytes,2,opt,name=tag,proto3" json:"tag,omitempty"`
+	Ticker string                 `protobuf:"bytes,3,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	// Disabled aliases resolve as unknown.
+	Enabled bool `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	// Optional static address. When empty the token's wallet service issues
+	// one, with the routing hints below.
+	Address      string  `protobuf:"bytes,5,opt,name=address,proto3" json:"address,omitempty"`
+	AccountIndex *uint64 `protobuf:"varint,6,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
+	AccountId    *string `protobuf:"bytes,7,opt,name=account_id,json=accountId,proto3,oneof" json:"account_id,omitempty"`
+	WalletId     *string `protobuf:"bytes,8,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	AddressMode  *string `protobuf:"bytes,9,opt,name=address_mode,json=addressMode,proto3,oneof" json:"address_mode,omitempty"`
+	// Optional destination fields payers must include, as in config.yml.
+	Memo           *string `protobuf:"bytes,10,opt,name=memo,proto3,oneof" json:"memo,omitempty"`
+	DestinationTag *uint32 `protobuf:"varint,11,opt,name=destination_tag,json=destinationTag,proto3,oneof" json:"destination_tag,omitempty"`
+	PaymentId      *string `protobuf:"bytes,12,opt,name=payment_id,json=paymentId,proto3,oneof" json:"payment_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ProvidedAlias) Reset() {
+	*x = ProvidedAlias{}
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProvidedAlias) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProvidedAlias) ProtoMessage() {}
+
+func (x *ProvidedAlias) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProvidedAlias.ProtoReflect.Descriptor instead.
+func (*ProvidedAlias) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ProvidedAlias) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *ProvidedAlias) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetAccountIndex() uint64 {
+	if x != nil && x.AccountIndex != nil {
+		return *x.AccountIndex
+	}
+	return 0
+}
+
+func (x *ProvidedAlias) GetAccountId() string {
+	if x != nil && x.AccountId != nil {
+		return *x.AccountId
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetWalletId() string {
+	if x != nil && x.WalletId != nil {
+		return *x.WalletId
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetAddressMode() string {
+	if x != nil && x.AddressMode != nil {
+		return *x.AddressMode
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetMemo() string {
+	if x != nil && x.Memo != nil {
+		return *x.Memo
+	}
+	return ""
+}
+
+func (x *ProvidedAlias) GetDestinationTag() uint32 {
+	if x != nil && x.DestinationTag != nil {
+		return *x.DestinationTag
+	}
+	return 0
+}
+
+func (x *ProvidedAlias) GetPaymentId() string {
+	if x != nil && x.PaymentId != nil {
+		return *x.PaymentId
+	}
+	return ""
+}
+
+type ListAliasesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Domain string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Opaque token from a previous response; empty for the first page.
+	PageToken     string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAliasesRequest) Reset() {
+	*x = ListAliasesRequest{}
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAliasesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAliasesRequest) ProtoMessage() {}
+
+func (x *ListAliasesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAliasesRequest.ProtoReflect.Descriptor instead.
+func (*ListAliasesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListAliasesRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *ListAliasesRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListAliasesResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Aliases []*ProvidedAlias       `protobuf:"bytes,1,rep,name=aliases,proto3" json:"aliases,omitempty"`
+	// Empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAliasesResponse) Reset() {
+	*x = ListAliasesResponse{}
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAliasesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAliasesResponse) ProtoMessage() {}
+
+func (x *ListAliasesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_alias_provider_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAliasesResponse.ProtoReflect.Descriptor instead.
+func (*ListAliasesResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAliasesResponse) GetAliases() []*ProvidedAlias {
+	if x != nil {
+		return x.Aliases
+	}
+	return nil
+}
+
+func (x *ListAliasesResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+var File_proto_cryptalias_v1_alias_provider_proto protoreflect.FileDescriptor
+
+const file_proto_cryptalias_v1_alias_provider_proto_rawDesc = "" +
+	"\n" +
+	"(proto/cryptalias/v1/alias_provider.proto\x12\rcryptalias.v1\"l\n" +
+	"\x12LookupAliasRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06ticker\x18\x04 \x01(\tR\x06ticker\"\x84\x01\n" +
+	"\x13LookupAliasResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x122\n" +
+	"\x05alias\x18\x02 \x01(\v2\x1c.cryptalias.v1.ProvidedAliasR\x05alias\x12#\n" +
+	"\rcache_seconds\x18\x03 \x01(\rR\fcacheSeconds\"\xf2\x03\n" +
+	"\rProvidedAlias\x12\x14\n" +
+	"\x05alias\x18\x01 \x01(\tR\x05alias\x12\x10\n" +
+	"\x03tag\x18\x02 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06ticker\x18\x03 \x01(\tR\x06ticker\x12\x18\n" +
+	"\aenabled\x18\x04 \x01(\bR\aenabled\x12\x18\n" +
+	"\aaddress\x18\x05 \x01(\tR\aaddress\x12(\n" +
+	"\raccount_index\x18\x06 \x01(\x04H\x00R\faccountIndex\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"account_id\x18\a \x01(\tH\x01R\taccountId\x88\x01\x01\x12 \n" +
+	"\twallet_id\x18\b \x01(\tH\x02R\bwalletId\x88\x01\x01\x12&\n" +
+	"\faddress_mode\x18\t \x01(\tH\x03R\vaddressMode\x88\x01\x01\x12\x17\n" +
+	"\x04memo\x18\n" +
+	" \x01(\tH\x04R\x04memo\x88\x01\x01\x12,\n" +
+	"\x0fdestination_tag\x18\v \x01(\rH\x05R\x0edestinationTag\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"payment_id\x18\f \x01(\tH\x06R\tpaymentId\x88\x01\x01B\x10\n" +
+	"\x0e_account_indexB\r\n" +
+	"\v_account_idB\f\n" +
+	"\n" +
+	"_wallet_idB\x0f\n" +
+	"\r_address_modeB\a\n" +
+	"\x05_memoB\x12\n" +
+	"\x10_destination_tagB\r\n" +
+	"\v_payment_id\"K\n" +
+	"\x12ListAliasesRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"u\n" +
+	"\x13ListAliasesResponse\x126\n" +
+	"\aaliases\x18\x01 \x03(\v2\x1c.cryptalias.v1.ProvidedAliasR\aaliases\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken2\xbb\x01\n" +
+	"\rAliasProvider\x12T\n" +
+	"\vLookupAlias\x12!.cryptalias.v1.LookupAliasRequest\x1a\".cryptalias.v1.LookupAliasResponse\x12T\n" +
+	"\vListAliases\x12!.cryptalias.v1.ListAliasesRequest\x1a\".cryptalias.v1.ListAliasesResponseB?Z=github.com/kaigoh/cryptalias/proto/cryptalias/v1;cryptaliasv1b\x06proto3"
+
+var (
+	file_proto_cryptalias_v1_alias_provider_proto_rawDescOnce sync.Once
+	file_proto_cryptalias_v1_alias_provider_proto_rawDescData []byte
+)
+
+func file_proto_cryptalias_v1_alias_provider_proto_rawDescGZIP() []byte {
+	file_proto_cryptalias_v1_alias_provider_proto_rawDescOnce.Do(func() {
+		file_proto_cryptalias_v1_alias_provider_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_cryptalias_v1_alias_provider_proto_rawDesc), len(file_proto_cryptalias_v1_alias_provider_proto_rawDesc)))
+	})
+	return file_proto_cryptalias_v1_alias_provider_proto_rawDescData
+}
+
+var file_proto_cryptalias_v1_alias_provider_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_proto_cryptalias_v1_alias_provider_proto_goTypes = []any{
+	(*LookupAliasRequest)(nil),  // 0: cryptalias.v1.LookupAliasRequest
+	(*LookupAliasResponse)(nil), // 1: cryptalias.v1.LookupAliasResponse
+	(*ProvidedAlias)(nil),       // 2: cryptalias.v1.ProvidedAlias
+	(*ListAliasesRequest)(nil),  // 3: cryptalias.v1.ListAliasesRequest
+	(*ListAliasesResponse)(nil), // 4: cryptalias.v1.ListAliasesResponse
+}
+var file_proto_cryptalias_v1_alias_provider_proto_depIdxs = []int32{
+	2, // 0: cryptalias.v1.LookupAliasResponse.alias:type_name -> cryptalias.v1.ProvidedAlias
+	2, // 1: cryptalias.v1.ListAliasesResponse.aliases:type_name -> cryptalias.v1.ProvidedAlias
+	0, // 2: cryptalias.v1.AliasProvider.LookupAlias:input_type -> cryptalias.v1.LookupAliasRequest
+	3, // 3: cryptalias.v1.AliasProvider.ListAliases:input_type -> cryptalias.v1.ListAliasesRequest
+	1, // 4: cryptalias.v1.AliasProvider.LookupAlias:output_type -> cryptalias.v1.LookupAliasResponse
+	4, // 5: cryptalias.v1.AliasProvider.ListAliases:output_type -> cryptalias.v1.ListAliasesResponse
+	4, // [4:6] is the sub-list for method output_type
+	2, // [2:4] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_proto_cryptalias_v1_alias_provider_proto_init() }
+func file_proto_cryptalias_v1_alias_provider_proto_init() {
+	if File_proto_cryptalias_v1_alias_provider_proto != nil {
+		return
+	}
+	file_proto_cryptalias_v1_alias_provider_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_cryptalias_v1_alias_provider_proto_rawDesc), len(file_proto_cryptalias_v1_alias_provider_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_cryptalias_v1_alias_provider_proto_goTypes,
+		DependencyIndexes: file_proto_cryptalias_v1_alias_provider_proto_depIdxs,
+		MessageInfos:      file_proto_cryptalias_v1_alias_provider_proto_msgTypes,
+	}.Build()
+	File_proto_cryptalias_v1_alias_provider_proto = out.File
+	file_proto_cryptalias_v1_alias_provider_proto_goTypes = nil
+	file_proto_cryptalias_v1_alias_provider_proto_depIdxs = nil
+}
@@ -0,0 +1,171 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.1
+// source: proto/cryptalias/v1/alias_provider.proto
+
+package cryptaliasv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AliasProvider_LookupAlias_FullMethodName = "/cryptalias.v1.AliasProvider/LookupAlias"
+	AliasProvider_ListAliases_FullMethodName = "/cryptalias.v1.AliasProvider/ListAliases"
+)
+
+// AliasProviderClient is the client API for AliasProvider service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AliasProvider lets another system, e.g. a user directory, declare which
+// aliases of a domain exist. Cryptalias consults it for aliases that are not
+// in config.yml.
+type AliasProviderClient interface {
+	LookupAlias(ctx context.Context, in *LookupAliasRequest, opts ...grpc.CallOption) (*LookupAliasResponse, error)
+	// ListAliases enumerates a domain's aliases, e.g. for admin tooling.
+	// Providers may leave it unimplemented.
+	ListAliases(ctx context.Context, in *ListAliasesRequest, opts ...grpc.CallOption) (*ListAliasesResponse, error)
+}
+
+type aliasProviderClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAliasProviderClient(cc grpc.ClientConnInterface) AliasProviderClient {
+	return &aliasProviderClient{cc}
+}
+
+func (c *aliasProviderClient) LookupAlias(ctx context.Context, in *LookupAliasRequest, opts ...grpc.CallOption) (*LookupAliasResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupAliasResponse)
+	err := c.cc.Invoke(ctx, AliasProvider_LookupAlias_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *aliasProviderClient) ListAliases(ctx context.Context, in *ListAliasesRequest, opts ...grpc.CallOption) (*ListAliasesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAliasesResponse)
+	err := c.cc.Invoke(ctx, AliasProvider_ListAliases_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AliasProviderServer is the server API for AliasProvider service.
+// All implementations must embed UnimplementedAliasProviderServer
+// for forward compatibility.
+//
+// AliasProvider lets another system, e.g. a user directory, declare which
+// aliases of a domain exist. Cryptalias consults it for aliases that are not
+// in config.yml.
+type AliasProviderServer interface {
+	LookupAlias(context.Context, *LookupAliasRequest) (*LookupAliasResponse, error)
+	// ListAliases enumerates a domain's aliases, e.g. for admin tooling.
+	// Providers may leave it unimplemented.
+	ListAliases(context.Context, *ListAliasesRequest) (*ListAliasesResponse, error)
+	mustEmbedUnimplementedAliasProviderServer()
+}
+
+// UnimplementedAliasProviderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAliasProviderServer struct{}
+
+func (UnimplementedAliasProviderServer) LookupAlias(context.Context, *LookupAliasRequest) (*LookupAliasResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method LookupAlias not implemented")
+}
+func (UnimplementedAliasProviderServer) ListAliases(context.Context, *ListAliasesRequest) (*ListAliasesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListAliases not implemented")
+}
+func (UnimplementedAliasProviderServer) mustEmbedUnimplementedAliasProviderServer() {}
+func (UnimplementedAliasProviderServer) testEmbeddedByValue()                       {}
+
+// UnsafeAliasProviderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AliasProviderServer will
+// result in compilation errors.
+type UnsafeAliasProviderServer interface {
+	mustEmbedUnimplementedAliasProviderServer()
+}
+
+func RegisterAliasProviderServer(s grpc.ServiceRegistrar, srv AliasProviderServer) {
+	// If the following call panics, it indicates UnimplementedAliasProviderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AliasProvider_ServiceDesc, srv)
+}
+
+func _AliasProvider_LookupAlias_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupAliasRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AliasProviderServer).LookupAlias(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AliasProvider_LookupAlias_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AliasProviderServer).LookupAlias(ctx, req.(*LookupAliasRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AliasProvider_ListAliases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAliasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AliasProviderServer).ListAliases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AliasProvider_ListAliases_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AliasProviderServer).ListAliases(ctx, req.(*ListAliasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AliasProvider_ServiceDesc is the grpc.ServiceDesc for AliasProvider service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AliasProvider_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cryptalias.v1.AliasProvider",
+	HandlerType: (*AliasProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "LookupAlias",
+			Handler:    _AliasProvider_LookupAlias_Handler,
+		},
+		{
+			MethodName: "ListAliases",
+			Handler:    _AliasProvider_ListAliases_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/cryptalias/v1/alias_provider.proto",
+}
@@ -29,9 +29,14 @@ type WalletAddressRequest struct {
 	Tag    string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
 	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
 	// Optional alias-local routing hints from config.yml. Wallet services may ignore them.
-	AccountIndex  *uint64 `protobuf:"varint,5,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
-	AccountId     *string `protobuf:"bytes,6,opt,name=account_id,json=accountId,proto3,oneof" json:"account_id,omitempty"`
-	WalletId      *string `protobuf:"bytes,7,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	AccountIndex *uint64 `protobuf:"varint,5,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
+	AccountId    *string `protobuf:"bytes,6,opt,name=account_id,json=accountId,proto3,oneof" json:"account_id,omitempty"`
+	WalletId     *string `protobuf:"bytes,7,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	// Optional address mode hint, e.g. "subaddress" or "integrated" for Monero.
+	AddressMode *string `protobuf:"bytes,8,opt,name=address_mode,json=addressMode,proto3,oneof" json:"address_mode,omitempty"`
+	// Optional amount requested by the payer, as a decimal string in the
+	// ticker's units (e.g. "0.0015"). Services may create an invoice for it.
+	Amount        *string `protobuf:"bytes,9,opt,name=amount,proto3,oneof" json:"amount,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -115,9 +120,40 @@ func (x *WalletAddressRequest) GetWalletId() string {
 	return ""
 }
 
+func (x *WalletAddressRequest) GetAddressMode() string {
+	if x != nil && x.AddressMode != nil {
+		return *x.AddressMode
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetAmount() string {
+	if x != nil && x.Amount != nil {
+		return *x.Amount
+	}
+	return ""
+}
+
 type WalletAddressResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Optional chain-specific destination fields. Wallets MUST include them in
+	// the payment, so services should only set them when the chain needs them.
+	Memo           *string `protobuf:"bytes,2,opt,name=memo,proto3,oneof" json:"memo,omitempty"`
+	DestinationTag *uint32 `protobuf:"varint,3,opt,name=destination_tag,json=destinationTag,proto3,oneof" json:"destination_tag,omitempty"`
+	PaymentId      *string `protobuf:"bytes,4,opt,name=payment_id,json=paymentId,proto3,oneof" json:"payment_id,omitempty"`
+	// Optional hint that the address should not be handed out for longer than
+	// this many seconds, e.g. an invoice-backed address. Cryptalias caps its
+	// cache TTL and the signed expiry to it.
+	ExpiresInSeconds *uint32 `protobuf:"varint,5,opt,name=expires_in_seconds,json=expiresInSeconds,proto3,oneof" json:"expires_in_seconds,omitempty"`
+	// Optional free-form metadata, e.g. a payment URI. Not signed or shown to
+	// payers; it is passed on in webhook events.
+	Labels map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Optional wallet-side position of the address, e.g. Monero subaddress 0/57.
+	Index *AddressIndex `protobuf:"bytes,7,opt,name=index,proto3,oneof" json:"index,omitempty"`
+	// Optional opaque reference the service wants back when the address is
+	// paid, e.g. its own invoice ID. Reported with payment events.
+	Reference     *string `protobuf:"bytes,8,opt,name=reference,proto3,oneof" json:"reference,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -159,6 +195,600 @@ func (x *WalletAddressResponse) GetAddress() string {
 	return ""
 }
 
+func (x *WalletAddressResponse) GetMemo() string {
+	if x != nil && x.Memo != nil {
+		return *x.Memo
+	}
+	return ""
+}
+
+func (x *WalletAddressResponse) GetDestinationTag() uint32 {
+	if x != nil && x.DestinationTag != nil {
+		return *x.DestinationTag
+	}
+	return 0
+}
+
+func (x *WalletAddressResponse) GetPaymentId() string {
+	if x != nil && x.PaymentId != nil {
+		return *x.PaymentId
+	}
+	return ""
+}
+
+func (x *WalletAddressResponse) GetExpiresInSeconds() uint32 {
+	if x != nil && x.ExpiresInSeconds != nil {
+		return *x.ExpiresInSeconds
+	}
+	return 0
+}
+
+func (x *WalletAddressResponse) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *WalletAddressResponse) GetIndex() *AddressIndex {
+	if x != nil {
+		return x.Index
+	}
+	return nil
+}
+
+func (x *WalletAddressResponse) GetReference() string {
+	if x != nil && x.Reference != nil {
+		return *x.Reference
+	}
+	return ""
+}
+
+type AddressIndex struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       uint64                 `protobuf:"varint,1,opt,name=account,proto3" json:"account,omitempty"`
+	Address       uint64                 `protobuf:"varint,2,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressIndex) Reset() {
+	*x = AddressIndex{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressIndex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressIndex) ProtoMessage() {}
+
+func (x *AddressIndex) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressIndex.ProtoReflect.Descriptor instead.
+func (*AddressIndex) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *AddressIndex) GetAccount() uint64 {
+	if x != nil {
+		return x.Account
+	}
+	return 0
+}
+
+func (x *AddressIndex) GetAddress() uint64 {
+	if x != nil {
+		return x.Address
+	}
+	return 0
+}
+
+type InvoiceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Parsed alias identifier components.
+	Ticker string `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Alias  string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	Tag    string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Amount requested by the payer in millisatoshis.
+	AmountMsat uint64 `protobuf:"varint,5,opt,name=amount_msat,json=amountMsat,proto3" json:"amount_msat,omitempty"`
+	// SHA-256 of the LNURL-pay metadata; invoices MUST commit to it.
+	DescriptionHash []byte `protobuf:"bytes,6,opt,name=description_hash,json=descriptionHash,proto3" json:"description_hash,omitempty"`
+	// Optional payer comment (LUD-12).
+	Comment string `protobuf:"bytes,7,opt,name=comment,proto3" json:"comment,omitempty"`
+	// Optional alias-local routing hints from config.yml. Wallet services may ignore them.
+	AccountIndex  *uint64 `protobuf:"varint,8,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
+	AccountId     *string `protobuf:"bytes,9,opt,name=account_id,json=accountId,proto3,oneof" json:"account_id,omitempty"`
+	WalletId      *string `protobuf:"bytes,10,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InvoiceRequest) Reset() {
+	*x = InvoiceRequest{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvoiceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvoiceRequest) ProtoMessage() {}
+
+func (x *InvoiceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvoiceRequest.ProtoReflect.Descriptor instead.
+func (*InvoiceRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *InvoiceRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetAmountMsat() uint64 {
+	if x != nil {
+		return x.AmountMsat
+	}
+	return 0
+}
+
+func (x *InvoiceRequest) GetDescriptionHash() []byte {
+	if x != nil {
+		return x.DescriptionHash
+	}
+	return nil
+}
+
+func (x *InvoiceRequest) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetAccountIndex() uint64 {
+	if x != nil && x.AccountIndex != nil {
+		return *x.AccountIndex
+	}
+	return 0
+}
+
+func (x *InvoiceRequest) GetAccountId() string {
+	if x != nil && x.AccountId != nil {
+		return *x.AccountId
+	}
+	return ""
+}
+
+func (x *InvoiceRequest) GetWalletId() string {
+	if x != nil && x.WalletId != nil {
+		return *x.WalletId
+	}
+	return ""
+}
+
+type InvoiceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// BOLT11 payment request.
+	PaymentRequest string `protobuf:"bytes,1,opt,name=payment_request,json=paymentRequest,proto3" json:"payment_request,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *InvoiceResponse) Reset() {
+	*x = InvoiceResponse{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InvoiceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InvoiceResponse) ProtoMessage() {}
+
+func (x *InvoiceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InvoiceResponse.ProtoReflect.Descriptor instead.
+func (*InvoiceResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *InvoiceResponse) GetPaymentRequest() string {
+	if x != nil {
+		return x.PaymentRequest
+	}
+	return ""
+}
+
+type ListPaymentsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Ticker string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	// Only transfers at or above this block height are returned. Unconfirmed
+	// (pool) transfers are always included.
+	MinHeight uint64 `protobuf:"varint,2,opt,name=min_height,json=minHeight,proto3" json:"min_height,omitempty"`
+	// Optional wallet routing hints, as for WalletAddressRequest.
+	WalletId      *string `protobuf:"bytes,3,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	AccountIndex  *uint64 `protobuf:"varint,4,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsRequest) Reset() {
+	*x = ListPaymentsRequest{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsRequest) ProtoMessage() {}
+
+func (x *ListPaymentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsRequest.ProtoReflect.Descriptor instead.
+func (*ListPaymentsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListPaymentsRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetMinHeight() uint64 {
+	if x != nil {
+		return x.MinHeight
+	}
+	return 0
+}
+
+func (x *ListPaymentsRequest) GetWalletId() string {
+	if x != nil && x.WalletId != nil {
+		return *x.WalletId
+	}
+	return ""
+}
+
+func (x *ListPaymentsRequest) GetAccountIndex() uint64 {
+	if x != nil && x.AccountIndex != nil {
+		return *x.AccountIndex
+	}
+	return 0
+}
+
+type Payment struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Txid  string                 `protobuf:"bytes,1,opt,name=txid,proto3" json:"txid,omitempty"`
+	// Receiving address as issued by GetAddress (subaddress, or the primary
+	// address for payment ID based transfers).
+	Address string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// Amount received as a decimal string in the ticker's units.
+	Amount        string `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	Confirmations uint64 `protobuf:"varint,4,opt,name=confirmations,proto3" json:"confirmations,omitempty"`
+	// Block height; 0 while unconfirmed.
+	Height    uint64  `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	PaymentId *string `protobuf:"bytes,6,opt,name=payment_id,json=paymentId,proto3,oneof" json:"payment_id,omitempty"`
+	// Unix seconds when the transfer was seen or mined.
+	Timestamp     int64 `protobuf:"varint,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Payment) Reset() {
+	*x = Payment{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Payment) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Payment) ProtoMessage() {}
+
+func (x *Payment) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Payment.ProtoReflect.Descriptor instead.
+func (*Payment) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Payment) GetTxid() string {
+	if x != nil {
+		return x.Txid
+	}
+	return ""
+}
+
+func (x *Payment) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Payment) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Payment) GetConfirmations() uint64 {
+	if x != nil {
+		return x.Confirmations
+	}
+	return 0
+}
+
+func (x *Payment) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Payment) GetPaymentId() string {
+	if x != nil && x.PaymentId != nil {
+		return *x.PaymentId
+	}
+	return ""
+}
+
+func (x *Payment) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+type ListPaymentsResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Payments []*Payment             `protobuf:"bytes,1,rep,name=payments,proto3" json:"payments,omitempty"`
+	// Current wallet height, used as the next min_height window.
+	Height        uint64 `protobuf:"varint,2,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPaymentsResponse) Reset() {
+	*x = ListPaymentsResponse{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPaymentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPaymentsResponse) ProtoMessage() {}
+
+func (x *ListPaymentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPaymentsResponse.ProtoReflect.Descriptor instead.
+func (*ListPaymentsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListPaymentsResponse) GetPayments() []*Payment {
+	if x != nil {
+		return x.Payments
+	}
+	return nil
+}
+
+func (x *ListPaymentsResponse) GetHeight() uint64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type AddressUsedRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Ticker  string                 `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Address string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	// Optional wallet routing hint, as for WalletAddressRequest.
+	WalletId      *string `protobuf:"bytes,3,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressUsedRequest) Reset() {
+	*x = AddressUsedRequest{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressUsedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressUsedRequest) ProtoMessage() {}
+
+func (x *AddressUsedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressUsedRequest.ProtoReflect.Descriptor instead.
+func (*AddressUsedRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *AddressUsedRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *AddressUsedRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *AddressUsedRequest) GetWalletId() string {
+	if x != nil && x.WalletId != nil {
+		return *x.WalletId
+	}
+	return ""
+}
+
+type AddressUsedResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// True once any transfer to the address was seen, including unconfirmed.
+	Used          bool `protobuf:"varint,1,opt,name=used,proto3" json:"used,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddressUsedResponse) Reset() {
+	*x = AddressUsedResponse{}
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddressUsedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddressUsedResponse) ProtoMessage() {}
+
+func (x *AddressUsedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddressUsedResponse.ProtoReflect.Descriptor instead.
+func (*AddressUsedResponse) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AddressUsedResponse) GetUsed() bool {
+	if x != nil {
+		return x.Used
+	}
+	return false
+}
+
 type HealthRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -167,7 +797,7 @@ type HealthRequest struct {
 
 func (x *HealthRequest) Reset() {
 	*x = HealthRequest{}
-	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[2]
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -179,7 +809,7 @@ func (x *HealthRequest) String() string {
 func (*HealthRequest) ProtoMessage() {}
 
 func (x *HealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[2]
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -192,7 +822,7 @@ func (x *HealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
 func (*HealthRequest) Descriptor() ([]byte, []int) {
-	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{2}
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{10}
 }
 
 type HealthResponse struct {
@@ -205,7 +835,7 @@ type HealthResponse struct {
 
 func (x *HealthResponse) Reset() {
 	*x = HealthResponse{}
-	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[3]
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -217,7 +847,7 @@ func (x *HealthResponse) String() string {
 func (*HealthResponse) ProtoMessage() {}
 
 func (x *HealthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[3]
+	mi := &file_proto_cryptalias_v1_wallet_service_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -230,7 +860,7 @@ func (x *HealthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
 func (*HealthResponse) Descriptor() ([]byte, []int) {
-	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{3}
+	return file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *HealthResponse) GetOk() bool {
@@ -251,7 +881,7 @@ var File_proto_cryptalias_v1_wallet_service_proto protoreflect.FileDescriptor
 
 const file_proto_cryptalias_v1_wallet_service_proto_rawDesc = "" +
 	"\n" +
-	"(proto/cryptalias/v1/wallet_service.proto\x12\rcryptalias.v1\"\x8d\x02\n" +
+	"(proto/cryptalias/v1/wallet_service.proto\x12\rcryptalias.v1\"\xee\x02\n" +
 	"\x14WalletAddressRequest\x12\x16\n" +
 	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
 	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x10\n" +
@@ -260,21 +890,99 @@ const file_proto_cryptalias_v1_wallet_service_proto_rawDesc = "" +
 	"\raccount_index\x18\x05 \x01(\x04H\x00R\faccountIndex\x88\x01\x01\x12\"\n" +
 	"\n" +
 	"account_id\x18\x06 \x01(\tH\x01R\taccountId\x88\x01\x01\x12 \n" +
-	"\twallet_id\x18\a \x01(\tH\x02R\bwalletId\x88\x01\x01B\x10\n" +
+	"\twallet_id\x18\a \x01(\tH\x02R\bwalletId\x88\x01\x01\x12&\n" +
+	"\faddress_mode\x18\b \x01(\tH\x03R\vaddressMode\x88\x01\x01\x12\x1b\n" +
+	"\x06amount\x18\t \x01(\tH\x04R\x06amount\x88\x01\x01B\x10\n" +
 	"\x0e_account_indexB\r\n" +
 	"\v_account_idB\f\n" +
 	"\n" +
-	"_wallet_id\"1\n" +
+	"_wallet_idB\x0f\n" +
+	"\r_address_modeB\t\n" +
+	"\a_amount\"\x8a\x04\n" +
 	"\x15WalletAddressResponse\x12\x18\n" +
-	"\aaddress\x18\x01 \x01(\tR\aaddress\"\x0f\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x17\n" +
+	"\x04memo\x18\x02 \x01(\tH\x00R\x04memo\x88\x01\x01\x12,\n" +
+	"\x0fdestination_tag\x18\x03 \x01(\rH\x01R\x0edestinationTag\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"payment_id\x18\x04 \x01(\tH\x02R\tpaymentId\x88\x01\x01\x121\n" +
+	"\x12expires_in_seconds\x18\x05 \x01(\rH\x03R\x10expiresInSeconds\x88\x01\x01\x12H\n" +
+	"\x06labels\x18\x06 \x03(\v20.cryptalias.v1.WalletAddressResponse.LabelsEntryR\x06labels\x126\n" +
+	"\x05index\x18\a \x01(\v2\x1b.cryptalias.v1.AddressIndexH\x04R\x05index\x88\x01\x01\x12!\n" +
+	"\treference\x18\b \x01(\tH\x05R\treference\x88\x01\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\a\n" +
+	"\x05_memoB\x12\n" +
+	"\x10_destination_tagB\r\n" +
+	"\v_payment_idB\x15\n" +
+	"\x13_expires_in_secondsB\b\n" +
+	"\x06_indexB\f\n" +
+	"\n" +
+	"_reference\"B\n" +
+	"\fAddressIndex\x12\x18\n" +
+	"\aaccount\x18\x01 \x01(\x04R\aaccount\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\x04R\aaddress\"\xed\x02\n" +
+	"\x0eInvoiceRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x12\x1f\n" +
+	"\vamount_msat\x18\x05 \x01(\x04R\n" +
+	"amountMsat\x12)\n" +
+	"\x10description_hash\x18\x06 \x01(\fR\x0fdescriptionHash\x12\x18\n" +
+	"\acomment\x18\a \x01(\tR\acomment\x12(\n" +
+	"\raccount_index\x18\b \x01(\x04H\x00R\faccountIndex\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"account_id\x18\t \x01(\tH\x01R\taccountId\x88\x01\x01\x12 \n" +
+	"\twallet_id\x18\n" +
+	" \x01(\tH\x02R\bwalletId\x88\x01\x01B\x10\n" +
+	"\x0e_account_indexB\r\n" +
+	"\v_account_idB\f\n" +
+	"\n" +
+	"_wallet_id\":\n" +
+	"\x0fInvoiceResponse\x12'\n" +
+	"\x0fpayment_request\x18\x01 \x01(\tR\x0epaymentRequest\"\xb8\x01\n" +
+	"\x13ListPaymentsRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x1d\n" +
+	"\n" +
+	"min_height\x18\x02 \x01(\x04R\tminHeight\x12 \n" +
+	"\twallet_id\x18\x03 \x01(\tH\x00R\bwalletId\x88\x01\x01\x12(\n" +
+	"\raccount_index\x18\x04 \x01(\x04H\x01R\faccountIndex\x88\x01\x01B\f\n" +
+	"\n" +
+	"_wallet_idB\x10\n" +
+	"\x0e_account_index\"\xde\x01\n" +
+	"\aPayment\x12\x12\n" +
+	"\x04txid\x18\x01 \x01(\tR\x04txid\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x12$\n" +
+	"\rconfirmations\x18\x04 \x01(\x04R\rconfirmations\x12\x16\n" +
+	"\x06height\x18\x05 \x01(\x04R\x06height\x12\"\n" +
+	"\n" +
+	"payment_id\x18\x06 \x01(\tH\x00R\tpaymentId\x88\x01\x01\x12\x1c\n" +
+	"\ttimestamp\x18\a \x01(\x03R\ttimestampB\r\n" +
+	"\v_payment_id\"b\n" +
+	"\x14ListPaymentsResponse\x122\n" +
+	"\bpayments\x18\x01 \x03(\v2\x16.cryptalias.v1.PaymentR\bpayments\x12\x16\n" +
+	"\x06height\x18\x02 \x01(\x04R\x06height\"v\n" +
+	"\x12AddressUsedRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12 \n" +
+	"\twallet_id\x18\x03 \x01(\tH\x00R\bwalletId\x88\x01\x01B\f\n" +
+	"\n" +
+	"_wallet_id\")\n" +
+	"\x13AddressUsedResponse\x12\x12\n" +
+	"\x04used\x18\x01 \x01(\bR\x04used\"\x0f\n" +
 	"\rHealthRequest\":\n" +
 	"\x0eHealthResponse\x12\x0e\n" +
 	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\xaf\x01\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2\xb0\x03\n" +
 	"\rWalletService\x12W\n" +
 	"\n" +
 	"GetAddress\x12#.cryptalias.v1.WalletAddressRequest\x1a$.cryptalias.v1.WalletAddressResponse\x12E\n" +
-	"\x06Health\x12\x1c.cryptalias.v1.HealthRequest\x1a\x1d.cryptalias.v1.HealthResponseB?Z=github.com/kaigoh/cryptalias/proto/cryptalias/v1;cryptaliasv1b\x06proto3"
+	"\x06Health\x12\x1c.cryptalias.v1.HealthRequest\x1a\x1d.cryptalias.v1.HealthResponse\x12N\n" +
+	"\rCreateInvoice\x12\x1d.cryptalias.v1.InvoiceRequest\x1a\x1e.cryptalias.v1.InvoiceResponse\x12W\n" +
+	"\fListPayments\x12\".cryptalias.v1.ListPaymentsRequest\x1a#.cryptalias.v1.ListPaymentsResponse\x12V\n" +
+	"\rIsAddressUsed\x12!.cryptalias.v1.AddressUsedRequest\x1a\".cryptalias.v1.AddressUsedResponseB?Z=github.com/kaigoh/cryptalias/proto/cryptalias/v1;cryptaliasv1b\x06proto3"
 
 var (
 	file_proto_cryptalias_v1_wallet_service_proto_rawDescOnce sync.Once
@@ -288,23 +996,41 @@ func file_proto_cryptalias_v1_wallet_service_proto_rawDescGZIP() []byte {
 	return file_proto_cryptalias_v1_wallet_service_proto_rawDescData
 }
 
-var file_proto_cryptalias_v1_wallet_service_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_proto_cryptalias_v1_wallet_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_proto_cryptalias_v1_wallet_service_proto_goTypes = []any{
 	(*WalletAddressRequest)(nil),  // 0: cryptalias.v1.WalletAddressRequest
 	(*WalletAddressResponse)(nil), // 1: cryptalias.v1.WalletAddressResponse
-	(*HealthRequest)(nil),         // 2: cryptalias.v1.HealthRequest
-	(*HealthResponse)(nil),        // 3: cryptalias.v1.HealthResponse
+	(*AddressIndex)(nil),          // 2: cryptalias.v1.AddressIndex
+	(*InvoiceRequest)(nil),        // 3: cryptalias.v1.InvoiceRequest
+	(*InvoiceResponse)(nil),       // 4: cryptalias.v1.InvoiceResponse
+	(*ListPaymentsRequest)(nil),   // 5: cryptalias.v1.ListPaymentsRequest
+	(*Payment)(nil),               // 6: cryptalias.v1.Payment
+	(*ListPaymentsResponse)(nil),  // 7: cryptalias.v1.ListPaymentsResponse
+	(*AddressUsedRequest)(nil),    // 8: cryptalias.v1.AddressUsedRequest
+	(*AddressUsedResponse)(nil),   // 9: cryptalias.v1.AddressUsedResponse
+	(*HealthRequest)(nil),         // 10: cryptalias.v1.HealthRequest
+	(*HealthResponse)(nil),        // 11: cryptalias.v1.HealthResponse
+	nil,                           // 12: cryptalias.v1.WalletAddressResponse.LabelsEntry
 }
 var file_proto_cryptalias_v1_wallet_service_proto_depIdxs = []int32{
-	0, // 0: cryptalias.v1.WalletService.GetAddress:input_type -> cryptalias.v1.WalletAddressRequest
-	2, // 1: cryptalias.v1.WalletService.Health:input_type -> cryptalias.v1.HealthRequest
-	1, // 2: cryptalias.v1.WalletService.GetAddress:output_type -> cryptalias.v1.WalletAddressResponse
-	3, // 3: cryptalias.v1.WalletService.Health:output_type -> cryptalias.v1.HealthResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	12, // 0: cryptalias.v1.WalletAddressResponse.labels:type_name -> cryptalias.v1.WalletAddressResponse.LabelsEntry
+	2,  // 1: cryptalias.v1.WalletAddressResponse.index:type_name -> cryptalias.v1.AddressIndex
+	6,  // 2: cryptalias.v1.ListPaymentsResponse.payments:type_name -> cryptalias.v1.Payment
+	0,  // 3: cryptalias.v1.WalletService.GetAddress:input_type -> cryptalias.v1.WalletAddressRequest
+	10, // 4: cryptalias.v1.WalletService.Health:input_type -> cryptalias.v1.HealthRequest
+	3,  // 5: cryptalias.v1.WalletService.CreateInvoice:input_type -> cryptalias.v1.InvoiceRequest
+	5,  // 6: cryptalias.v1.WalletService.ListPayments:input_type -> cryptalias.v1.ListPaymentsRequest
+	8,  // 7: cryptalias.v1.WalletService.IsAddressUsed:input_type -> cryptalias.v1.AddressUsedRequest
+	1,  // 8: cryptalias.v1.WalletService.GetAddress:output_type -> cryptalias.v1.WalletAddressResponse
+	11, // 9: cryptalias.v1.WalletService.Health:output_type -> cryptalias.v1.HealthResponse
+	4,  // 10: cryptalias.v1.WalletService.CreateInvoice:output_type -> cryptalias.v1.InvoiceResponse
+	7,  // 11: cryptalias.v1.WalletService.ListPayments:output_type -> cryptalias.v1.ListPaymentsResponse
+	9,  // 12: cryptalias.v1.WalletService.IsAddressUsed:output_type -> cryptalias.v1.AddressUsedResponse
+	8,  // [8:13] is the sub-list for method output_type
+	3,  // [3:8] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_proto_cryptalias_v1_wallet_service_proto_init() }
@@ -313,13 +1039,18 @@ func file_proto_cryptalias_v1_wallet_service_proto_init() {
 		return
 	}
 	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[0].OneofWrappers = []any{}
+	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[1].OneofWrappers = []any{}
+	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[3].OneofWrappers = []any{}
+	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[5].OneofWrappers = []any{}
+	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[6].OneofWrappers = []any{}
+	file_proto_cryptalias_v1_wallet_service_proto_msgTypes[8].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_cryptalias_v1_wallet_service_proto_rawDesc), len(file_proto_cryptalias_v1_wallet_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
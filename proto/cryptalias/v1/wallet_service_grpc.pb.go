@@ -19,8 +19,11 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	WalletService_GetAddress_FullMethodName = "/cryptalias.v1.WalletService/GetAddress"
-	WalletService_Health_FullMethodName     = "/cryptalias.v1.WalletService/Health"
+	WalletService_GetAddress_FullMethodName    = "/cryptalias.v1.WalletService/GetAddress"
+	WalletService_Health_FullMethodName        = "/cryptalias.v1.WalletService/Health"
+	WalletService_CreateInvoice_FullMethodName = "/cryptalias.v1.WalletService/CreateInvoice"
+	WalletService_ListPayments_FullMethodName  = "/cryptalias.v1.WalletService/ListPayments"
+	WalletService_IsAddressUsed_FullMethodName = "/cryptalias.v1.WalletService/IsAddressUsed"
 )
 
 // WalletServiceClient is the client API for WalletService service.
@@ -29,6 +32,15 @@ const (
 type WalletServiceClient interface {
 	GetAddress(ctx context.Context, in *WalletAddressRequest, opts ...grpc.CallOption) (*WalletAddressResponse, error)
 	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	// CreateInvoice issues a Lightning invoice for LNURL-pay / Lightning Address.
+	// Wallet services without Lightning support may leave it unimplemented.
+	CreateInvoice(ctx context.Context, in *InvoiceRequest, opts ...grpc.CallOption) (*InvoiceResponse, error)
+	// ListPayments reports incoming transfers so Cryptalias can tell when an
+	// address it issued was paid. Services may leave it unimplemented.
+	ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error)
+	// IsAddressUsed reports whether an address has received funds, so it is
+	// never handed out again. Services may leave it unimplemented.
+	IsAddressUsed(ctx context.Context, in *AddressUsedRequest, opts ...grpc.CallOption) (*AddressUsedResponse, error)
 }
 
 type walletServiceClient struct {
@@ -59,12 +71,51 @@ func (c *walletServiceClient) Health(ctx context.Context, in *HealthRequest, opt
 	return out, nil
 }
 
+func (c *walletServiceClient) CreateInvoice(ctx context.Context, in *InvoiceRequest, opts ...grpc.CallOption) (*InvoiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InvoiceResponse)
+	err := c.cc.Invoke(ctx, WalletService_CreateInvoice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ListPayments(ctx context.Context, in *ListPaymentsRequest, opts ...grpc.CallOption) (*ListPaymentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPaymentsResponse)
+	err := c.cc.Invoke(ctx, WalletService_ListPayments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) IsAddressUsed(ctx context.Context, in *AddressUsedRequest, opts ...grpc.CallOption) (*AddressUsedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddressUsedResponse)
+	err := c.cc.Invoke(ctx, WalletService_IsAddressUsed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WalletServiceServer is the server API for WalletService service.
 // All implementations must embed UnimplementedWalletServiceServer
 // for forward compatibility.
 type WalletServiceServer interface {
 	GetAddress(context.Context, *WalletAddressRequest) (*WalletAddressResponse, error)
 	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	// CreateInvoice issues a Lightning invoice for LNURL-pay / Lightning Address.
+	// Wallet services without Lightning support may leave it unimplemented.
+	CreateInvoice(context.Context, *InvoiceRequest) (*InvoiceResponse, error)
+	// ListPayments reports incoming transfers so Cryptalias can tell when an
+	// address it issued was paid. Services may leave it unimplemented.
+	ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error)
+	// IsAddressUsed reports whether an address has received funds, so it is
+	// never handed out again. Services may leave it unimplemented.
+	IsAddressUsed(context.Context, *AddressUsedRequest) (*AddressUsedResponse, error)
 	mustEmbedUnimplementedWalletServiceServer()
 }
 
@@ -81,6 +132,15 @@ func (UnimplementedWalletServiceServer) GetAddress(context.Context, *WalletAddre
 func (UnimplementedWalletServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
 }
+func (UnimplementedWalletServiceServer) CreateInvoice(context.Context, *InvoiceRequest) (*InvoiceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateInvoice not implemented")
+}
+func (UnimplementedWalletServiceServer) ListPayments(context.Context, *ListPaymentsRequest) (*ListPaymentsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListPayments not implemented")
+}
+func (UnimplementedWalletServiceServer) IsAddressUsed(context.Context, *AddressUsedRequest) (*AddressUsedResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method IsAddressUsed not implemented")
+}
 func (UnimplementedWalletServiceServer) mustEmbedUnimplementedWalletServiceServer() {}
 func (UnimplementedWalletServiceServer) testEmbeddedByValue()                       {}
 
@@ -138,6 +198,60 @@ func _WalletService_Health_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WalletService_CreateInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_CreateInvoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, req.(*InvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_ListPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ListPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_ListPayments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ListPayments(ctx, req.(*ListPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_IsAddressUsed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddressUsedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).IsAddressUsed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_IsAddressUsed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).IsAddressUsed(ctx, req.(*AddressUsedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // WalletService_ServiceDesc is the grpc.ServiceDesc for WalletService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,6 +267,18 @@ var WalletService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Health",
 			Handler:    _WalletService_Health_Handler,
 		},
+		{
+			MethodName: "CreateInvoice",
+			Handler:    _WalletService_CreateInvoice_Handler,
+		},
+		{
+			MethodName: "ListPayments",
+			Handler:    _WalletService_ListPayments_Handler,
+		},
+		{
+			MethodName: "IsAddressUsed",
+			Handler:    _WalletService_IsAddressUsed_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/cryptalias/v1/wallet_service.proto",
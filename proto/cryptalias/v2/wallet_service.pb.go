@@ -0,0 +1,352 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.1
+// source: proto/cryptalias/v2/wallet_service.proto
+
+package cryptaliasv2
+
+import (
+	v1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WalletAddressRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Parsed alias identifier components.
+	Ticker string `protobuf:"bytes,1,opt,name=ticker,proto3" json:"ticker,omitempty"`
+	Alias  string `protobuf:"bytes,2,opt,name=alias,proto3" json:"alias,omitempty"`
+	Tag    string `protobuf:"bytes,3,opt,name=tag,proto3" json:"tag,omitempty"`
+	Domain string `protobuf:"bytes,4,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Optional alias-local routing hints from config.yml. Wallet services may ignore them.
+	AccountIndex *uint64 `protobuf:"varint,5,opt,name=account_index,json=accountIndex,proto3,oneof" json:"account_index,omitempty"`
+	AccountId    *string `protobuf:"bytes,6,opt,name=account_id,json=accountId,proto3,oneof" json:"account_id,omitempty"`
+	WalletId     *string `protobuf:"bytes,7,opt,name=wallet_id,json=walletId,proto3,oneof" json:"wallet_id,omitempty"`
+	// Optional address mode hint, e.g. "subaddress" or "integrated" for Monero.
+	AddressMode *string `protobuf:"bytes,8,opt,name=address_mode,json=addressMode,proto3,oneof" json:"address_mode,omitempty"`
+	// Optional amount requested by the payer, as a decimal string in the
+	// ticker's units (e.g. "0.0015").
+	Amount *string `protobuf:"bytes,9,opt,name=amount,proto3,oneof" json:"amount,omitempty"`
+	// Unique ID of the address request, for deduplication and log correlation.
+	// Retries and failover reuse it; asking again after a used address gets a
+	// new one.
+	RequestId string `protobuf:"bytes,10,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// Hex HMAC-SHA256 of the resolving client's identity under a per-instance
+	// salt. Stable for a client, but does not reveal its IP address. Empty when
+	// the client is unknown.
+	ClientKeyHash string `protobuf:"bytes,11,opt,name=client_key_hash,json=clientKeyHash,proto3" json:"client_key_hash,omitempty"`
+	// Optional memo requested by the payer with the resolve memo parameter.
+	Memo *string `protobuf:"bytes,12,opt,name=memo,proto3,oneof" json:"memo,omitempty"`
+	// How Cryptalias will cache the returned address.
+	CachePolicy   *CachePolicy `protobuf:"bytes,13,opt,name=cache_policy,json=cachePolicy,proto3" json:"cache_policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WalletAddressRequest) Reset() {
+	*x = WalletAddressRequest{}
+	mi := &file_proto_cryptalias_v2_wallet_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WalletAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WalletAddressRequest) ProtoMessage() {}
+
+func (x *WalletAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v2_wallet_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WalletAddressRequest.ProtoReflect.Descriptor instead.
+func (*WalletAddressRequest) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v2_wallet_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WalletAddressRequest) GetTicker() string {
+	if x != nil {
+		return x.Ticker
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetAlias() string {
+	if x != nil {
+		return x.Alias
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetAccountIndex() uint64 {
+	if x != nil && x.AccountIndex != nil {
+		return *x.AccountIndex
+	}
+	return 0
+}
+
+func (x *WalletAddressRequest) GetAccountId() string {
+	if x != nil && x.AccountId != nil {
+		return *x.AccountId
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetWalletId() string {
+	if x != nil && x.WalletId != nil {
+		return *x.WalletId
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetAddressMode() string {
+	if x != nil && x.AddressMode != nil {
+		return *x.AddressMode
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetAmount() string {
+	if x != nil && x.Amount != nil {
+		return *x.Amount
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetClientKeyHash() string {
+	if x != nil {
+		return x.ClientKeyHash
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetMemo() string {
+	if x != nil && x.Memo != nil {
+		return *x.Memo
+	}
+	return ""
+}
+
+func (x *WalletAddressRequest) GetCachePolicy() *CachePolicy {
+	if x != nil {
+		return x.CachePolicy
+	}
+	return nil
+}
+
+type CachePolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Seconds the address is reused for the same client.
+	TtlSeconds uint32 `protobuf:"varint,1,opt,name=ttl_seconds,json=ttlSeconds,proto3" json:"ttl_seconds,omitempty"`
+	// Whether the address is dropped once it received funds.
+	RetireUsed bool `protobuf:"varint,2,opt,name=retire_used,json=retireUsed,proto3" json:"retire_used,omitempty"`
+	// Whether unused addresses may later be handed to other clients.
+	ReuseUnused   bool `protobuf:"varint,3,opt,name=reuse_unused,json=reuseUnused,proto3" json:"reuse_unused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CachePolicy) Reset() {
+	*x = CachePolicy{}
+	mi := &file_proto_cryptalias_v2_wallet_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CachePolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CachePolicy) ProtoMessage() {}
+
+func (x *CachePolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_cryptalias_v2_wallet_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CachePolicy.ProtoReflect.Descriptor instead.
+func (*CachePolicy) Descriptor() ([]byte, []int) {
+	return file_proto_cryptalias_v2_wallet_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CachePolicy) GetTtlSeconds() uint32 {
+	if x != nil {
+		return x.TtlSeconds
+	}
+	return 0
+}
+
+func (x *CachePolicy) GetRetireUsed() bool {
+	if x != nil {
+		return x.RetireUsed
+	}
+	return false
+}
+
+func (x *CachePolicy) GetReuseUnused() bool {
+	if x != nil {
+		return x.ReuseUnused
+	}
+	return false
+}
+
+var File_proto_cryptalias_v2_wallet_service_proto protoreflect.FileDescriptor
+
+const file_proto_cryptalias_v2_wallet_service_proto_rawDesc = "" +
+	"\n" +
+	"(proto/cryptalias/v2/wallet_service.proto\x12\rcryptalias.v2\x1a(proto/cryptalias/v1/wallet_service.proto\"\x96\x04\n" +
+	"\x14WalletAddressRequest\x12\x16\n" +
+	"\x06ticker\x18\x01 \x01(\tR\x06ticker\x12\x14\n" +
+	"\x05alias\x18\x02 \x01(\tR\x05alias\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x16\n" +
+	"\x06domain\x18\x04 \x01(\tR\x06domain\x12(\n" +
+	"\raccount_index\x18\x05 \x01(\x04H\x00R\faccountIndex\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"account_id\x18\x06 \x01(\tH\x01R\taccountId\x88\x01\x01\x12 \n" +
+	"\twallet_id\x18\a \x01(\tH\x02R\bwalletId\x88\x01\x01\x12&\n" +
+	"\faddress_mode\x18\b \x01(\tH\x03R\vaddressMode\x88\x01\x01\x12\x1b\n" +
+	"\x06amount\x18\t \x01(\tH\x04R\x06amount\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\n" +
+	" \x01(\tR\trequestId\x12&\n" +
+	"\x0fclient_key_hash\x18\v \x01(\tR\rclientKeyHash\x12\x17\n" +
+	"\x04memo\x18\f \x01(\tH\x05R\x04memo\x88\x01\x01\x12=\n" +
+	"\fcache_policy\x18\r \x01(\v2\x1a.cryptalias.v2.CachePolicyR\vcachePolicyB\x10\n" +
+	"\x0e_account_indexB\r\n" +
+	"\v_account_idB\f\n" +
+	"\n" +
+	"_wallet_idB\x0f\n" +
+	"\r_address_modeB\t\n" +
+	"\a_amountB\a\n" +
+	"\x05_memo\"r\n" +
+	"\vCachePolicy\x12\x1f\n" +
+	"\vttl_seconds\x18\x01 \x01(\rR\n" +
+	"ttlSeconds\x12\x1f\n" +
+	"\vretire_used\x18\x02 \x01(\bR\n" +
+	"retireUsed\x12!\n" +
+	"\freuse_unused\x18\x03 \x01(\bR\vreuseUnused2\xb0\x03\n" +
+	"\rWalletService\x12W\n" +
+	"\n" +
+	"GetAddress\x12#.cryptalias.v2.WalletAddressRequest\x1a$.cryptalias.v1.WalletAddressResponse\x12E\n" +
+	"\x06Health\x12\x1c.cryptalias.v1.HealthRequest\x1a\x1d.cryptalias.v1.HealthResponse\x12N\n" +
+	"\rCreateInvoice\x12\x1d.cryptalias.v1.InvoiceRequest\x1a\x1e.cryptalias.v1.InvoiceResponse\x12W\n" +
+	"\fListPayments\x12\".cryptalias.v1.ListPaymentsRequest\x1a#.cryptalias.v1.ListPaymentsResponse\x12V\n" +
+	"\rIsAddressUsed\x12!.cryptalias.v1.AddressUsedRequest\x1a\".cryptalias.v1.AddressUsedResponseB?Z=github.com/kaigoh/cryptalias/proto/cryptalias/v2;cryptaliasv2b\x06proto3"
+
+var (
+	file_proto_cryptalias_v2_wallet_service_proto_rawDescOnce sync.Once
+	file_proto_cryptalias_v2_wallet_service_proto_rawDescData []byte
+)
+
+func file_proto_cryptalias_v2_wallet_service_proto_rawDescGZIP() []byte {
+	file_proto_cryptalias_v2_wallet_service_proto_rawDescOnce.Do(func() {
+		file_proto_cryptalias_v2_wallet_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_cryptalias_v2_wallet_service_proto_rawDesc), len(file_proto_cryptalias_v2_wallet_service_proto_rawDesc)))
+	})
+	return file_proto_cryptalias_v2_wallet_service_proto_rawDescData
+}
+
+var file_proto_cryptalias_v2_wallet_service_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_cryptalias_v2_wallet_service_proto_goTypes = []any{
+	(*WalletAddressRequest)(nil),     // 0: cryptalias.v2.WalletAddressRequest
+	(*CachePolicy)(nil),              // 1: cryptalias.v2.CachePolicy
+	(*v1.HealthRequest)(nil),         // 2: cryptalias.v1.HealthRequest
+	(*v1.InvoiceRequest)(nil),        // 3: cryptalias.v1.InvoiceRequest
+	(*v1.ListPaymentsRequest)(nil),   // 4: cryptalias.v1.ListPaymentsRequest
+	(*v1.AddressUsedRequest)(nil),    // 5: cryptalias.v1.AddressUsedRequest
+	(*v1.WalletAddressResponse)(nil), // 6: cryptalias.v1.WalletAddressResponse
+	(*v1.HealthResponse)(nil),        // 7: cryptalias.v1.HealthResponse
+	(*v1.InvoiceResponse)(nil),       // 8: cryptalias.v1.InvoiceResponse
+	(*v1.ListPaymentsResponse)(nil),  // 9: cryptalias.v1.ListPaymentsResponse
+	(*v1.AddressUsedResponse)(nil),   // 10: cryptalias.v1.AddressUsedResponse
+}
+var file_proto_cryptalias_v2_wallet_service_proto_depIdxs = []int32{
+	1,  // 0: cryptalias.v2.WalletAddressRequest.cache_policy:type_name -> cryptalias.v2.CachePolicy
+	0,  // 1: cryptalias.v2.WalletService.GetAddress:input_type -> cryptalias.v2.WalletAddressRequest
+	2,  // 2: cryptalias.v2.WalletService.Health:input_type -> cryptalias.v1.HealthRequest
+	3,  // 3: cryptalias.v2.WalletService.CreateInvoice:input_type -> cryptalias.v1.InvoiceRequest
+	4,  // 4: cryptalias.v2.WalletService.ListPayments:input_type -> cryptalias.v1.ListPaymentsRequest
+	5,  // 5: cryptalias.v2.WalletService.IsAddressUsed:input_type -> cryptalias.v1.AddressUsedRequest
+	6,  // 6: cryptalias.v2.WalletService.GetAddress:output_type -> cryptalias.v1.WalletAddressResponse
+	7,  // 7: cryptalias.v2.WalletService.Health:output_type -> cryptalias.v1.HealthResponse
+	8,  // 8: cryptalias.v2.WalletService.CreateInvoice:output_type -> cryptalias.v1.InvoiceResponse
+	9,  // 9: cryptalias.v2.WalletService.ListPayments:output_type -> cryptalias.v1.ListPaymentsResponse
+	10, // 10: cryptalias.v2.WalletService.IsAddressUsed:output_type -> cryptalias.v1.AddressUsedResponse
+	6,  // [6:11] is the sub-list for method output_type
+	1,  // [1:6] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_cryptalias_v2_wallet_service_proto_init() }
+func file_proto_cryptalias_v2_wallet_service_proto_init() {
+	if File_proto_cryptalias_v2_wallet_service_proto != nil {
+		return
+	}
+	file_proto_cryptalias_v2_wallet_service_proto_msgTypes[0].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_cryptalias_v2_wallet_service_proto_rawDesc), len(file_proto_cryptalias_v2_wallet_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_cryptalias_v2_wallet_service_proto_goTypes,
+		DependencyIndexes: file_proto_cryptalias_v2_wallet_service_proto_depIdxs,
+		MessageInfos:      file_proto_cryptalias_v2_wallet_service_proto_msgTypes,
+	}.Build()
+	File_proto_cryptalias_v2_wallet_service_proto = out.File
+	file_proto_cryptalias_v2_wallet_service_proto_goTypes = nil
+	file_proto_cryptalias_v2_wallet_service_proto_depIdxs = nil
+}
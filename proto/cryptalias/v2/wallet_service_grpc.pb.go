@@ -0,0 +1,282 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.1
+// source: proto/cryptalias/v2/wallet_service.proto
+
+package cryptaliasv2
+
+import (
+	context "context"
+	v1 "github.com/kaigoh/cryptalias/proto/cryptalias/v1"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WalletService_GetAddress_FullMethodName    = "/cryptalias.v2.WalletService/GetAddress"
+	WalletService_Health_FullMethodName        = "/cryptalias.v2.WalletService/Health"
+	WalletService_CreateInvoice_FullMethodName = "/cryptalias.v2.WalletService/CreateInvoice"
+	WalletService_ListPayments_FullMethodName  = "/cryptalias.v2.WalletService/ListPayments"
+	WalletService_IsAddressUsed_FullMethodName = "/cryptalias.v2.WalletService/IsAddressUsed"
+)
+
+// WalletServiceClient is the client API for WalletService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// WalletService v2 adds request context to GetAddress. The other RPCs and all
+// responses are unchanged from v1. Cryptalias negotiates the version per
+// endpoint by calling v2 Health first, so v2 services must implement Health.
+type WalletServiceClient interface {
+	GetAddress(ctx context.Context, in *WalletAddressRequest, opts ...grpc.CallOption) (*v1.WalletAddressResponse, error)
+	Health(ctx context.Context, in *v1.HealthRequest, opts ...grpc.CallOption) (*v1.HealthResponse, error)
+	CreateInvoice(ctx context.Context, in *v1.InvoiceRequest, opts ...grpc.CallOption) (*v1.InvoiceResponse, error)
+	ListPayments(ctx context.Context, in *v1.ListPaymentsRequest, opts ...grpc.CallOption) (*v1.ListPaymentsResponse, error)
+	IsAddressUsed(ctx context.Context, in *v1.AddressUsedRequest, opts ...grpc.CallOption) (*v1.AddressUsedResponse, error)
+}
+
+type walletServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWalletServiceClient(cc grpc.ClientConnInterface) WalletServiceClient {
+	return &walletServiceClient{cc}
+}
+
+func (c *walletServiceClient) GetAddress(ctx context.Context, in *WalletAddressRequest, opts ...grpc.CallOption) (*v1.WalletAddressResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.WalletAddressResponse)
+	err := c.cc.Invoke(ctx, WalletService_GetAddress_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) Health(ctx context.Context, in *v1.HealthRequest, opts ...grpc.CallOption) (*v1.HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.HealthResponse)
+	err := c.cc.Invoke(ctx, WalletService_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) CreateInvoice(ctx context.Context, in *v1.InvoiceRequest, opts ...grpc.CallOption) (*v1.InvoiceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.InvoiceResponse)
+	err := c.cc.Invoke(ctx, WalletService_CreateInvoice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) ListPayments(ctx context.Context, in *v1.ListPaymentsRequest, opts ...grpc.CallOption) (*v1.ListPaymentsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.ListPaymentsResponse)
+	err := c.cc.Invoke(ctx, WalletService_ListPayments_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *walletServiceClient) IsAddressUsed(ctx context.Context, in *v1.AddressUsedRequest, opts ...grpc.CallOption) (*v1.AddressUsedResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(v1.AddressUsedResponse)
+	err := c.cc.Invoke(ctx, WalletService_IsAddressUsed_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WalletServiceServer is the server API for WalletService service.
+// All implementations must embed UnimplementedWalletServiceServer
+// for forward compatibility.
+//
+// WalletService v2 adds request context to GetAddress. The other RPCs and all
+// responses are unchanged from v1. Cryptalias negotiates the version per
+// endpoint by calling v2 Health first, so v2 services must implement Health.
+type WalletServiceServer interface {
+	GetAddress(context.Context, *WalletAddressRequest) (*v1.WalletAddressResponse, error)
+	Health(context.Context, *v1.HealthRequest) (*v1.HealthResponse, error)
+	CreateInvoice(context.Context, *v1.InvoiceRequest) (*v1.InvoiceResponse, error)
+	ListPayments(context.Context, *v1.ListPaymentsRequest) (*v1.ListPaymentsResponse, error)
+	IsAddressUsed(context.Context, *v1.AddressUsedRequest) (*v1.AddressUsedResponse, error)
+	mustEmbedUnimplementedWalletServiceServer()
+}
+
+// UnimplementedWalletServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWalletServiceServer struct{}
+
+func (UnimplementedWalletServiceServer) GetAddress(context.Context, *WalletAddressRequest) (*v1.WalletAddressResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAddress not implemented")
+}
+func (UnimplementedWalletServiceServer) Health(context.Context, *v1.HealthRequest) (*v1.HealthResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedWalletServiceServer) CreateInvoice(context.Context, *v1.InvoiceRequest) (*v1.InvoiceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method CreateInvoice not implemented")
+}
+func (UnimplementedWalletServiceServer) ListPayments(context.Context, *v1.ListPaymentsRequest) (*v1.ListPaymentsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListPayments not implemented")
+}
+func (UnimplementedWalletServiceServer) IsAddressUsed(context.Context, *v1.AddressUsedRequest) (*v1.AddressUsedResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method IsAddressUsed not implemented")
+}
+func (UnimplementedWalletServiceServer) mustEmbedUnimplementedWalletServiceServer() {}
+func (UnimplementedWalletServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeWalletServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WalletServiceServer will
+// result in compilation errors.
+type UnsafeWalletServiceServer interface {
+	mustEmbedUnimplementedWalletServiceServer()
+}
+
+func RegisterWalletServiceServer(s grpc.ServiceRegistrar, srv WalletServiceServer) {
+	// If the following call panics, it indicates UnimplementedWalletServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WalletService_ServiceDesc, srv)
+}
+
+func _WalletService_GetAddress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WalletAddressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).GetAddress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_GetAddress_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).GetAddress(ctx, req.(*WalletAddressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).Health(ctx, req.(*v1.HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_CreateInvoice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.InvoiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_CreateInvoice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).CreateInvoice(ctx, req.(*v1.InvoiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_ListPayments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.ListPaymentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).ListPayments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_ListPayments_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).ListPayments(ctx, req.(*v1.ListPaymentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WalletService_IsAddressUsed_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(v1.AddressUsedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WalletServiceServer).IsAddressUsed(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WalletService_IsAddressUsed_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WalletServiceServer).IsAddressUsed(ctx, req.(*v1.AddressUsedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WalletService_ServiceDesc is the grpc.ServiceDesc for WalletService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WalletService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cryptalias.v2.WalletService",
+	HandlerType: (*WalletServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetAddress",
+			Handler:    _WalletService_GetAddress_Handler,
+		},
+		{
+			MethodName: "Health",
+			Handler:    _WalletService_Health_Handler,
+		},
+		{
+			MethodName: "CreateInvoice",
+			Handler:    _WalletService_CreateInvoice_Handler,
+		},
+		{
+			MethodName: "ListPayments",
+			Handler:    _WalletService_ListPayments_Handler,
+		},
+		{
+			MethodName: "IsAddressUsed",
+			Handler:    _WalletService_IsAddressUsed_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "proto/cryptalias/v2/wallet_service.proto",
+}